@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/dnephin/pflag"
+	"gotest.tools/gotestsum/internal/config"
+)
+
+// configArgsKey is the key in the configuration file used for the 'go test'
+// arguments, which are otherwise positional arguments on the command line.
+const configArgsKey = "args"
+
+// flagEnvAnnotation is the key of the flag annotation that holds the name of
+// the environment variable used as the default value of the flag. A flag set by
+// an environment variable takes precedence over the value in the configuration
+// file.
+const flagEnvAnnotation = "gotestsum_env"
+
+// flagEnv maps the name of a flag to the environment variable used as the
+// default value of the flag.
+type flagEnv map[string]string
+
+// lookup records key as the environment variable for the flag, and returns the
+// value of the environment variable, or defValue if it is not set.
+func (e flagEnv) lookup(name, key, defValue string) string {
+	e[name] = key
+	return lookEnvWithDefault(key, defValue)
+}
+
+// annotate adds the name of the environment variable to each flag looked up
+// by lookup.
+func (e flagEnv) annotate(flags *pflag.FlagSet) {
+	for name, key := range e {
+		if err := flags.SetAnnotation(name, flagEnvAnnotation, []string{key}); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// flagEnvVar returns the environment variable used as the default value of
+// the flag, or an empty string if the flag has no environment variable.
+func flagEnvVar(flag *pflag.Flag) string {
+	if values := flag.Annotations[flagEnvAnnotation]; len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// loadConfigFile finds the configuration file for dir, and sets the value of
+// any flag in the file that was not already set by a command line flag or
+// an environment variable.
+func loadConfigFile(flags *pflag.FlagSet, opts *options, dir string) error {
+	file, err := config.Find(dir)
+	switch {
+	case err != nil:
+		return err
+	case file == nil && opts.profile != "":
+		return fmt.Errorf("--profile %v requires a .gotestsum.yaml or .gotestsum.toml file", opts.profile)
+	case file == nil:
+		return nil
+	}
+
+	values, err := file.ProfileValues(opts.profile)
+	if err != nil {
+		return err
+	}
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if err := setFromConfig(flags, opts, key, values[key]); err != nil {
+			return fmt.Errorf("invalid configuration in %v: %w", file.Path, err)
+		}
+	}
+	return nil
+}
+
+func setFromConfig(flags *pflag.FlagSet, opts *options, key string, values []string) error {
+	if key == configArgsKey {
+		if len(opts.args) == 0 {
+			opts.args = values
+		}
+		return nil
+	}
+
+	flag := flags.Lookup(key)
+	switch {
+	case flag == nil:
+		return fmt.Errorf("unknown option %v", key)
+	case key == "profile":
+		return fmt.Errorf("profile can only be set by a flag or GOTESTSUM_PROFILE")
+	case flag.Changed:
+		return nil
+	case flagEnvVar(flag) != "" && os.Getenv(flagEnvVar(flag)) != "":
+		return nil
+	case len(values) > 1 && !isRepeatableFlag(flag):
+		return fmt.Errorf("option %v must be a single value", key)
+	}
+
+	for _, value := range values {
+		if err := flags.Set(key, value); err != nil {
+			return fmt.Errorf("option %v: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// isRepeatableFlag returns true if the flag accepts more than one value.
+func isRepeatableFlag(flag *pflag.Flag) bool {
+	switch flag.Value.(type) {
+	case *commandsValue, *stringSlice, *formatFileValue, *attrFilterValue:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,197 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"regexp"
+	"testing"
+
+	"github.com/dnephin/pflag"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/env"
+	"gotest.tools/v3/fs"
+)
+
+const configFile = `
+format: testname
+packages: [./cmd/..., ./internal/...]
+rerun-fails: 3
+junitfile: junit.xml
+webhook-template: from file
+args: [-race, -count=1]
+profiles:
+  ci:
+    format: pkgname
+    jsonfile: out.json
+`
+
+func TestLoadConfigFile(t *testing.T) {
+	type testCase struct {
+		name     string
+		args     []string
+		env      map[string]string
+		expected func(opts *options)
+	}
+	fn := func(t *testing.T, tc testCase) {
+		defer env.PatchAll(t, tc.env)()
+		dir := fs.NewDir(t, t.Name(),
+			fs.WithFile("go.mod", "module example.com/proj\n"),
+			fs.WithFile(".gotestsum.yaml", configFile))
+
+		flags, opts := setupFlags("gotestsum")
+		assert.NilError(t, flags.Parse(tc.args))
+		opts.args = flags.Args()
+		assert.NilError(t, loadConfigFile(flags, opts, dir.Path()))
+
+		expected := &options{
+			format:                "testname",
+			packages:              []string{"./cmd/...", "./internal/..."},
+			rerunFailsMaxAttempts: 3,
+			junitFile:             "junit.xml",
+			webhookTemplate:       "from file",
+			args:                  []string{"-race", "-count=1"},
+		}
+		if tc.expected != nil {
+			tc.expected(expected)
+		}
+		assert.Equal(t, opts.format, expected.format)
+		assert.DeepEqual(t, opts.packages, expected.packages)
+		assert.Equal(t, opts.rerunFailsMaxAttempts, expected.rerunFailsMaxAttempts)
+		assert.Equal(t, opts.junitFile, expected.junitFile)
+		assert.Equal(t, opts.jsonFile, expected.jsonFile)
+		assert.Equal(t, opts.webhookTemplate, expected.webhookTemplate)
+		assert.DeepEqual(t, opts.args, expected.args)
+	}
+	var testCases = []testCase{
+		{
+			name: "values from file",
+		},
+		{
+			name: "flag overrides env and file",
+			args: []string{"--format=dots", "--rerun-fails=1", "--", "-short"},
+			env:  map[string]string{"GOTESTSUM_FORMAT": "standard-verbose"},
+			expected: func(opts *options) {
+				opts.format = "dots"
+				opts.rerunFailsMaxAttempts = 1
+				opts.args = []string{"-short"}
+			},
+		},
+		{
+			name: "env overrides file",
+			env:  map[string]string{"GOTESTSUM_JUNITFILE": "other.xml"},
+			expected: func(opts *options) {
+				opts.junitFile = "other.xml"
+			},
+		},
+		{
+			name: "env overrides file for webhook-template",
+			env:  map[string]string{"GOTESTSUM_WEBHOOK_TEMPLATE": "from env"},
+			expected: func(opts *options) {
+				opts.webhookTemplate = "from env"
+			},
+		},
+		{
+			name: "profile overrides file",
+			args: []string{"--profile=ci"},
+			expected: func(opts *options) {
+				opts.format = "pkgname"
+				opts.jsonFile = "out.json"
+			},
+		},
+		{
+			name: "profile from env",
+			env:  map[string]string{"GOTESTSUM_PROFILE": "ci"},
+			expected: func(opts *options) {
+				opts.format = "pkgname"
+				opts.jsonFile = "out.json"
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fn(t, tc)
+		})
+	}
+}
+
+// TestSetupFlags_EnvVars checks that every environment variable used as the
+// default value of a flag is recorded on the flag, so that the value from the
+// environment takes precedence over the configuration file.
+func TestSetupFlags_EnvVars(t *testing.T) {
+	source, err := ioutil.ReadFile("main.go")
+	assert.NilError(t, err)
+	keys := regexp.MustCompile(`"(GOTESTSUM_\w+)"`).FindAllStringSubmatch(string(source), -1)
+	assert.Assert(t, len(keys) > 0)
+
+	flags, _ := setupFlags("gotestsum")
+	flagByEnv := map[string]string{}
+	flags.VisitAll(func(flag *pflag.Flag) {
+		if key := flagEnvVar(flag); key != "" {
+			flagByEnv[key] = flag.Name
+		}
+	})
+	for _, key := range keys {
+		_, ok := flagByEnv[key[1]]
+		assert.Assert(t, ok, "no flag uses %v as the default", key[1])
+	}
+	assert.Equal(t, flagByEnv["GOTESTSUM_WEBHOOK_TEMPLATE"], "webhook-template")
+}
+
+func TestLoadConfigFile_Errors(t *testing.T) {
+	type testCase struct {
+		name     string
+		config   string
+		args     []string
+		expected string
+	}
+	fn := func(t *testing.T, tc testCase) {
+		defer env.PatchAll(t, nil)()
+		ops := []fs.PathOp{fs.WithFile("go.mod", "module example.com/proj\n")}
+		if tc.config != "" {
+			ops = append(ops, fs.WithFile(".gotestsum.yaml", tc.config))
+		}
+		dir := fs.NewDir(t, t.Name(), ops...)
+
+		flags, opts := setupFlags("gotestsum")
+		assert.NilError(t, flags.Parse(tc.args))
+		err := loadConfigFile(flags, opts, dir.Path())
+		assert.ErrorContains(t, err, tc.expected)
+	}
+	var testCases = []testCase{
+		{
+			name:     "unknown option",
+			config:   "formatt: dots\n",
+			expected: "unknown option formatt",
+		},
+		{
+			name:     "list for a single value",
+			config:   "format: [dots, testname]\n",
+			expected: "option format must be a single value",
+		},
+		{
+			name:     "invalid value",
+			config:   "rerun-fails: many\n",
+			expected: "option rerun-fails: ",
+		},
+		{
+			name:     "profile in file",
+			config:   "profile: ci\n",
+			expected: "profile can only be set by a flag or GOTESTSUM_PROFILE",
+		},
+		{
+			name:     "missing profile",
+			config:   "format: dots\n",
+			args:     []string{"--profile=ci"},
+			expected: `profile "ci" not found`,
+		},
+		{
+			name:     "profile without a file",
+			args:     []string{"--profile=ci"},
+			expected: "--profile ci requires a .gotestsum.yaml or .gotestsum.toml file",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fn(t, tc)
+		})
+	}
+}
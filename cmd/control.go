@@ -0,0 +1,201 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"gotest.tools/gotestsum/internal/filewatcher"
+	"gotest.tools/gotestsum/internal/log"
+	"gotest.tools/gotestsum/runner"
+	"gotest.tools/gotestsum/testjson"
+)
+
+// controlServer accepts commands from a Unix socket or loopback TCP address
+// and sends them to the file watcher as requests to run tests. It allows
+// editors to trigger a run in watch mode.
+type controlServer struct {
+	requests chan filewatcher.Request
+	// lastExec returns the Execution from the most recent run.
+	lastExec func() *testjson.Execution
+}
+
+func newControlServer(lastExec func() *testjson.Execution) *controlServer {
+	return &controlServer{
+		requests: make(chan filewatcher.Request),
+		lastExec: lastExec,
+	}
+}
+
+// controlHeader must be set on every request to the control server. A web
+// page can not send a request with a custom header to another origin without
+// a CORS preflight, which the control server does not accept, so the header
+// prevents a page open in a browser from sending commands.
+const controlHeader = "X-Gotestsum"
+
+func (s *controlServer) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/run", s.handleRun)
+	mux.HandleFunc("/rerun", s.handleRerun)
+	mux.HandleFunc("/debug", s.handleDebug)
+	mux.HandleFunc("/status", s.handleStatus)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(controlHeader) == "" {
+			http.Error(w, "missing required header: "+controlHeader, http.StatusForbidden)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// validPkg returns an error response and false if pkg could be read as a flag
+// by go test, ex: -toolexec=cmd, instead of a package.
+func validPkg(w http.ResponseWriter, pkg string) bool {
+	if strings.HasPrefix(pkg, "-") {
+		http.Error(w, "invalid package: "+pkg, http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// handleRun runs the tests in the package from the pkg query parameter. If
+// the test query parameter is set, only that test is run.
+func (s *controlServer) handleRun(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodPost) {
+		return
+	}
+	pkg := r.URL.Query().Get("pkg")
+	if pkg == "" {
+		http.Error(w, "missing required query parameter: pkg", http.StatusBadRequest)
+		return
+	}
+	if !validPkg(w, pkg) {
+		return
+	}
+	req := filewatcher.Request{PkgPath: pkg}
+	if test := r.URL.Query().Get("test"); test != "" {
+		req.RunFilter = runner.RunPattern(testjson.TestName(test))
+	}
+	s.send(w, r, req)
+}
+
+// handleRerun runs tests for the package from the previous run.
+func (s *controlServer) handleRerun(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodPost) {
+		return
+	}
+	s.send(w, r, filewatcher.Request{})
+}
+
+// handleDebug runs the tests with delve, for the package from the pkg query
+// parameter, or the package from the previous run.
+func (s *controlServer) handleDebug(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodPost) {
+		return
+	}
+	pkg := r.URL.Query().Get("pkg")
+	if !validPkg(w, pkg) {
+		return
+	}
+	s.send(w, r, filewatcher.Request{PkgPath: pkg, Debug: true})
+}
+
+// handleStatus responds with the summary of the previous run.
+func (s *controlServer) handleStatus(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodGet) {
+		return
+	}
+	s.writeSummary(w)
+}
+
+func requireMethod(w http.ResponseWriter, r *http.Request, method string) bool {
+	if r.Method == method {
+		return true
+	}
+	w.Header().Set("Allow", method)
+	http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	return false
+}
+
+// send the request to the file watcher, wait for the run to complete, and
+// respond with the summary of the run.
+func (s *controlServer) send(w http.ResponseWriter, r *http.Request, req filewatcher.Request) {
+	req.Done = make(chan struct{})
+	select {
+	case s.requests <- req:
+	case <-r.Context().Done():
+		return
+	}
+	select {
+	case <-req.Done:
+	case <-r.Context().Done():
+		return
+	}
+	s.writeSummary(w)
+}
+
+func (s *controlServer) writeSummary(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	summary := jsonSummary{Status: statusNone, Packages: []jsonPackage{}}
+	if exec := s.lastExec(); exec != nil {
+		summary = newJSONSummary(exec)
+	}
+	if err := json.NewEncoder(w).Encode(summary); err != nil {
+		log.Warnf("failed to write control response: %v", err)
+	}
+}
+
+// listenControl listens on addr. If addr has a unix: prefix the rest of the
+// value is used as the path to a Unix socket, otherwise addr must be a
+// loopback TCP address.
+func listenControl(addr string) (net.Listener, error) {
+	if path := strings.TrimPrefix(addr, "unix:"); path != addr {
+		// remove a socket left behind by a previous run
+		if info, err := os.Stat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+			if err := os.Remove(path); err != nil {
+				return nil, err
+			}
+		}
+		return net.Listen("unix", path)
+	}
+
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	if host != "localhost" {
+		if ip := net.ParseIP(host); ip == nil || !ip.IsLoopback() {
+			return nil, fmt.Errorf("address %v must use a loopback host", addr)
+		}
+	}
+	return net.Listen("tcp", addr)
+}
+
+// serve the control server on addr. The returned function stops the server.
+func (s *controlServer) serve(addr string) (func(), error) {
+	listener, err := listenControl(addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %v: %w", addr, err)
+	}
+	fmt.Printf("Listening for commands on %v\n", listener.Addr())
+
+	srv := &http.Server{Handler: s.handler(), ReadHeaderTimeout: 10 * time.Second}
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if err := srv.Serve(listener); err != nil && err != http.ErrServerClosed {
+			log.Errorf("control server failed: %v", err)
+		}
+	}()
+	return func() {
+		// nolint: errcheck // the server is shutting down
+		srv.Close()
+		wg.Wait()
+	}, nil
+}
@@ -0,0 +1,142 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gotest.tools/gotestsum/internal/filewatcher"
+	"gotest.tools/gotestsum/testjson"
+	"gotest.tools/v3/assert"
+)
+
+func TestControlServer(t *testing.T) {
+	var exec *testjson.Execution
+	srv := newControlServer(func() *testjson.Execution { return exec })
+	httpSrv := httptest.NewServer(srv.handler())
+	t.Cleanup(httpSrv.Close)
+
+	received := make(chan filewatcher.Request, 1)
+	go func() {
+		for req := range srv.requests {
+			exec = newExecFromTestData(t)
+			received <- req
+			close(req.Done)
+		}
+	}()
+	t.Cleanup(func() { close(srv.requests) })
+
+	t.Run("status before any run", func(t *testing.T) {
+		resp, err := controlGet(t, httpSrv.URL+"/status")
+		assert.NilError(t, err)
+		defer resp.Body.Close() // nolint: errcheck
+
+		body, err := ioutil.ReadAll(resp.Body)
+		assert.NilError(t, err)
+		assert.Equal(t, string(body),
+			`{"status":"none","total":0,"failed":0,"skipped":0,"elapsed":0,"runs":0,"packages":[]}`+"\n")
+	})
+
+	t.Run("run test", func(t *testing.T) {
+		resp, err := controlPost(t, httpSrv.URL+"/run?pkg=./foo&test=TestOne/sub")
+		assert.NilError(t, err)
+		defer resp.Body.Close() // nolint: errcheck
+
+		req := <-received
+		assert.Equal(t, req.PkgPath, "./foo")
+		assert.Equal(t, req.RunFilter, "^TestOne$/^sub$")
+		assert.Equal(t, req.Debug, false)
+
+		var summary jsonSummary
+		assert.NilError(t, json.NewDecoder(resp.Body).Decode(&summary))
+		assert.Equal(t, summary.Status, statusFail)
+		assert.Equal(t, summary.Total, 59)
+		assert.Equal(t, summary.Failed, 13)
+		assert.Equal(t, summary.Failures[1].Test, "TestNestedParallelFailures/a")
+	})
+
+	t.Run("run without package", func(t *testing.T) {
+		resp, err := controlPost(t, httpSrv.URL+"/run")
+		assert.NilError(t, err)
+		defer resp.Body.Close() // nolint: errcheck
+		assert.Equal(t, resp.StatusCode, http.StatusBadRequest)
+	})
+
+	t.Run("rerun", func(t *testing.T) {
+		resp, err := controlPost(t, httpSrv.URL+"/rerun")
+		assert.NilError(t, err)
+		defer resp.Body.Close() // nolint: errcheck
+		assert.Equal(t, resp.StatusCode, http.StatusOK)
+
+		req := <-received
+		assert.Equal(t, req.PkgPath, "")
+	})
+
+	t.Run("debug", func(t *testing.T) {
+		resp, err := controlPost(t, httpSrv.URL+"/debug?pkg=./bar")
+		assert.NilError(t, err)
+		defer resp.Body.Close() // nolint: errcheck
+		assert.Equal(t, resp.StatusCode, http.StatusOK)
+
+		req := <-received
+		assert.Equal(t, req.PkgPath, "./bar")
+		assert.Equal(t, req.Debug, true)
+	})
+
+	t.Run("package is a flag", func(t *testing.T) {
+		for _, path := range []string{"/run?pkg=-toolexec=/bin/sh", "/debug?pkg=-exec=/bin/sh"} {
+			resp, err := controlPost(t, httpSrv.URL+path)
+			assert.NilError(t, err)
+			resp.Body.Close() // nolint: errcheck
+			assert.Equal(t, resp.StatusCode, http.StatusBadRequest, path)
+		}
+	})
+
+	t.Run("missing header", func(t *testing.T) {
+		resp, err := http.Post(httpSrv.URL+"/run?pkg=./foo", "", nil)
+		assert.NilError(t, err)
+		defer resp.Body.Close() // nolint: errcheck
+		assert.Equal(t, resp.StatusCode, http.StatusForbidden)
+
+		resp, err = http.Get(httpSrv.URL + "/status")
+		assert.NilError(t, err)
+		defer resp.Body.Close() // nolint: errcheck
+		assert.Equal(t, resp.StatusCode, http.StatusForbidden)
+	})
+
+	t.Run("wrong method", func(t *testing.T) {
+		resp, err := controlGet(t, httpSrv.URL+"/rerun")
+		assert.NilError(t, err)
+		defer resp.Body.Close() // nolint: errcheck
+		assert.Equal(t, resp.StatusCode, http.StatusMethodNotAllowed)
+	})
+}
+
+func TestListenControl_RequiresLoopback(t *testing.T) {
+	_, err := listenControl("0.0.0.0:0")
+	assert.Error(t, err, "address 0.0.0.0:0 must use a loopback host")
+
+	listener, err := listenControl("127.0.0.1:0")
+	assert.NilError(t, err)
+	assert.NilError(t, listener.Close())
+}
+
+// controlGet sends a GET request with the header required by the control server.
+func controlGet(t *testing.T, url string) (*http.Response, error) {
+	return controlRequest(t, http.MethodGet, url)
+}
+
+// controlPost sends a POST request with the header required by the control server.
+func controlPost(t *testing.T, url string) (*http.Response, error) {
+	return controlRequest(t, http.MethodPost, url)
+}
+
+func controlRequest(t *testing.T, method string, url string) (*http.Response, error) {
+	t.Helper()
+	req, err := http.NewRequest(method, url, nil)
+	assert.NilError(t, err)
+	req.Header.Set(controlHeader, "1")
+	return http.DefaultClient.Do(req)
+}
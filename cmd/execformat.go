@@ -0,0 +1,185 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/google/shlex"
+	"gotest.tools/gotestsum/internal/log"
+	"gotest.tools/gotestsum/testjson"
+)
+
+// execFormatPrefix is the prefix of a --format value which runs a command to
+// format the events.
+const execFormatPrefix = "exec:"
+
+// execFormatVersion is the version of the protocol used to send messages to
+// the command from --format exec:. It is incremented when a change is not
+// backwards compatible.
+const execFormatVersion = 1
+
+const (
+	execMessageBegin = "begin"
+	execMessageEvent = "event"
+	execMessageEnd   = "end"
+)
+
+// execFormatMessage is written to the stdin of the command from --format exec:
+// as a single line of JSON. The first message is always a begin message, and
+// the last message is always an end message. There is an event message for
+// every TestEvent in between.
+type execFormatMessage struct {
+	Type    string `json:"type"`
+	Version int    `json:"version"`
+
+	// Begin is set on begin messages.
+	Begin *execFormatBegin `json:"begin,omitempty"`
+
+	// Event is set on event messages.
+	Event *testjson.TestEvent `json:"event,omitempty"`
+	// TestID is the ID of the test case from the event. It is set on event
+	// messages for events that are not package events.
+	TestID int `json:"testID,omitempty"`
+	// Package is the state of the package from the event, set on event messages.
+	Package *jsonPackage `json:"package,omitempty"`
+
+	// End is set on end messages.
+	End *execFormatEnd `json:"end,omitempty"`
+}
+
+type execFormatBegin struct {
+	Time time.Time `json:"time"`
+	// Command is the command used to run the tests.
+	Command []string `json:"command"`
+	// Color is true when the output should use color.
+	Color bool `json:"color"`
+}
+
+type execFormatEnd struct {
+	Time     time.Time `json:"time"`
+	ExitCode int       `json:"exitCode"`
+	// Summary of the test run. It is the same JSON that is sent to
+	// --post-run-command. Summary is nil if the tests could not be run.
+	Summary *jsonSummary `json:"summary"`
+}
+
+// execFormatter sends every event to a subprocess, which is expected to write
+// the formatted output to its stdout.
+type execFormatter struct {
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+	enc   *json.Encoder
+}
+
+func newExecFormatter(
+	opts *options,
+	out io.Writer,
+	command string,
+	formatOpts testjson.FormatOptions,
+) (*execFormatter, error) {
+	args, err := shlex.Split(command)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) == 0 {
+		return nil, fmt.Errorf("missing command")
+	}
+
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdout = out
+	cmd.Stderr = opts.stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	log.Debugf("exec: %s", cmd.Args)
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to run %s: %w", strings.Join(cmd.Args, " "), err)
+	}
+
+	f := &execFormatter{cmd: cmd, stdin: stdin, enc: json.NewEncoder(stdin)}
+	err = f.send(execFormatMessage{
+		Type: execMessageBegin,
+		Begin: &execFormatBegin{
+			Time:    time.Now(),
+			Command: runnerConfig(opts).Command(),
+			Color:   useColor(formatOpts.Color),
+		},
+	})
+	if err != nil {
+		f.stdin.Close() // nolint: errcheck
+		f.cmd.Wait()    // nolint: errcheck
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *execFormatter) send(msg execFormatMessage) error {
+	msg.Version = execFormatVersion
+	return f.enc.Encode(msg)
+}
+
+func (f *execFormatter) Format(event testjson.TestEvent, exec *testjson.Execution) error {
+	msg := execFormatMessage{Type: execMessageEvent, Event: &event}
+	if pkg := exec.Package(event.Package); pkg != nil {
+		p := newJSONPackage(event.Package, pkg)
+		msg.Package = &p
+		if !event.PackageEvent() {
+			msg.TestID = pkg.TestCaseByName(event.Test).ID
+		}
+	}
+	return f.send(msg)
+}
+
+// Finish sends the end message, and waits for the command to exit.
+func (f *execFormatter) Finish(exec *testjson.Execution, exitErr error) error {
+	end := &execFormatEnd{Time: time.Now(), ExitCode: ExitCodeWithDefault(exitErr)}
+	if exec != nil {
+		summary := newJSONSummary(exec)
+		end.Summary = &summary
+	}
+	sendErr := f.send(execFormatMessage{Type: execMessageEnd, End: end})
+	if err := f.stdin.Close(); err != nil && sendErr == nil {
+		sendErr = err
+	}
+	if err := f.cmd.Wait(); err != nil {
+		return fmt.Errorf("%s: %w", strings.Join(f.cmd.Args, " "), err)
+	}
+	return sendErr
+}
+
+// formatFinisher is implemented by formatters that must be notified when the
+// run has ended.
+type formatFinisher interface {
+	Finish(exec *testjson.Execution, exitErr error) error
+}
+
+// finishFormatter notifies the formatter that the run has ended. Errors are
+// logged instead of returned, so that the results of the run are still
+// reported.
+func finishFormatter(formatter testjson.EventFormatter, exec *testjson.Execution, exitErr error) {
+	f, ok := formatter.(formatFinisher)
+	if !ok {
+		return
+	}
+	if err := f.Finish(exec, exitErr); err != nil {
+		log.Errorf("Failed to finish --format: %v", err)
+	}
+}
+
+// useColor returns true if output with the color setting from mode should
+// use color.
+func useColor(mode testjson.ColorMode) bool {
+	switch mode {
+	case testjson.ColorAlways:
+		return true
+	case testjson.ColorNever:
+		return false
+	}
+	return !color.NoColor
+}
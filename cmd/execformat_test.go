@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+
+	"gotest.tools/gotestsum/testjson"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/skip"
+)
+
+func TestExecFormatter(t *testing.T) {
+	skip.If(t, runtime.GOOS == "windows", "cat is not available")
+
+	buf := new(bytes.Buffer)
+	opts := &options{format: "exec:cat", stdout: buf, args: []string{"./..."}}
+	formatter, err := newFormatter(opts)
+	assert.NilError(t, err)
+
+	f, err := os.Open("../testjson/testdata/input/go-test-json.out")
+	assert.NilError(t, err)
+	defer f.Close() // nolint: errcheck
+
+	exec, err := testjson.ScanTestOutput(testjson.ScanConfig{
+		Stdout:  f,
+		Stderr:  strings.NewReader(""),
+		Handler: &formatHandler{formatter: formatter},
+	})
+	assert.NilError(t, err)
+	finishFormatter(formatter, exec, nil)
+
+	var messages []execFormatMessage
+	scanner := bufio.NewScanner(buf)
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		var msg execFormatMessage
+		assert.NilError(t, json.Unmarshal(scanner.Bytes(), &msg))
+		assert.Equal(t, msg.Version, execFormatVersion)
+		messages = append(messages, msg)
+	}
+	assert.NilError(t, scanner.Err())
+	assert.Assert(t, len(messages) > 2)
+
+	first := messages[0]
+	assert.Equal(t, first.Type, execMessageBegin)
+	assert.DeepEqual(t, first.Begin.Command, []string{"go", "test", "-json", "./..."})
+
+	last := messages[len(messages)-1]
+	assert.Equal(t, last.Type, execMessageEnd)
+	assert.Equal(t, last.End.ExitCode, 0)
+	assert.Equal(t, last.End.Summary.Total, exec.Total())
+	assert.Equal(t, last.End.Summary.Failed, len(exec.Failed()))
+
+	var events, withTestID int
+	for _, msg := range messages[1 : len(messages)-1] {
+		assert.Equal(t, msg.Type, execMessageEvent)
+		assert.Equal(t, msg.Package.Name, msg.Event.Package)
+		events++
+		if msg.TestID > 0 {
+			withTestID++
+		}
+	}
+	assert.Equal(t, events, countLines(t, "../testjson/testdata/input/go-test-json.out"))
+	assert.Assert(t, withTestID > 0)
+}
+
+func TestNewFormatter_ExecMissingCommand(t *testing.T) {
+	_, err := newFormatter(&options{format: "exec:"})
+	assert.Error(t, err, "invalid --format: missing command")
+}
+
+// formatHandler is a testjson.EventHandler which sends every event to a
+// formatter.
+type formatHandler struct {
+	formatter testjson.EventFormatter
+}
+
+func (h *formatHandler) Event(event testjson.TestEvent, exec *testjson.Execution) error {
+	return h.formatter.Format(event, exec)
+}
+
+func (h *formatHandler) Err(text string) error {
+	if f, ok := h.formatter.(testjson.ErrFormatter); ok {
+		return f.FormatErr(text)
+	}
+	return nil
+}
+
+func countLines(t *testing.T, path string) int {
+	t.Helper()
+	raw, err := ioutil.ReadFile(path)
+	assert.NilError(t, err)
+	return strings.Count(strings.TrimSpace(string(raw)), "\n") + 1
+}
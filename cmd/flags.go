@@ -3,12 +3,14 @@ package cmd
 import (
 	"encoding/csv"
 	"fmt"
+	"io/ioutil"
 	"path"
 	"strings"
 
 	"github.com/dnephin/pflag"
 	"github.com/google/shlex"
 	"gotest.tools/gotestsum/internal/junitxml"
+	"gotest.tools/gotestsum/internal/notify"
 	"gotest.tools/gotestsum/testjson"
 )
 
@@ -114,6 +116,49 @@ func (c *commandValue) Value() []string {
 	return c.command
 }
 
+// commandsValue is a flag.Value which appends a new command each time the
+// flag is set.
+type commandsValue struct {
+	commands []commandValue
+}
+
+func (c *commandsValue) String() string {
+	if c == nil {
+		return ""
+	}
+	values := make([]string, 0, len(c.commands))
+	for _, cmd := range c.commands {
+		values = append(values, cmd.String())
+	}
+	return strings.Join(values, ", ")
+}
+
+func (c *commandsValue) Set(raw string) error {
+	cmd := commandValue{}
+	if err := cmd.Set(raw); err != nil {
+		return err
+	}
+	if len(cmd.command) > 0 {
+		c.commands = append(c.commands, cmd)
+	}
+	return nil
+}
+
+func (c *commandsValue) Type() string {
+	return "command"
+}
+
+func (c *commandsValue) Value() [][]string {
+	if c == nil {
+		return nil
+	}
+	result := make([][]string, 0, len(c.commands))
+	for _, cmd := range c.commands {
+		result = append(result, cmd.Value())
+	}
+	return result
+}
+
 var _ pflag.Value = (*stringSlice)(nil)
 
 // stringSlice is a flag.Value which populates the string slice by splitting
@@ -140,3 +185,108 @@ func truthyFlag(s string) bool {
 	}
 	return false
 }
+
+// notifyValue is the --notify flag. It also stores the result of the previous
+// run, so that in watch mode a notification is only sent when the status
+// changes.
+type notifyValue struct {
+	name string
+	// last is the Failed field of the last notification, or nil if no
+	// notification has been sent.
+	last *bool
+}
+
+func (v *notifyValue) Set(val string) error {
+	if _, err := notify.New(val, ioutil.Discard); err != nil {
+		return err
+	}
+	v.name = val
+	return nil
+}
+
+func (v *notifyValue) Type() string {
+	return "notifier"
+}
+
+func (v *notifyValue) String() string {
+	if v == nil {
+		return ""
+	}
+	return v.name
+}
+
+// formatFileColorSuffix is the suffix of a --format-file value which enables
+// color in the file.
+const formatFileColorSuffix = ",color"
+
+// formatFileValue is the --format-file flag. Each value is a format, and the
+// path to the file where the output of the format is written.
+type formatFileValue []formatFile
+
+type formatFile struct {
+	format string
+	path   string
+	color  bool
+}
+
+func (f formatFile) String() string {
+	if f.color {
+		return f.format + "=" + f.path + formatFileColorSuffix
+	}
+	return f.format + "=" + f.path
+}
+
+func (v *formatFileValue) Set(raw string) error {
+	var ff formatFile
+	value := raw
+	if strings.HasSuffix(value, formatFileColorSuffix) {
+		ff.color = true
+		value = strings.TrimSuffix(value, formatFileColorSuffix)
+	}
+	// The format may contain '=' (ex: template=TEMPLATE), so the path is
+	// everything after the last '='.
+	i := strings.LastIndex(value, "=")
+	if i <= 0 || i == len(value)-1 {
+		return fmt.Errorf("%v must be in the form <format>=<path>", raw)
+	}
+	ff.format, ff.path = value[:i], value[i+1:]
+	*v = append(*v, ff)
+	return nil
+}
+
+func (v *formatFileValue) String() string {
+	values := make([]string, 0, len(*v))
+	for _, ff := range *v {
+		values = append(values, ff.String())
+	}
+	return strings.Join(values, " ")
+}
+
+func (v *formatFileValue) Type() string {
+	return "format=path"
+}
+
+// attrFilterValue is the --summary-attr flag. Each value is an attribute, set
+// by a test with testing.T.Attr, in the form key=value.
+type attrFilterValue []testjson.Attr
+
+func (v *attrFilterValue) Set(raw string) error {
+	i := strings.Index(raw, "=")
+	if i <= 0 {
+		return fmt.Errorf("%v must be in the form <key>=<value>", raw)
+	}
+	*v = append(*v, testjson.Attr{Key: raw[:i], Value: raw[i+1:]})
+	return nil
+}
+
+func (v *attrFilterValue) String() string {
+	values := make([]string, 0, len(*v))
+	for _, attr := range *v {
+		values = append(values, attr.String())
+	}
+	return strings.Join(values, " ")
+}
+
+func (v *attrFilterValue) Type() string {
+	return "key=value"
+}
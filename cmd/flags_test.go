@@ -3,6 +3,8 @@ package cmd
 import (
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
+
 	"gotest.tools/v3/assert"
 )
 
@@ -33,3 +35,44 @@ func TestStringSlice(t *testing.T) {
 	assert.NilError(t, ss.Set(value))
 	assert.DeepEqual(t, v, []string{"one", "two", "three", "four", "five"})
 }
+
+func TestFormatFileValue_Set(t *testing.T) {
+	var v formatFileValue
+	assert.NilError(t, v.Set("testname=out/console.log"))
+	assert.NilError(t, v.Set("standard-verbose=verbose.log,color"))
+	assert.NilError(t, v.Set("template={{ .Action }}=actions.log"))
+	expected := formatFileValue{
+		{format: "testname", path: "out/console.log"},
+		{format: "standard-verbose", path: "verbose.log", color: true},
+		{format: "template={{ .Action }}", path: "actions.log"},
+	}
+	assert.DeepEqual(t, v, expected, cmp.AllowUnexported(formatFile{}))
+	assert.Equal(t, v.String(),
+		"testname=out/console.log standard-verbose=verbose.log,color template={{ .Action }}=actions.log")
+
+	t.Run("bad value", func(t *testing.T) {
+		for _, value := range []string{"testname", "=out.log", "testname="} {
+			assert.ErrorContains(t, v.Set(value), "must be in the form <format>=<path>", value)
+		}
+	})
+}
+
+func TestAttrFilterValue_Set(t *testing.T) {
+	var v attrFilterValue
+	assert.NilError(t, v.Set("owner=team-a"))
+	assert.NilError(t, v.Set("query=a=b"))
+	assert.NilError(t, v.Set("empty="))
+	expected := attrFilterValue{
+		{Key: "owner", Value: "team-a"},
+		{Key: "query", Value: "a=b"},
+		{Key: "empty", Value: ""},
+	}
+	assert.DeepEqual(t, v, expected)
+	assert.Equal(t, v.String(), "owner=team-a query=a=b empty=")
+
+	t.Run("bad value", func(t *testing.T) {
+		for _, value := range []string{"owner", "=team-a"} {
+			assert.ErrorContains(t, v.Set(value), "must be in the form <key>=<value>", value)
+		}
+	})
+}
@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+
+	"gotest.tools/gotestsum/testjson"
+)
+
+// fileFormatter is a formatter from --format-file. It writes to a file, and
+// uses the color setting of the file instead of the one from --no-color.
+type fileFormatter struct {
+	formatter testjson.EventFormatter
+	file      *os.File
+}
+
+func newFileFormatter(opts *options, ff formatFile) (*fileFormatter, error) {
+	_ = os.MkdirAll(filepath.Dir(ff.path), 0o755)
+	file, err := os.Create(ff.path)
+	if err != nil {
+		return nil, err
+	}
+	formatOpts := opts.formatOptions
+	formatOpts.Color = testjson.ColorNever
+	if ff.color {
+		formatOpts.Color = testjson.ColorAlways
+	}
+	formatter, err := newFormatterForOutput(opts, file, ff.format, formatOpts)
+	if err != nil {
+		file.Close() // nolint: errcheck
+		return nil, err
+	}
+	return &fileFormatter{formatter: formatter, file: file}, nil
+}
+
+func (f *fileFormatter) Format(event testjson.TestEvent, exec *testjson.Execution) error {
+	return f.formatter.Format(event, exec)
+}
+
+// FormatErr writes the lines from the stderr of 'go test' to the file, so
+// that errors like build failures are included. Formats which print stderr
+// lines themselves are used instead.
+func (f *fileFormatter) FormatErr(text string) error {
+	switch formatter := f.formatter.(type) {
+	case testjson.ErrFormatter:
+		return formatter.FormatErr(text)
+	case *execFormatter:
+		// the file is the stdout of the command, do not write to it
+		return nil
+	}
+	_, err := f.file.WriteString(text + "\n")
+	return err
+}
+
+// Finish notifies the formatter that the run has ended, and closes the file.
+func (f *fileFormatter) Finish(exec *testjson.Execution, exitErr error) error {
+	var err error
+	if finisher, ok := f.formatter.(formatFinisher); ok {
+		err = finisher.Finish(exec, exitErr)
+	}
+	if closeErr := f.file.Close(); closeErr != nil && err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// multiFormatter sends every event to each of the formatters, in order.
+type multiFormatter []testjson.EventFormatter
+
+func (m multiFormatter) Format(event testjson.TestEvent, exec *testjson.Execution) error {
+	for _, formatter := range m {
+		if err := formatter.Format(event, exec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m multiFormatter) FormatErr(text string) error {
+	for _, formatter := range m {
+		if f, ok := formatter.(testjson.ErrFormatter); ok {
+			if err := f.FormatErr(text); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Finish notifies every formatter that the run has ended. The first error is
+// returned after all the formatters have been notified.
+func (m multiFormatter) Finish(exec *testjson.Execution, exitErr error) error {
+	var result error
+	for _, formatter := range m {
+		if f, ok := formatter.(formatFinisher); ok {
+			if err := f.Finish(exec, exitErr); err != nil && result == nil {
+				result = err
+			}
+		}
+	}
+	return result
+}
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+	"gotest.tools/gotestsum/testjson"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+)
+
+func TestNewFormatter_WithFormatFiles(t *testing.T) {
+	patchNoColor(t, true)
+	dir := fs.NewDir(t, t.Name())
+
+	var formatFiles formatFileValue
+	assert.NilError(t, formatFiles.Set("standard-quiet="+dir.Join("quiet.log")))
+	assert.NilError(t, formatFiles.Set("testname="+dir.Join("out", "testname.log")+",color"))
+
+	buf := new(bytes.Buffer)
+	opts := &options{format: "pkgname", formatFiles: formatFiles, stdout: buf}
+	formatter, err := newFormatter(opts)
+	assert.NilError(t, err)
+
+	exec, err := testjson.ScanTestOutput(testjson.ScanConfig{
+		Stdout:  strings.NewReader(formatFileInput),
+		Stderr:  strings.NewReader(""),
+		Handler: &formatHandler{formatter: formatter},
+	})
+	assert.NilError(t, err)
+	assert.NilError(t, formatter.(testjson.ErrFormatter).FormatErr("build failed"))
+	finishFormatter(formatter, exec, nil)
+	assert.Assert(t, color.NoColor, "global color setting was changed")
+
+	assert.Equal(t, buf.String(), "✖  example.com/pkg (10ms)\n")
+
+	expected := fs.Expected(t,
+		fs.WithFile("quiet.log", "FAIL\nbuild failed\n"),
+		fs.WithDir("out",
+			fs.WithFile("testname.log", "--- FAIL: TestOne (0.01s)\n"+
+				"\x1b[31mFAIL\x1b[0m example.com/pkg.TestOne (0.01s)\n"+
+				"\x1b[31mFAIL\x1b[0m example.com/pkg\n"+
+				"build failed\n")))
+	assert.Assert(t, fs.Equal(dir.Path(), expected))
+}
+
+func TestNewFormatter_WithFormatFiles_UnknownFormat(t *testing.T) {
+	dir := fs.NewDir(t, t.Name())
+	var formatFiles formatFileValue
+	assert.NilError(t, formatFiles.Set("bogus="+dir.Join("out.log")))
+
+	_, err := newFormatter(&options{format: "testname", formatFiles: formatFiles})
+	assert.ErrorContains(t, err, "invalid --format-file bogus=")
+	assert.ErrorContains(t, err, "unknown format bogus")
+}
+
+// patchNoColor sets color.NoColor for the duration of the test.
+func patchNoColor(t *testing.T, noColor bool) {
+	orig := color.NoColor
+	color.NoColor = noColor
+	t.Cleanup(func() {
+		color.NoColor = orig
+	})
+}
+
+const formatFileInput = `{"Action":"run","Package":"example.com/pkg","Test":"TestOne"}
+{"Action":"output","Package":"example.com/pkg","Test":"TestOne","Output":"--- FAIL: TestOne (0.01s)\n"}
+{"Action":"fail","Package":"example.com/pkg","Test":"TestOne","Elapsed":0.01}
+{"Action":"output","Package":"example.com/pkg","Output":"FAIL\n"}
+{"Action":"fail","Package":"example.com/pkg","Elapsed":0.01}
+`
@@ -1,24 +1,33 @@
 package cmd
 
 import (
-	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"gotest.tools/gotestsum/internal/junitxml"
 	"gotest.tools/gotestsum/internal/log"
+	"gotest.tools/gotestsum/internal/markdown"
+	"gotest.tools/gotestsum/internal/metrics"
+	"gotest.tools/gotestsum/internal/notify"
+	"gotest.tools/gotestsum/internal/otlp"
+	"gotest.tools/gotestsum/runner"
 	"gotest.tools/gotestsum/testjson"
 )
 
+// eventHandler writes events to the files from --jsonfile and
+// --jsonfile-timing-events.
 type eventHandler struct {
-	formatter            testjson.EventFormatter
-	err                  *bufio.Writer
 	jsonFile             writeSyncer
 	jsonFileTimingEvents writeSyncer
-	maxFails             int
 }
 
 type writeSyncer interface {
@@ -26,16 +35,11 @@ type writeSyncer interface {
 	Sync() error
 }
 
-// nolint:errcheck
-func (h *eventHandler) Err(text string) error {
-	h.err.WriteString(text)
-	h.err.WriteRune('\n')
-	h.err.Flush()
-	// always return nil, no need to stop scanning if the stderr write fails
+func (h *eventHandler) Err(string) error {
 	return nil
 }
 
-func (h *eventHandler) Event(event testjson.TestEvent, execution *testjson.Execution) error {
+func (h *eventHandler) Event(event testjson.TestEvent, _ *testjson.Execution) error {
 	if err := writeWithNewline(h.jsonFile, event.Bytes()); err != nil {
 		return fmt.Errorf("failed to write JSON file: %w", err)
 	}
@@ -44,15 +48,6 @@ func (h *eventHandler) Event(event testjson.TestEvent, execution *testjson.Execu
 			return fmt.Errorf("failed to write JSON file: %w", err)
 		}
 	}
-
-	err := h.formatter.Format(event, execution)
-	if err != nil {
-		return fmt.Errorf("failed to format event: %w", err)
-	}
-
-	if h.maxFails > 0 && len(execution.Failed()) >= h.maxFails {
-		return fmt.Errorf("ending test run because max failures was reached")
-	}
 	return nil
 }
 
@@ -97,16 +92,52 @@ func (h *eventHandler) Close() error {
 
 var _ testjson.EventHandler = &eventHandler{}
 
-func newEventHandler(opts *options) (*eventHandler, error) {
-	formatter := testjson.NewEventFormatter(opts.stdout, opts.format, opts.formatOptions)
-	if formatter == nil {
-		return nil, fmt.Errorf("unknown format %s", opts.format)
+func newFormatter(opts *options) (testjson.EventFormatter, error) {
+	formatter, err := newFormatterForOutput(opts, opts.stdout, opts.format, opts.formatOptions)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --format: %w", err)
+	}
+	if len(opts.formatFiles) == 0 {
+		return formatter, nil
+	}
+
+	formatters := multiFormatter{formatter}
+	for _, ff := range opts.formatFiles {
+		formatter, err := newFileFormatter(opts, ff)
+		if err != nil {
+			formatters.Finish(nil, nil) // nolint: errcheck
+			return nil, fmt.Errorf("invalid --format-file %v: %w", ff, err)
+		}
+		formatters = append(formatters, formatter)
+	}
+	return formatters, nil
+}
+
+func newFormatterForOutput(
+	opts *options,
+	out io.Writer,
+	format string,
+	formatOpts testjson.FormatOptions,
+) (testjson.EventFormatter, error) {
+	switch {
+	case strings.HasPrefix(format, execFormatPrefix):
+		return newExecFormatter(opts, out, strings.TrimPrefix(format, execFormatPrefix), formatOpts)
+	case strings.HasPrefix(format, testjson.TemplateFormatPrefix):
+		tmpl, err := testjson.ParseTemplate(strings.TrimPrefix(format, testjson.TemplateFormatPrefix))
+		if err != nil {
+			return nil, err
+		}
+		return testjson.NewTemplateFormatter(out, tmpl, formatOpts), nil
 	}
-	handler := &eventHandler{
-		formatter: formatter,
-		err:       bufio.NewWriter(opts.stderr),
-		maxFails:  opts.maxFails,
+	formatter := testjson.NewEventFormatter(out, format, formatOpts)
+	if formatter == nil {
+		return nil, fmt.Errorf("unknown format %s", format)
 	}
+	return formatter, nil
+}
+
+func newEventHandler(opts *options) (*eventHandler, error) {
+	handler := &eventHandler{}
 	var err error
 	if opts.jsonFile != "" {
 		_ = os.MkdirAll(filepath.Dir(opts.jsonFile), 0o755)
@@ -125,6 +156,32 @@ func newEventHandler(opts *options) (*eventHandler, error) {
 	return handler, nil
 }
 
+// printSummary prints the summary of the execution, using the template from
+// --summary-template when it is set. The tests in the summary are filtered and
+// grouped by the attributes from --summary-attr and --summary-group-by-attr.
+func printSummary(opts *options, execution *testjson.Execution) error {
+	if opts.summaryTemplate == "" {
+		sections := opts.hideSummary.value
+		if opts.summaryClusters {
+			sections |= testjson.SummarizeClusters
+		}
+		testjson.PrintSummaryWithOptions(opts.stdout, execution, testjson.SummaryOptions{
+			Sections: sections,
+			Attrs: testjson.SummaryAttrs{
+				Filter:  opts.summaryAttrs,
+				GroupBy: opts.summaryGroupByAttr,
+			},
+			ReproduceCommand: reproduceCommand(opts, execution),
+		})
+		return nil
+	}
+	tmpl, err := testjson.ParseTemplate(opts.summaryTemplate)
+	if err != nil {
+		return err
+	}
+	return testjson.PrintSummaryTemplate(opts.stdout, execution, tmpl)
+}
+
 func writeJUnitFile(opts *options, execution *testjson.Execution) error {
 	if opts.junitFile == "" {
 		return nil
@@ -145,27 +202,229 @@ func writeJUnitFile(opts *options, execution *testjson.Execution) error {
 		FormatTestSuiteName:     opts.junitTestSuiteNameFormat.Value(),
 		FormatTestCaseClassname: opts.junitTestCaseClassnameFormat.Value(),
 		HideEmptyPackages:       opts.junitHideEmptyPackages,
+		ReproduceCommand:        reproduceCommand(opts, execution),
 	})
 }
 
+// reproduceCommand returns a function that returns the command to run a failed
+// test again, or nil when --reproduce-commands is not set. The command uses the
+// same args as the original run, and the shuffle seed of the package.
+func reproduceCommand(opts *options, execution *testjson.Execution) func(testjson.TestCase) string {
+	if !opts.reproduceCommands {
+		return nil
+	}
+	cfg := runnerConfig(opts)
+	return func(tc testjson.TestCase) string {
+		var seed string
+		if pkg := execution.Package(tc.Package); pkg != nil {
+			seed = pkg.ShuffleSeed()
+		}
+		return runner.QuoteCommand(cfg.ReproduceCommand(tc, seed))
+	}
+}
+
+func writeMarkdownSummary(opts *options, execution *testjson.Execution) error {
+	stepSummary := os.Getenv("GITHUB_STEP_SUMMARY")
+	if opts.markdownFile == "" && stepSummary == "" {
+		return nil
+	}
+
+	buf := new(bytes.Buffer)
+	err := markdown.Write(buf, execution, markdown.Config{
+		MaxOutputBytes: opts.markdownMaxOutput,
+		NumSlowest:     10,
+		Clusters:       opts.summaryClusters,
+	})
+	if err != nil {
+		return err
+	}
+
+	if opts.markdownFile != "" {
+		_ = os.MkdirAll(filepath.Dir(opts.markdownFile), 0o755)
+		if err := writeFile(opts.markdownFile, os.O_TRUNC, buf.Bytes()); err != nil {
+			return err
+		}
+	}
+	// GitHub Actions expects the job summary to be appended to the file.
+	if stepSummary != "" {
+		return writeFile(stepSummary, os.O_APPEND, buf.Bytes())
+	}
+	return nil
+}
+
+func writeFile(path string, mode int, content []byte) error {
+	fh, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|mode, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %v", err)
+	}
+	if _, err := fh.Write(content); err != nil {
+		fh.Close() // nolint: errcheck
+		return err
+	}
+	return fh.Close()
+}
+
+// otlpExportTimeout is the maximum time to wait for the OTLP receiver.
+const otlpExportTimeout = 10 * time.Second
+
+// writeTrace writes the execution as an OpenTelemetry trace to --otlp-file,
+// and exports it to --otlp-endpoint. A failed export is logged instead of
+// returned, so that an unavailable receiver does not change the exit code.
+func writeTrace(opts *options, execution *testjson.Execution) error {
+	if opts.otlpFile == "" && opts.otlpEndpoint == "" {
+		return nil
+	}
+	cfg := otlp.Config{
+		Version: version,
+		Attributes: []otlp.KeyValue{
+			{Key: "process.command_line", Value: strings.Join(runnerConfig(opts).Command(), " ")},
+		},
+	}
+	trace, err := otlp.NewTrace(execution, cfg)
+	if errors.Is(err, otlp.ErrInvalidTraceParent) {
+		log.Warnf("starting a new trace: %v", err)
+		cfg.NewRoot = true
+		trace, err = otlp.NewTrace(execution, cfg)
+	}
+	if err != nil {
+		return err
+	}
+
+	if opts.otlpFile != "" {
+		_ = os.MkdirAll(filepath.Dir(opts.otlpFile), 0o755)
+		buf := new(bytes.Buffer)
+		if err := otlp.WriteFile(buf, trace); err != nil {
+			return err
+		}
+		if err := writeFile(opts.otlpFile, os.O_TRUNC, buf.Bytes()); err != nil {
+			return err
+		}
+	}
+	if opts.otlpEndpoint != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), otlpExportTimeout)
+		defer cancel()
+		err := otlp.Export(ctx, trace, otlp.ExportConfig{
+			Endpoint: opts.otlpEndpoint,
+			Protocol: opts.otlpProtocol,
+		})
+		if err != nil {
+			log.Warnf("failed to export trace to %v: %v", opts.otlpEndpoint, err)
+		}
+	}
+	return nil
+}
+
+// metricsPushTimeout is the maximum time to wait for the Pushgateway.
+const metricsPushTimeout = 10 * time.Second
+
+// writeMetrics writes metrics about the execution to --metrics-file, and
+// pushes them to --metrics-push-url. A failed push is logged instead of
+// returned, so that an unavailable Pushgateway does not change the exit code.
+func writeMetrics(opts *options, execution *testjson.Execution) error {
+	if opts.metricsFile == "" && opts.metricsPushURL == "" {
+		return nil
+	}
+	buf := new(bytes.Buffer)
+	if err := metrics.Write(buf, execution, metrics.Config{}); err != nil {
+		return err
+	}
+
+	if opts.metricsFile != "" {
+		_ = os.MkdirAll(filepath.Dir(opts.metricsFile), 0o755)
+		if err := writeFile(opts.metricsFile, os.O_TRUNC, buf.Bytes()); err != nil {
+			return err
+		}
+	}
+	if opts.metricsPushURL != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), metricsPushTimeout)
+		defer cancel()
+		err := metrics.Push(ctx, opts.metricsPushURL, opts.metricsPushJob, buf.Bytes())
+		if err != nil {
+			log.Warnf("failed to push metrics to %v: %v", opts.metricsPushURL, err)
+		}
+	}
+	return nil
+}
+
 func postRunHook(opts *options, execution *testjson.Execution) error {
-	command := opts.postRunHookCmd.Value()
-	if len(command) == 0 {
+	commands := opts.postRunHookCmd.Value()
+	if len(commands) == 0 {
 		return nil
 	}
 
-	cmd := exec.Command(command[0], command[1:]...)
-	cmd.Stdout = opts.stdout
-	cmd.Stderr = opts.stderr
-	cmd.Env = append(
-		os.Environ(),
-		"GOTESTSUM_JSONFILE="+opts.jsonFile,
-		"GOTESTSUM_JSONFILE_TIMING_EVENTS="+opts.jsonFileTimingEvents,
-		"GOTESTSUM_JUNITFILE="+opts.junitFile,
+	payload, err := json.Marshal(newJSONSummary(execution))
+	if err != nil {
+		return fmt.Errorf("failed to create JSON summary: %w", err)
+	}
+	env := append(
+		hookEnv(opts),
 		fmt.Sprintf("TESTS_TOTAL=%d", execution.Total()),
 		fmt.Sprintf("TESTS_FAILED=%d", len(execution.Failed())),
 		fmt.Sprintf("TESTS_SKIPPED=%d", len(execution.Skipped())),
 		fmt.Sprintf("TESTS_ERRORS=%d", len(execution.Errors())),
 	)
-	return cmd.Run()
+
+	// Run every command, even if one fails, so that one broken hook does not
+	// prevent the others from running.
+	var firstErr error
+	for _, command := range commands {
+		cmd := exec.Command(command[0], command[1:]...)
+		cmd.Stdin = bytes.NewReader(payload)
+		cmd.Stdout = opts.stdout
+		cmd.Stderr = opts.stderr
+		cmd.Env = env
+		if err := cmd.Run(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// preRunHook runs each of the pre-run commands, in dir, and returns the error
+// from the first command that fails.
+func preRunHook(opts *options, dir string) error {
+	for _, command := range opts.preRunHookCmd.Value() {
+		cmd := exec.Command(command[0], command[1:]...)
+		cmd.Dir = dir
+		cmd.Stdout = opts.stdout
+		cmd.Stderr = opts.stderr
+		cmd.Env = hookEnv(opts)
+		if err := cmd.Run(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func hookEnv(opts *options) []string {
+	return append(
+		os.Environ(),
+		"GOTESTSUM_JSONFILE="+opts.jsonFile,
+		"GOTESTSUM_JSONFILE_TIMING_EVENTS="+opts.jsonFileTimingEvents,
+		"GOTESTSUM_JUNITFILE="+opts.junitFile,
+	)
+}
+
+// sendNotification sends a notification with the result of the run when
+// --notify is set. In watch mode a notification is only sent when the result
+// changes from pass to fail, or from fail to pass. Errors are logged instead of
+// returned, so that a failed notification does not change the exit code.
+func sendNotification(opts *options, exec *testjson.Execution) {
+	if opts.notify == nil || opts.notify.name == "" {
+		return
+	}
+	msg := notify.NewNotification(exec)
+	if opts.watch && opts.notify.last != nil && *opts.notify.last == msg.Failed {
+		return
+	}
+	opts.notify.last = &msg.Failed
+
+	notifier, err := notify.New(opts.notify.name, opts.stderr)
+	if err != nil {
+		log.Warnf("failed to send notification: %v", err)
+		return
+	}
+	if err := notifier.Notify(msg); err != nil {
+		log.Warnf("failed to send notification: %v", err)
+	}
 }
@@ -2,7 +2,10 @@ package cmd
 
 import (
 	"bytes"
+	"encoding/json"
 	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strings"
@@ -16,7 +19,7 @@ import (
 )
 
 func TestPostRunHook(t *testing.T) {
-	command := &commandValue{}
+	command := &commandsValue{}
 	err := command.Set("go run ./testdata/postrunhook/main.go")
 	assert.NilError(t, err)
 
@@ -37,6 +40,59 @@ func TestPostRunHook(t *testing.T) {
 	golden.Assert(t, buf.String(), "post-run-hook-expected")
 }
 
+func TestPostRunHook_JSONSummaryOnStdin(t *testing.T) {
+	command := &commandsValue{}
+	assert.NilError(t, command.Set("cat"))
+	assert.NilError(t, command.Set("cat"))
+
+	buf := new(bytes.Buffer)
+	opts := &options{postRunHookCmd: command, stdout: buf}
+
+	exec := newExecFromTestData(t)
+	err := postRunHook(opts, exec)
+	assert.NilError(t, err)
+
+	// each command receives the summary on stdin
+	dec := json.NewDecoder(buf)
+	for i := 0; i < 2; i++ {
+		var summary jsonSummary
+		assert.NilError(t, dec.Decode(&summary))
+		assert.Equal(t, summary.Status, statusFail)
+		assert.Equal(t, summary.Total, 59)
+		assert.Equal(t, summary.Runs, 1)
+		assert.Equal(t, len(summary.Packages), 5)
+		assert.Equal(t, len(summary.Failures), 13)
+		assert.Equal(t, len(summary.Skips), 5)
+		assert.Equal(t, summary.Skips[1].Output,
+			"=== RUN   TestSkippedWitLog\n    good_test.go:27: the skip message\n--- SKIP: TestSkippedWitLog (0.00s)\n")
+	}
+}
+
+func TestPostRunHook_RunsAllCommands(t *testing.T) {
+	command := &commandsValue{}
+	assert.NilError(t, command.Set("false"))
+	assert.NilError(t, command.Set("echo second"))
+
+	buf := new(bytes.Buffer)
+	opts := &options{postRunHookCmd: command, stdout: buf}
+	err := postRunHook(opts, newExecFromTestData(t))
+	assert.Error(t, err, "exit status 1")
+	assert.Equal(t, buf.String(), "second\n")
+}
+
+func TestPreRunHook(t *testing.T) {
+	command := &commandsValue{}
+	assert.NilError(t, command.Set("echo first"))
+	assert.NilError(t, command.Set("false"))
+	assert.NilError(t, command.Set("echo third"))
+
+	buf := new(bytes.Buffer)
+	opts := &options{preRunHookCmd: command, stdout: buf}
+	err := preRunHook(opts, "")
+	assert.Error(t, err, "exit status 1")
+	assert.Equal(t, buf.String(), "first\n")
+}
+
 func newExecFromTestData(t *testing.T) *testjson.Execution {
 	t.Helper()
 	f, err := os.Open("../testjson/testdata/input/go-test-json.out")
@@ -61,34 +117,15 @@ func (bufferCloser) Sync() error { return nil }
 
 func TestEventHandler_Event_WithMissingActionFail(t *testing.T) {
 	buf := new(bufferCloser)
-	errBuf := new(bytes.Buffer)
-	format := testjson.NewEventFormatter(errBuf, "testname", testjson.FormatOptions{})
-
 	source := golden.Get(t, "../../testjson/testdata/input/go-test-json-missing-test-fail.out")
 	cfg := testjson.ScanConfig{
 		Stdout:  bytes.NewReader(source),
-		Handler: &eventHandler{jsonFile: buf, formatter: format},
+		Handler: &eventHandler{jsonFile: buf},
 	}
 	_, err := testjson.ScanTestOutput(cfg)
 	assert.NilError(t, err)
 
 	assert.Equal(t, buf.String(), string(source))
-	// confirm the artificial event was sent to the handler by checking the output
-	// of the formatter.
-	golden.Assert(t, errBuf.String(), "event-handler-missing-test-fail-expected")
-}
-
-func TestEventHandler_Event_MaxFails(t *testing.T) {
-	format := testjson.NewEventFormatter(ioutil.Discard, "testname", testjson.FormatOptions{})
-
-	source := golden.Get(t, "../../testjson/testdata/input/go-test-json.out")
-	cfg := testjson.ScanConfig{
-		Stdout:  bytes.NewReader(source),
-		Handler: &eventHandler{formatter: format, maxFails: 2},
-	}
-
-	_, err := testjson.ScanTestOutput(cfg)
-	assert.Error(t, err, "ending test run because max failures was reached")
 }
 
 func TestNewEventHandler_CreatesDirectory(t *testing.T) {
@@ -123,3 +160,128 @@ func TestWriteJunitFile_CreatesDirectory(t *testing.T) {
 	_, err = os.Stat(junitFile)
 	assert.NilError(t, err)
 }
+
+func TestWriteMarkdownSummary(t *testing.T) {
+	dir := fs.NewDir(t, t.Name(), fs.WithFile("step-summary.md", "previous step\n"))
+	env.Patch(t, "GITHUB_STEP_SUMMARY", dir.Join("step-summary.md"))
+
+	opts := &options{markdownFile: dir.Join("new-path", "summary.md")}
+	exec := newExecFromTestData(t)
+	assert.NilError(t, writeMarkdownSummary(opts, exec))
+
+	summary, err := ioutil.ReadFile(opts.markdownFile)
+	assert.NilError(t, err)
+	assert.Assert(t, strings.HasPrefix(string(summary), "## ❌ Tests failed\n"))
+
+	// the step summary is appended to the existing file
+	stepSummary, err := ioutil.ReadFile(dir.Join("step-summary.md"))
+	assert.NilError(t, err)
+	assert.Equal(t, string(stepSummary), "previous step\n"+string(summary))
+}
+
+func TestWriteTrace_ToFile(t *testing.T) {
+	dir := fs.NewDir(t, t.Name())
+	opts := &options{otlpFile: dir.Join("new-path", "trace.json")}
+	assert.NilError(t, writeTrace(opts, newExecFromTestData(t)))
+
+	raw, err := ioutil.ReadFile(opts.otlpFile)
+	assert.NilError(t, err)
+	var req struct {
+		ResourceSpans []struct {
+			ScopeSpans []struct {
+				Spans []struct {
+					Name string `json:"name"`
+				} `json:"spans"`
+			} `json:"scopeSpans"`
+		} `json:"resourceSpans"`
+	}
+	assert.NilError(t, json.Unmarshal(raw, &req))
+	spans := req.ResourceSpans[0].ScopeSpans[0].Spans
+	// root span, 5 packages, and 59 tests
+	assert.Equal(t, len(spans), 1+5+59)
+	assert.Equal(t, spans[0].Name, "gotestsum")
+}
+
+func TestWriteTrace_InvalidTraceParentDoesNotFail(t *testing.T) {
+	env.Patch(t, "TRACEPARENT", "bogus")
+	dir := fs.NewDir(t, t.Name())
+	opts := &options{otlpFile: dir.Join("trace.json")}
+	assert.NilError(t, writeTrace(opts, newExecFromTestData(t)))
+
+	raw, err := ioutil.ReadFile(opts.otlpFile)
+	assert.NilError(t, err)
+	assert.Assert(t, len(raw) > 0)
+}
+
+func TestWriteTrace_ExportErrorDoesNotFail(t *testing.T) {
+	opts := &options{otlpEndpoint: "http://127.0.0.1:1/v1/traces"}
+	assert.NilError(t, writeTrace(opts, newExecFromTestData(t)))
+}
+
+func TestSendNotification_WatchModeOnlyOnStatusChange(t *testing.T) {
+	buf := new(bytes.Buffer)
+	opts := &options{
+		notify: &notifyValue{},
+		stderr: buf,
+		watch:  true,
+	}
+	assert.NilError(t, opts.notify.Set("terminal"))
+
+	failed := newExecFromTestData(t)
+	passed, err := testjson.ScanTestOutput(testjson.ScanConfig{
+		Stdout: strings.NewReader(`{"Action":"pass","Package":"example.com/pkg"}` + "\n"),
+	})
+	assert.NilError(t, err)
+
+	sendNotification(opts, failed)
+	assert.Assert(t, strings.Contains(buf.String(), "Failed"), buf.String())
+
+	buf.Reset()
+	sendNotification(opts, failed)
+	assert.Equal(t, buf.String(), "", "no notification when status is unchanged")
+
+	sendNotification(opts, passed)
+	assert.Assert(t, strings.Contains(buf.String(), "Passed"), buf.String())
+}
+
+func TestWriteMetrics(t *testing.T) {
+	var pushed string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw, _ := ioutil.ReadAll(r.Body)
+		pushed = r.URL.Path + "\n" + string(raw)
+	}))
+	defer srv.Close()
+
+	dir := fs.NewDir(t, t.Name())
+	opts := &options{
+		metricsFile:    dir.Join("new-path", "metrics.prom"),
+		metricsPushURL: srv.URL,
+		metricsPushJob: "gotestsum",
+	}
+	assert.NilError(t, writeMetrics(opts, newExecFromTestData(t)))
+
+	raw, err := ioutil.ReadFile(opts.metricsFile)
+	assert.NilError(t, err)
+	assert.Assert(t, strings.Contains(string(raw),
+		`gotestsum_tests{package="gotest.tools/gotestsum/testjson/internal/good",result="pass"} 16`), string(raw))
+	assert.Equal(t, pushed, "/metrics/job/gotestsum\n"+string(raw))
+}
+
+func TestReproduceCommand(t *testing.T) {
+	raw, err := ioutil.ReadFile("../testjson/testdata/input/go-test-json-with-shuffle.out")
+	assert.NilError(t, err)
+	exec, err := testjson.ScanTestOutput(testjson.ScanConfig{Stdout: bytes.NewReader(raw)})
+	assert.NilError(t, err)
+
+	opts := &options{args: []string{"-shuffle=on", "-tags=integration"}}
+	assert.Assert(t, reproduceCommand(opts, exec) == nil)
+
+	opts.reproduceCommands = true
+	tc := testjson.TestCase{
+		Package: "gotest.tools/gotestsum/testjson/internal/parallelfails",
+		Test:    "TestNestedParallelFailures/a",
+	}
+	expected := "go test '-run=^TestNestedParallelFailures$/^a$' -count=1 -shuffle=123456 " +
+		"-tags=integration gotest.tools/gotestsum/testjson/internal/parallelfails"
+	assert.Equal(t, reproduceCommand(opts, exec)(tc), expected)
+}
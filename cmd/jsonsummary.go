@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"strings"
+
+	"gotest.tools/gotestsum/testjson"
+)
+
+// jsonSummary is the JSON representation of the result of a test run. It is
+// sent to post-run commands on stdin, and returned by the control server.
+type jsonSummary struct {
+	Status  string  `json:"status"`
+	Total   int     `json:"total"`
+	Failed  int     `json:"failed"`
+	Skipped int     `json:"skipped"`
+	Elapsed float64 `json:"elapsed"`
+	// Runs is the number of times 'go test' was run. It is greater than 1 when
+	// failed tests were run again with --rerun-fails.
+	Runs     int            `json:"runs"`
+	Packages []jsonPackage  `json:"packages"`
+	Failures []jsonTestCase `json:"failures,omitempty"`
+	Skips    []jsonTestCase `json:"skips,omitempty"`
+	Errors   []string       `json:"errors,omitempty"`
+	// Clusters are the groups of failures with the same signature.
+	Clusters []jsonFailureCluster `json:"clusters,omitempty"`
+}
+
+// jsonPackage is the JSON representation of a testjson.Package.
+type jsonPackage struct {
+	Name    string  `json:"name"`
+	Result  string  `json:"result"`
+	Elapsed float64 `json:"elapsed"`
+	Total   int     `json:"total"`
+	Passed  int     `json:"passed"`
+	Failed  int     `json:"failed"`
+	Skipped int     `json:"skipped"`
+}
+
+// jsonTestCase is the JSON representation of a testjson.TestCase.
+type jsonTestCase struct {
+	Package string  `json:"package"`
+	Test    string  `json:"test,omitempty"`
+	Elapsed float64 `json:"elapsed"`
+	RunID   int     `json:"runID,omitempty"`
+	Output  string  `json:"output,omitempty"`
+}
+
+// jsonFailureCluster is the JSON representation of a testjson.FailureCluster.
+// The Tests do not include the output.
+type jsonFailureCluster struct {
+	Signature string         `json:"signature"`
+	Count     int            `json:"count"`
+	Tests     []jsonTestCase `json:"tests"`
+}
+
+const (
+	statusPass = "pass"
+	statusFail = "fail"
+	// statusNone is the status of the summary from the --watch-listen control
+	// server before the first run.
+	statusNone = "none"
+)
+
+func newJSONSummary(exec *testjson.Execution) jsonSummary {
+	failed := exec.Failed()
+	skipped := exec.Skipped()
+	summary := jsonSummary{
+		Status:   statusPass,
+		Total:    exec.Total(),
+		Failed:   len(failed),
+		Skipped:  len(skipped),
+		Elapsed:  exec.Elapsed().Seconds(),
+		Runs:     1,
+		Packages: []jsonPackage{},
+		Errors:   exec.Errors(),
+	}
+	if len(failed) > 0 || len(summary.Errors) > 0 {
+		summary.Status = statusFail
+	}
+	for _, name := range exec.Packages() {
+		pkg := exec.Package(name)
+		summary.Packages = append(summary.Packages, newJSONPackage(name, pkg))
+		for _, tc := range pkg.TestCases() {
+			if tc.RunID+1 > summary.Runs {
+				summary.Runs = tc.RunID + 1
+			}
+		}
+	}
+	for _, tc := range failed {
+		summary.Failures = append(summary.Failures, newJSONTestCase(exec, tc))
+	}
+	for _, tc := range skipped {
+		summary.Skips = append(summary.Skips, newJSONTestCase(exec, tc))
+	}
+	for _, cluster := range exec.FailureClusters() {
+		summary.Clusters = append(summary.Clusters, newJSONFailureCluster(cluster))
+	}
+	return summary
+}
+
+func newJSONFailureCluster(cluster testjson.FailureCluster) jsonFailureCluster {
+	result := jsonFailureCluster{Signature: cluster.Signature, Count: cluster.Count}
+	for _, tc := range cluster.Tests {
+		result.Tests = append(result.Tests, jsonTestCase{
+			Package: tc.Package,
+			Test:    tc.Test.Name(),
+			Elapsed: tc.Elapsed.Seconds(),
+			RunID:   tc.RunID,
+		})
+	}
+	return result
+}
+
+func newJSONPackage(name string, pkg *testjson.Package) jsonPackage {
+	return jsonPackage{
+		Name:    name,
+		Result:  string(pkg.Result()),
+		Elapsed: pkg.Elapsed().Seconds(),
+		Total:   pkg.Total,
+		Passed:  len(pkg.Passed),
+		Failed:  len(pkg.Failed),
+		Skipped: len(pkg.Skipped),
+	}
+}
+
+func newJSONTestCase(exec *testjson.Execution, tc testjson.TestCase) jsonTestCase {
+	return jsonTestCase{
+		Package: tc.Package,
+		Test:    tc.Test.Name(),
+		Elapsed: tc.Elapsed.Seconds(),
+		RunID:   tc.RunID,
+		Output:  strings.Join(exec.OutputLines(tc), ""),
+	}
+}
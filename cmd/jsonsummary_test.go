@@ -0,0 +1,29 @@
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	"gotest.tools/gotestsum/testjson"
+	"gotest.tools/v3/assert"
+)
+
+func TestNewJSONSummary_WithFailureClusters(t *testing.T) {
+	raw, err := ioutil.ReadFile("../testjson/testdata/input/go-test-json-clusters.out")
+	assert.NilError(t, err)
+	exec, err := testjson.ScanTestOutput(testjson.ScanConfig{Stdout: bytes.NewReader(raw)})
+	assert.NilError(t, err)
+
+	summary := newJSONSummary(exec)
+	assert.Equal(t, len(summary.Clusters), 2)
+	expected := jsonFailureCluster{
+		Signature: "fixture <tmp> is missing",
+		Count:     2,
+		Tests: []jsonTestCase{
+			{Package: "example.com/clu", Test: "TestFixtureUsers"},
+			{Package: "example.com/clu", Test: "TestFixtureOrders"},
+		},
+	}
+	assert.DeepEqual(t, summary.Clusters[1], expected)
+}
@@ -6,15 +6,14 @@ import (
 	"fmt"
 	"io"
 	"os"
-	"os/exec"
-	"os/signal"
-	"strings"
-	"sync/atomic"
-	"syscall"
+	"time"
 
 	"github.com/dnephin/pflag"
 	"github.com/fatih/color"
 	"gotest.tools/gotestsum/internal/log"
+	"gotest.tools/gotestsum/internal/notify"
+	"gotest.tools/gotestsum/internal/otlp"
+	"gotest.tools/gotestsum/runner"
 	"gotest.tools/gotestsum/testjson"
 )
 
@@ -30,6 +29,9 @@ func Run(name string, args []string) error {
 		return err
 	}
 	opts.args = flags.Args()
+	if err := loadConfigFile(flags, opts, "."); err != nil {
+		return err
+	}
 	setupLogging(opts)
 
 	switch {
@@ -47,18 +49,23 @@ func setupFlags(name string) (*pflag.FlagSet, *options) {
 		hideSummary:                  newHideSummaryValue(),
 		junitTestCaseClassnameFormat: &junitFieldFormatValue{},
 		junitTestSuiteNameFormat:     &junitFieldFormatValue{},
-		postRunHookCmd:               &commandValue{},
+		postRunHookCmd:               &commandsValue{},
+		preRunHookCmd:                &commandsValue{},
+		notify:                       &notifyValue{},
 		stdout:                       color.Output,
 		stderr:                       color.Error,
 	}
 	flags := pflag.NewFlagSet(name, pflag.ContinueOnError)
 	flags.SetInterspersed(false)
+	env := flagEnv{}
 	flags.Usage = func() {
 		usage(os.Stdout, name, flags)
 	}
 	flags.StringVarP(&opts.format, "format", "f",
-		lookEnvWithDefault("GOTESTSUM_FORMAT", "short"),
+		env.lookup("format", "GOTESTSUM_FORMAT", "short"),
 		"print format of test input")
+	flags.Var(&opts.formatFiles, "format-file",
+		"also write a format to a file, may be repeated, append ,color to use color in the file")
 	flags.BoolVar(&opts.formatOptions.HideEmptyPackages, "format-hide-empty-pkg",
 		false, "do not print empty packages in compact formats")
 	flags.BoolVar(&opts.formatOptions.UseHiVisibilityIcons, "format-hivis",
@@ -69,10 +76,10 @@ func setupFlags(name string) (*pflag.FlagSet, *options) {
 		"write non-JSON 'go test' output lines to stderr instead of failing")
 	flags.Lookup("ignore-non-json-output-lines").Hidden = true
 	flags.StringVar(&opts.jsonFile, "jsonfile",
-		lookEnvWithDefault("GOTESTSUM_JSONFILE", ""),
+		env.lookup("jsonfile", "GOTESTSUM_JSONFILE", ""),
 		"write all TestEvents to file")
 	flags.StringVar(&opts.jsonFileTimingEvents, "jsonfile-timing-events",
-		lookEnvWithDefault("GOTESTSUM_JSONFILE_TIMING_EVENTS", ""),
+		env.lookup("jsonfile-timing-events", "GOTESTSUM_JSONFILE_TIMING_EVENTS", ""),
 		"write only the pass, skip, and fail TestEvents to the file")
 	flags.BoolVar(&opts.noColor, "no-color", defaultNoColor, "disable color output")
 
@@ -81,29 +88,83 @@ func setupFlags(name string) (*pflag.FlagSet, *options) {
 	flags.Lookup("no-summary").Hidden = true
 	flags.Var(opts.hideSummary, "hide-summary",
 		"hide sections of the summary: "+testjson.SummarizeAll.String())
+	flags.BoolVar(&opts.summaryClusters, "summary-clusters", false,
+		"print groups of failed tests with the same output in the summary and the markdown file")
+	flags.StringVar(&opts.summaryTemplate, "summary-template", "",
+		"print the summary using this Go text/template, or @file with the template")
+	flags.Var(&opts.summaryAttrs, "summary-attr",
+		"only include tests with this attribute, from t.Attr, in the summary, may be repeated")
+	flags.StringVar(&opts.summaryGroupByAttr, "summary-group-by-attr", "",
+		"group the tests in the summary by the value of this attribute, from t.Attr")
+	flags.BoolVar(&opts.reproduceCommands, "reproduce-commands", false,
+		"print the command to run each failed test again in the summary and the JUnit XML")
 	flags.Var(opts.postRunHookCmd, "post-run-command",
-		"command to run after the tests have completed")
+		"command to run after the tests have completed, may be repeated")
+	flags.Var(opts.preRunHookCmd, "pre-run-command",
+		"command to run before the tests, a non-zero exit aborts the run, may be repeated")
+	flags.Var(opts.notify, "notify",
+		"send a desktop notification when the tests complete, using one of: "+notify.Names)
 	flags.BoolVar(&opts.watch, "watch", false,
 		"watch go files, and run tests when a file is modified")
 	flags.BoolVar(&opts.watchChdir, "watch-chdir", false,
 		"in watch mode change the working directory to the directory with the modified file before running tests")
+	flags.StringVar(&opts.watchListen, "watch-listen", "",
+		"in watch mode listen for commands on this loopback address, or unix:PATH socket")
 	flags.IntVar(&opts.maxFails, "max-fails", 0,
 		"end the test run after this number of failures")
 
 	flags.StringVar(&opts.junitFile, "junitfile",
-		lookEnvWithDefault("GOTESTSUM_JUNITFILE", ""),
+		env.lookup("junitfile", "GOTESTSUM_JUNITFILE", ""),
 		"write a JUnit XML file")
 	flags.Var(opts.junitTestSuiteNameFormat, "junitfile-testsuite-name",
 		"format the testsuite name field as: "+junitFieldFormatValues)
 	flags.Var(opts.junitTestCaseClassnameFormat, "junitfile-testcase-classname",
 		"format the testcase classname field as: "+junitFieldFormatValues)
 	flags.StringVar(&opts.junitProjectName, "junitfile-project-name",
-		lookEnvWithDefault("GOTESTSUM_JUNITFILE_PROJECT_NAME", ""),
+		env.lookup("junitfile-project-name", "GOTESTSUM_JUNITFILE_PROJECT_NAME", ""),
 		"name of the project used in the junit.xml file")
 	flags.BoolVar(&opts.junitHideEmptyPackages, "junitfile-hide-empty-pkg",
-		truthyFlag(lookEnvWithDefault("GOTESTSUM_JUNIT_HIDE_EMPTY_PKG", "")),
+		truthyFlag(env.lookup("junitfile-hide-empty-pkg", "GOTESTSUM_JUNIT_HIDE_EMPTY_PKG", "")),
 		"omit packages with no tests from the junit.xml file")
 
+	flags.StringVar(&opts.markdownFile, "markdownfile",
+		env.lookup("markdownfile", "GOTESTSUM_MARKDOWNFILE", ""),
+		"write a summary in Markdown format to file, also written to $GITHUB_STEP_SUMMARY when set")
+	flags.IntVar(&opts.markdownMaxOutput, "markdownfile-max-output", 4096,
+		"maximum number of bytes of output to include for each test in the Markdown summary")
+
+	flags.StringVar(&opts.metricsFile, "metrics-file",
+		env.lookup("metrics-file", "GOTESTSUM_METRICSFILE", ""),
+		"write metrics about the test run to file in the Prometheus text format")
+	flags.StringVar(&opts.metricsPushURL, "metrics-push-url",
+		env.lookup("metrics-push-url", "GOTESTSUM_METRICS_PUSH_URL", ""),
+		"push metrics about the test run to this Prometheus Pushgateway")
+	flags.StringVar(&opts.metricsPushJob, "metrics-push-job",
+		env.lookup("metrics-push-job", "GOTESTSUM_METRICS_PUSH_JOB", "gotestsum"),
+		"job name used to push metrics to the Pushgateway")
+
+	flags.StringVar(&opts.webhookURL, "webhook-url",
+		env.lookup("webhook-url", "GOTESTSUM_WEBHOOK_URL", ""),
+		"POST a summary of the test run to this URL")
+	flags.StringVar(&opts.webhookFormat, "webhook-format",
+		env.lookup("webhook-format", "GOTESTSUM_WEBHOOK_FORMAT", "json"),
+		"format of the webhook payload, one of: "+webhookFormats)
+	flags.StringVar(&opts.webhookTemplate, "webhook-template",
+		env.lookup("webhook-template", "GOTESTSUM_WEBHOOK_TEMPLATE", ""),
+		"text/template for the message of a slack, mattermost, or teams webhook, or @file with the template")
+	flags.DurationVar(&opts.webhookTimeout, "webhook-timeout", 30*time.Second,
+		"maximum time to spend sending the webhook, including retries")
+
+	flags.StringVar(&opts.otlpEndpoint, "otlp-endpoint",
+		env.lookup("otlp-endpoint", "GOTESTSUM_OTLP_ENDPOINT", ""),
+		"export the test run as an OpenTelemetry trace to this OTLP/HTTP endpoint")
+	flags.StringVar(&opts.otlpProtocol, "otlp-protocol",
+		env.lookup("otlp-protocol", "GOTESTSUM_OTLP_PROTOCOL", otlp.ProtocolProtobuf),
+		"protocol used to export to --otlp-endpoint, one of: "+otlp.ProtocolProtobuf+", "+otlp.ProtocolJSON)
+	flags.StringVar(&opts.otlpFile, "otlp-file",
+		env.lookup("otlp-file", "GOTESTSUM_OTLP_FILE", ""),
+		"write the test run as an OpenTelemetry trace in OTLP JSON format to file")
+
 	flags.IntVar(&opts.rerunFailsMaxAttempts, "rerun-fails", 0,
 		"rerun failed tests until they all pass, or attempts exceeds maximum. Defaults to max 2 reruns when enabled")
 	flags.Lookup("rerun-fails").NoOptDefVal = "2"
@@ -116,8 +177,12 @@ func setupFlags(name string) (*pflag.FlagSet, *options) {
 	flags.BoolVar(&opts.rerunFailsRunRootCases, "rerun-fails-run-root-test", false,
 		"rerun the entire root testcase when any of its subtests fail, instead of only the failed subtest")
 
+	flags.StringVar(&opts.profile, "profile",
+		env.lookup("profile", "GOTESTSUM_PROFILE", ""),
+		"use the values from this profile in the .gotestsum.yaml or .gotestsum.toml file")
 	flags.BoolVar(&opts.debug, "debug", false, "enabled debug logging")
 	flags.BoolVar(&opts.version, "version", false, "show version and exit")
+	env.annotate(flags)
 	return flags, opts
 }
 
@@ -141,10 +206,18 @@ Formats:
     testname                 print a line for each test and package
     standard-quiet           standard go test format
     standard-verbose         standard go test -v format
+    quickfix                 print the file and line of each failure, for editors
+    github-actions           print a line for each package, and annotations for failures
+    template=TEMPLATE        print each event using a Go text/template, or @file with the template
+    exec:COMMAND             send each event as JSON to COMMAND, which prints the output
 
 Commands:
-    %[1]s tool slowest   find or skip the slowest tests
-    %[1]s help           print this help next
+    %[1]s tool slowest        find or skip the slowest tests
+    %[1]s tool ci-matrix      use previous test runtime to place packages into optimal buckets
+    %[1]s tool timeline       write a timeline of a test run, and report on test parallelism
+    %[1]s tool stress         run a test repeatedly to reproduce a flaky failure
+    %[1]s tool bisect-order   find the tests that make another test fail when they run first
+    %[1]s help                print this help next
 `, name)
 }
 
@@ -159,19 +232,39 @@ type options struct {
 	args                         []string
 	format                       string
 	formatOptions                testjson.FormatOptions
+	formatFiles                  formatFileValue
 	debug                        bool
 	rawCommand                   bool
 	ignoreNonJSONOutputLines     bool
 	jsonFile                     string
 	jsonFileTimingEvents         string
 	junitFile                    string
-	postRunHookCmd               *commandValue
+	postRunHookCmd               *commandsValue
+	preRunHookCmd                *commandsValue
+	notify                       *notifyValue
 	noColor                      bool
 	hideSummary                  *hideSummaryValue
+	summaryClusters              bool
+	summaryTemplate              string
+	summaryAttrs                 attrFilterValue
+	summaryGroupByAttr           string
+	reproduceCommands            bool
 	junitTestSuiteNameFormat     *junitFieldFormatValue
 	junitTestCaseClassnameFormat *junitFieldFormatValue
 	junitProjectName             string
 	junitHideEmptyPackages       bool
+	markdownFile                 string
+	markdownMaxOutput            int
+	metricsFile                  string
+	metricsPushURL               string
+	metricsPushJob               string
+	webhookURL                   string
+	webhookFormat                string
+	webhookTemplate              string
+	webhookTimeout               time.Duration
+	otlpEndpoint                 string
+	otlpProtocol                 string
+	otlpFile                     string
 	rerunFailsMaxAttempts        int
 	rerunFailsMaxInitialFailures int
 	rerunFailsReportFile         string
@@ -179,7 +272,9 @@ type options struct {
 	packages                     []string
 	watch                        bool
 	watchChdir                   bool
+	watchListen                  string
 	maxFails                     int
+	profile                      string
 	version                      bool
 
 	// shims for testing
@@ -188,14 +283,43 @@ type options struct {
 }
 
 func (o options) Validate() error {
-	if o.rerunFailsMaxAttempts > 0 && len(o.args) > 0 && !o.rawCommand && len(o.packages) == 0 {
+	switch err := runnerConfig(&o).Validate(); {
+	case errors.Is(err, runner.ErrRerunFailsWithoutPackages):
 		return fmt.Errorf(
 			"when go test args are used with --rerun-fails " +
 				"the list of packages to test must be specified by the --packages flag")
-	}
-	if o.rerunFailsMaxAttempts > 0 && boolArgIndex("failfast", o.args) > -1 {
+	case errors.Is(err, runner.ErrRerunFailsWithFailfast):
 		return fmt.Errorf("-failfast can not be used with --rerun-fails " +
 			"because not all test cases will run")
+	case err != nil:
+		return err
+	}
+	if o.watchListen != "" && !o.watch {
+		return fmt.Errorf("--watch-listen can only be used with --watch")
+	}
+	if o.summaryTemplate != "" {
+		if _, err := testjson.ParseTemplate(o.summaryTemplate); err != nil {
+			return fmt.Errorf("invalid --summary-template: %w", err)
+		}
+	}
+	if !validWebhookFormat(o.webhookFormat) {
+		return fmt.Errorf("invalid --webhook-format %v, must be one of: %v",
+			o.webhookFormat, webhookFormats)
+	}
+	if o.webhookTemplate != "" {
+		if o.webhookFormat == "" || o.webhookFormat == "json" {
+			return fmt.Errorf("--webhook-template can only be used with " +
+				"--webhook-format slack, mattermost, or teams")
+		}
+		if _, err := testjson.ParseTemplate(o.webhookTemplate); err != nil {
+			return fmt.Errorf("invalid --webhook-template: %w", err)
+		}
+	}
+	switch o.otlpProtocol {
+	case "", otlp.ProtocolProtobuf, otlp.ProtocolJSON:
+	default:
+		return fmt.Errorf("invalid --otlp-protocol %v, must be one of: %v, %v",
+			o.otlpProtocol, otlp.ProtocolProtobuf, otlp.ProtocolJSON)
 	}
 	return nil
 }
@@ -245,275 +369,84 @@ func run(opts *options) error {
 	if err := opts.Validate(); err != nil {
 		return err
 	}
+	if err := preRunHook(opts, ""); err != nil {
+		return fmt.Errorf("pre run command failed: %w", err)
+	}
 
-	goTestProc, err := startGoTestFn(ctx, "", goTestCmdArgs(opts, rerunOpts{}))
+	formatter, err := newFormatter(opts)
 	if err != nil {
 		return err
 	}
-
 	handler, err := newEventHandler(opts)
 	if err != nil {
 		return err
 	}
 	defer handler.Close() // nolint: errcheck
-	cfg := testjson.ScanConfig{
-		Stdout:                   goTestProc.stdout,
-		Stderr:                   goTestProc.stderr,
-		Handler:                  handler,
-		Stop:                     cancel,
-		IgnoreNonJSONOutputLines: opts.ignoreNonJSONOutputLines,
-	}
-	exec, err := testjson.ScanTestOutput(cfg)
-	handler.Flush()
-	if err != nil {
-		return finishRun(opts, exec, err)
-	}
-
-	exitErr := goTestProc.cmd.Wait()
-	if signum := atomic.LoadInt32(&goTestProc.signal); signum != 0 {
-		return finishRun(opts, exec, exitError{num: signalExitCode + int(signum)})
-	}
-	if exitErr == nil || opts.rerunFailsMaxAttempts == 0 {
-		return finishRun(opts, exec, exitErr)
-	}
-	if err := hasErrors(exitErr, exec); err != nil {
-		return finishRun(opts, exec, err)
-	}
 
-	failed := len(rerunFailsFilter(opts)(exec.Failed()))
-	if failed > opts.rerunFailsMaxInitialFailures {
-		err := fmt.Errorf(
-			"number of test failures (%d) exceeds maximum (%d) set by --rerun-fails-max-failures",
-			failed, opts.rerunFailsMaxInitialFailures)
-		return finishRun(opts, exec, err)
-	}
-
-	cfg = testjson.ScanConfig{Execution: exec, Handler: handler}
-	exitErr = rerunFailed(ctx, opts, cfg)
+	cfg := runnerConfig(opts)
+	cfg.Formatter = formatter
+	cfg.Handlers = []testjson.EventHandler{handler}
+	exec, exitErr := runner.Run(ctx, cfg)
 	handler.Flush()
+	finishFormatter(formatter, exec, exitErr)
+	if exec == nil {
+		return exitErr
+	}
 	if err := writeRerunFailsReport(opts, exec); err != nil {
 		return err
 	}
 	return finishRun(opts, exec, exitErr)
 }
 
-func finishRun(opts *options, exec *testjson.Execution, exitErr error) error {
-	testjson.PrintSummary(opts.stdout, exec, opts.hideSummary.value)
-
-	if err := writeJUnitFile(opts, exec); err != nil {
-		return fmt.Errorf("failed to write junit file: %w", err)
-	}
-	if err := postRunHook(opts, exec); err != nil {
-		return fmt.Errorf("post run command failed: %w", err)
-	}
-	return exitErr
-}
-
-func goTestCmdArgs(opts *options, rerunOpts rerunOpts) []string {
-	if opts.rawCommand {
-		var result []string
-		result = append(result, opts.args...)
-		result = append(result, rerunOpts.Args()...)
-		return result
-	}
-
-	args := opts.args
-	result := []string{"go", "test"}
-
-	if len(args) == 0 {
-		result = append(result, "-json")
-		if rerunOpts.runFlag != "" {
-			result = append(result, rerunOpts.runFlag)
-		}
-		return append(result, cmdArgPackageList(opts, rerunOpts, "./...")...)
-	}
-
-	if boolArgIndex("json", args) < 0 {
-		result = append(result, "-json")
-	}
-
-	if rerunOpts.runFlag != "" {
-		// Remove any existing run arg, it needs to be replaced with our new one
-		// and duplicate args are not allowed by 'go test'.
-		runIndex, runIndexEnd := argIndex("run", args)
-		if runIndex >= 0 && runIndexEnd < len(args) {
-			args = append(args[:runIndex], args[runIndexEnd+1:]...)
-		}
-		result = append(result, rerunOpts.runFlag)
-	}
-
-	pkgArgIndex := findPkgArgPosition(args)
-	result = append(result, args[:pkgArgIndex]...)
-	result = append(result, cmdArgPackageList(opts, rerunOpts)...)
-	result = append(result, args[pkgArgIndex:]...)
-	return result
-}
-
-func cmdArgPackageList(opts *options, rerunOpts rerunOpts, defPkgList ...string) []string {
-	switch {
-	case rerunOpts.pkg != "":
-		return []string{rerunOpts.pkg}
-	case len(opts.packages) > 0:
-		return opts.packages
-	case os.Getenv("TEST_DIRECTORY") != "":
-		return []string{os.Getenv("TEST_DIRECTORY")}
-	default:
-		return defPkgList
-	}
-}
-
-func boolArgIndex(flag string, args []string) int {
-	for i, arg := range args {
-		if arg == "-"+flag || arg == "--"+flag {
-			return i
-		}
+// runnerConfig returns the runner.Config used to run the tests with opts.
+func runnerConfig(opts *options) runner.Config {
+	return runner.Config{
+		Args:       opts.args,
+		RawCommand: opts.rawCommand,
+		Packages:   opts.packages,
+		Stdout:     opts.stdout,
+		Stderr:     opts.stderr,
+		MaxFails:   opts.maxFails,
+		RerunFails: runner.RerunFailsConfig{
+			MaxAttempts:        opts.rerunFailsMaxAttempts,
+			MaxInitialFailures: opts.rerunFailsMaxInitialFailures,
+			RunRootCases:       opts.rerunFailsRunRootCases,
+		},
+		IgnoreNonJSONOutputLines: opts.ignoreNonJSONOutputLines,
 	}
-	return -1
 }
 
-func argIndex(flag string, args []string) (start, end int) {
-	for i, arg := range args {
-		if arg == "-"+flag || arg == "--"+flag {
-			return i, i + 1
-		}
-		if strings.HasPrefix(arg, "-"+flag+"=") || strings.HasPrefix(arg, "--"+flag+"=") {
-			return i, i
-		}
+func finishRun(opts *options, exec *testjson.Execution, exitErr error) error {
+	if err := printSummary(opts, exec); err != nil {
+		return fmt.Errorf("failed to print summary: %w", err)
 	}
-	return -1, -1
-}
-
-// The package list is before the -args flag, or at the end of the args list
-// if the -args flag is not in args.
-// The -args flag is a 'go test' flag that indicates that all subsequent
-// args should be passed to the test binary. It requires that the list of
-// packages comes before -args, so we re-use it as a placeholder in the case
-// where some args must be passed to the test binary.
-func findPkgArgPosition(args []string) int {
-	if i := boolArgIndex("args", args); i >= 0 {
-		return i
+	if err := writeJUnitFile(opts, exec); err != nil {
+		return fmt.Errorf("failed to write junit file: %w", err)
 	}
-	return len(args)
-}
-
-type proc struct {
-	cmd    waiter
-	stdout io.Reader
-	stderr io.Reader
-	// signal is atomically set to the signal value when a signal is received
-	// by newSignalHandler.
-	signal int32
-}
-
-type waiter interface {
-	Wait() error
-}
-
-func startGoTest(ctx context.Context, dir string, args []string) (*proc, error) {
-	if len(args) == 0 {
-		return nil, errors.New("missing command to run")
+	if err := writeMarkdownSummary(opts, exec); err != nil {
+		return fmt.Errorf("failed to write markdown summary: %w", err)
 	}
-
-	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
-	cmd.Stdin = os.Stdin
-	cmd.Dir = dir
-
-	p := proc{cmd: cmd}
-	log.Debugf("exec: %s", cmd.Args)
-	var err error
-	p.stdout, err = cmd.StdoutPipe()
-	if err != nil {
-		return nil, err
+	if err := writeTrace(opts, exec); err != nil {
+		return fmt.Errorf("failed to write trace: %w", err)
 	}
-	p.stderr, err = cmd.StderrPipe()
-	if err != nil {
-		return nil, err
+	if err := writeMetrics(opts, exec); err != nil {
+		return fmt.Errorf("failed to write metrics: %w", err)
 	}
-	if err := cmd.Start(); err != nil {
-		return nil, fmt.Errorf("failed to run %s: %w", strings.Join(cmd.Args, " "), err)
+	if err := postRunHook(opts, exec); err != nil {
+		return fmt.Errorf("post run command failed: %w", err)
 	}
-	log.Debugf("go test pid: %d", cmd.Process.Pid)
-
-	ctx, cancel := context.WithCancel(ctx)
-	newSignalHandler(ctx, cmd.Process.Pid, &p)
-	p.cmd = &cancelWaiter{cancel: cancel, wrapped: p.cmd}
-	return &p, nil
+	sendNotification(opts, exec)
+	sendWebhook(opts, exec)
+	return exitErr
 }
 
 // ExitCodeWithDefault returns the ExitStatus of a process from the error returned by
 // exec.Run(). If the exit status is not available an error is returned.
 func ExitCodeWithDefault(err error) int {
-	if err == nil {
-		return 0
-	}
-	if exiterr, ok := err.(exitCoder); ok {
-		if code := exiterr.ExitCode(); code != -1 {
-			return code
-		}
-	}
-	return 127
-}
-
-type exitCoder interface {
-	ExitCode() int
+	return runner.ExitCodeWithDefault(err)
 }
 
+// IsExitCoder returns true if err includes the exit code of a process.
 func IsExitCoder(err error) bool {
-	_, ok := err.(exitCoder)
-	return ok
-}
-
-type exitError struct {
-	num int
-}
-
-func (e exitError) Error() string {
-	return fmt.Sprintf("exit code %d", e.num)
-}
-
-func (e exitError) ExitCode() int {
-	return e.num
-}
-
-// signalExitCode is the base value added to a signal number to produce the
-// exit code value. This matches the behaviour of bash.
-const signalExitCode = 128
-
-func newSignalHandler(ctx context.Context, pid int, p *proc) {
-	c := make(chan os.Signal, 1)
-	signal.Notify(c, os.Interrupt)
-
-	go func() {
-		defer signal.Stop(c)
-
-		select {
-		case <-ctx.Done():
-			return
-		case s := <-c:
-			atomic.StoreInt32(&p.signal, int32(s.(syscall.Signal)))
-
-			proc, err := os.FindProcess(pid)
-			if err != nil {
-				log.Errorf("failed to find pid of 'go test': %v", err)
-				return
-			}
-			if err := proc.Signal(s); err != nil {
-				log.Errorf("failed to interrupt 'go test': %v", err)
-				return
-			}
-		}
-	}()
-}
-
-// cancelWaiter wraps a waiter to cancel the context after the wrapped
-// Wait exits.
-type cancelWaiter struct {
-	cancel  func()
-	wrapped waiter
-}
-
-func (w *cancelWaiter) Wait() error {
-	err := w.wrapped.Wait()
-	w.cancel()
-	return err
+	return runner.IsExitCoder(err)
 }
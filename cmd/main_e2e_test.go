@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"bytes"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -122,11 +123,28 @@ func expectedFilename(name string) string {
 	switch {
 	case isPreGo120(ver):
 		return name + "-go1.19"
+	case !isPreGo124(ver) && goldenExists(name+"-go1.24"):
+		return name + "-go1.24"
 	default:
 		return name
 	}
 }
 
+// go1.24.0 added build events to the output of 'go test -json', so a package
+// that fails to build has a fail event.
+func isPreGo124(ver string) bool {
+	var minor int
+	if _, err := fmt.Sscanf(ver, "go1.%d", &minor); err != nil {
+		return false
+	}
+	return minor < 24
+}
+
+func goldenExists(name string) bool {
+	_, err := os.Stat(filepath.Join("testdata/e2e/expected", name))
+	return err == nil
+}
+
 // go1.20.0 changed how it prints messages from subtests. It seems the output
 // has changed back to match the output from go1.14 and earlier.
 func isPreGo120(ver string) bool {
@@ -4,7 +4,6 @@ import (
 	"bytes"
 	"encoding/json"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"runtime"
 	"strings"
@@ -78,6 +77,20 @@ func TestOptions_Validate_FromFlags(t *testing.T) {
 			args:     []string{"--rerun-fails", "--packages=./...", "--", "-failfast"},
 			expected: "-failfast can not be used with --rerun-fails",
 		},
+		{
+			name: "webhook template with slack",
+			args: []string{"--webhook-format=slack", "--webhook-template={{.Title}}"},
+		},
+		{
+			name:     "webhook template with json",
+			args:     []string{"--webhook-format=json", "--webhook-template={{.Title}}"},
+			expected: "--webhook-template can only be used with --webhook-format slack",
+		},
+		{
+			name:     "webhook template does not parse",
+			args:     []string{"--webhook-format=teams", "--webhook-template={{.Title"},
+			expected: "invalid --webhook-template",
+		},
 	}
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
@@ -86,331 +99,6 @@ func TestOptions_Validate_FromFlags(t *testing.T) {
 	}
 }
 
-func TestGoTestCmdArgs(t *testing.T) {
-	type testCase struct {
-		opts      *options
-		rerunOpts rerunOpts
-		env       []string
-		expected  []string
-	}
-
-	run := func(t *testing.T, name string, tc testCase) {
-		t.Helper()
-		runCase(t, name, func(t *testing.T) {
-			defer env.PatchAll(t, env.ToMap(tc.env))()
-			actual := goTestCmdArgs(tc.opts, tc.rerunOpts)
-			assert.DeepEqual(t, actual, tc.expected)
-		})
-	}
-
-	run(t, "raw command", testCase{
-		opts: &options{
-			rawCommand: true,
-			args:       []string{"./script", "-test.timeout=20m"},
-		},
-		expected: []string{"./script", "-test.timeout=20m"},
-	})
-	run(t, "no args", testCase{
-		opts:     &options{},
-		expected: []string{"go", "test", "-json", "./..."},
-	})
-	run(t, "no args, with rerunPackageList arg", testCase{
-		opts: &options{
-			packages: []string{"./pkg"},
-		},
-		expected: []string{"go", "test", "-json", "./pkg"},
-	})
-	run(t, "TEST_DIRECTORY env var no args", testCase{
-		opts:     &options{},
-		env:      []string{"TEST_DIRECTORY=testdir"},
-		expected: []string{"go", "test", "-json", "testdir"},
-	})
-	run(t, "TEST_DIRECTORY env var with args", testCase{
-		opts: &options{
-			args: []string{"-tags=integration"},
-		},
-		env:      []string{"TEST_DIRECTORY=testdir"},
-		expected: []string{"go", "test", "-json", "-tags=integration", "testdir"},
-	})
-	run(t, "no -json arg", testCase{
-		opts: &options{
-			args: []string{"-timeout=2m", "./pkg"},
-		},
-		expected: []string{"go", "test", "-json", "-timeout=2m", "./pkg"},
-	})
-	run(t, "with -json arg", testCase{
-		opts: &options{
-			args: []string{"-json", "-timeout=2m", "./pkg"},
-		},
-		expected: []string{"go", "test", "-json", "-timeout=2m", "./pkg"},
-	})
-	run(t, "raw command, with rerunOpts", testCase{
-		opts: &options{
-			rawCommand: true,
-			args:       []string{"./script", "-test.timeout=20m"},
-		},
-		rerunOpts: rerunOpts{
-			runFlag: "-run=TestOne|TestTwo",
-			pkg:     "./fails",
-		},
-		expected: []string{"./script", "-test.timeout=20m", "-run=TestOne|TestTwo", "./fails"},
-	})
-	run(t, "no args, with rerunOpts", testCase{
-		opts: &options{},
-		rerunOpts: rerunOpts{
-			runFlag: "-run=TestOne|TestTwo",
-			pkg:     "./fails",
-		},
-		expected: []string{"go", "test", "-json", "-run=TestOne|TestTwo", "./fails"},
-	})
-	run(t, "TEST_DIRECTORY env var, no args, with rerunOpts", testCase{
-		opts: &options{},
-		rerunOpts: rerunOpts{
-			runFlag: "-run=TestOne|TestTwo",
-			pkg:     "./fails",
-		},
-		env: []string{"TEST_DIRECTORY=testdir"},
-		// TEST_DIRECTORY should be overridden by rerun opts
-		expected: []string{"go", "test", "-json", "-run=TestOne|TestTwo", "./fails"},
-	})
-	run(t, "TEST_DIRECTORY env var, with args, with rerunOpts", testCase{
-		opts: &options{
-			args: []string{"-tags=integration"},
-		},
-		rerunOpts: rerunOpts{
-			runFlag: "-run=TestOne|TestTwo",
-			pkg:     "./fails",
-		},
-		env:      []string{"TEST_DIRECTORY=testdir"},
-		expected: []string{"go", "test", "-json", "-run=TestOne|TestTwo", "-tags=integration", "./fails"},
-	})
-	run(t, "no -json arg, with rerunOpts", testCase{
-		opts: &options{
-			args:     []string{"-timeout=2m"},
-			packages: []string{"./pkg"},
-		},
-		rerunOpts: rerunOpts{
-			runFlag: "-run=TestOne|TestTwo",
-			pkg:     "./fails",
-		},
-		expected: []string{"go", "test", "-json", "-run=TestOne|TestTwo", "-timeout=2m", "./fails"},
-	})
-	run(t, "with -json arg, with rerunOpts", testCase{
-		opts: &options{
-			args:     []string{"-json", "-timeout=2m"},
-			packages: []string{"./pkg"},
-		},
-		rerunOpts: rerunOpts{
-			runFlag: "-run=TestOne|TestTwo",
-			pkg:     "./fails",
-		},
-		expected: []string{"go", "test", "-run=TestOne|TestTwo", "-json", "-timeout=2m", "./fails"},
-	})
-	run(t, "with args, with reunFailsPackageList args, with rerunOpts", testCase{
-		opts: &options{
-			args:     []string{"-timeout=2m"},
-			packages: []string{"./pkg1", "./pkg2", "./pkg3"},
-		},
-		rerunOpts: rerunOpts{
-			runFlag: "-run=TestOne|TestTwo",
-			pkg:     "./fails",
-		},
-		expected: []string{"go", "test", "-json", "-run=TestOne|TestTwo", "-timeout=2m", "./fails"},
-	})
-	run(t, "with args, with reunFailsPackageList", testCase{
-		opts: &options{
-			args:     []string{"-timeout=2m"},
-			packages: []string{"./pkg1", "./pkg2", "./pkg3"},
-		},
-		expected: []string{"go", "test", "-json", "-timeout=2m", "./pkg1", "./pkg2", "./pkg3"},
-	})
-	run(t, "reunFailsPackageList args, with rerunOpts ", testCase{
-		opts: &options{
-			packages: []string{"./pkg1", "./pkg2", "./pkg3"},
-		},
-		rerunOpts: rerunOpts{
-			runFlag: "-run=TestOne|TestTwo",
-			pkg:     "./fails",
-		},
-		expected: []string{"go", "test", "-json", "-run=TestOne|TestTwo", "./fails"},
-	})
-	run(t, "reunFailsPackageList args, with rerunOpts, with -args ", testCase{
-		opts: &options{
-			args:     []string{"before", "-args", "after"},
-			packages: []string{"./pkg1"},
-		},
-		rerunOpts: rerunOpts{
-			runFlag: "-run=TestOne|TestTwo",
-			pkg:     "./fails",
-		},
-		expected: []string{"go", "test", "-json", "-run=TestOne|TestTwo", "before", "./fails", "-args", "after"},
-	})
-	run(t, "reunFailsPackageList args, with rerunOpts, with -args at end", testCase{
-		opts: &options{
-			args:     []string{"before", "-args"},
-			packages: []string{"./pkg1"},
-		},
-		rerunOpts: rerunOpts{
-			runFlag: "-run=TestOne|TestTwo",
-			pkg:     "./fails",
-		},
-		expected: []string{"go", "test", "-json", "-run=TestOne|TestTwo", "before", "./fails", "-args"},
-	})
-	run(t, "reunFailsPackageList args, with -args at start", testCase{
-		opts: &options{
-			args:     []string{"-args", "after"},
-			packages: []string{"./pkg1"},
-		},
-		expected: []string{"go", "test", "-json", "./pkg1", "-args", "after"},
-	})
-	run(t, "-run arg at start, with rerunOpts ", testCase{
-		opts: &options{
-			args:     []string{"-run=TestFoo", "-args"},
-			packages: []string{"./pkg"},
-		},
-		rerunOpts: rerunOpts{
-			runFlag: "-run=TestOne|TestTwo",
-			pkg:     "./fails",
-		},
-		expected: []string{"go", "test", "-json", "-run=TestOne|TestTwo", "./fails", "-args"},
-	})
-	run(t, "-run arg in middle, with rerunOpts ", testCase{
-		opts: &options{
-			args:     []string{"-count", "1", "--run", "TestFoo", "-args"},
-			packages: []string{"./pkg"},
-		},
-		rerunOpts: rerunOpts{
-			runFlag: "-run=TestOne|TestTwo",
-			pkg:     "./fails",
-		},
-		expected: []string{"go", "test", "-json", "-run=TestOne|TestTwo", "-count", "1", "./fails", "-args"},
-	})
-	run(t, "-run arg at end with missing value, with rerunOpts ", testCase{
-		opts: &options{
-			args:     []string{"-count", "1", "-run"},
-			packages: []string{"./pkg"},
-		},
-		rerunOpts: rerunOpts{
-			runFlag: "-run=TestOne|TestTwo",
-			pkg:     "./fails",
-		},
-		expected: []string{"go", "test", "-json", "-run=TestOne|TestTwo", "-count", "1", "-run", "./fails"},
-	})
-}
-
-func runCase(t *testing.T, name string, fn func(t *testing.T)) {
-	t.Helper()
-	t.Run(name, func(t *testing.T) {
-		t.Helper()
-		t.Log("case:", name)
-		fn(t)
-	})
-}
-
-func TestRun_RerunFails_WithTooManyInitialFailures(t *testing.T) {
-	jsonFailed := `{"Package": "pkg", "Action": "run"}
-{"Package": "pkg", "Test": "TestOne", "Action": "run"}
-{"Package": "pkg", "Test": "TestOne", "Action": "fail"}
-{"Package": "pkg", "Test": "TestTwo", "Action": "run"}
-{"Package": "pkg", "Test": "TestTwo", "Action": "fail"}
-{"Package": "pkg", "Action": "fail"}
-`
-
-	fn := func(args []string) *proc {
-		return &proc{
-			cmd:    fakeWaiter{result: newExitCode("failed", 1)},
-			stdout: strings.NewReader(jsonFailed),
-			stderr: bytes.NewReader(nil),
-		}
-	}
-	reset := patchStartGoTestFn(fn)
-	defer reset()
-
-	out := new(bytes.Buffer)
-	opts := &options{
-		rawCommand:                   true,
-		args:                         []string{"./test.test"},
-		format:                       "testname",
-		rerunFailsMaxAttempts:        3,
-		rerunFailsMaxInitialFailures: 1,
-		stdout:                       out,
-		stderr:                       os.Stderr,
-		hideSummary:                  newHideSummaryValue(),
-	}
-	err := run(opts)
-	assert.ErrorContains(t, err, "number of test failures (2) exceeds maximum (1)", out.String())
-}
-
-func TestRun_RerunFails_BuildErrorPreventsRerun(t *testing.T) {
-	jsonFailed := `{"Package": "pkg", "Action": "run"}
-{"Package": "pkg", "Test": "TestOne", "Action": "run"}
-{"Package": "pkg", "Test": "TestOne", "Action": "fail"}
-{"Package": "pkg", "Test": "TestTwo", "Action": "run"}
-{"Package": "pkg", "Test": "TestTwo", "Action": "fail"}
-{"Package": "pkg", "Action": "fail"}
-`
-
-	fn := func(args []string) *proc {
-		return &proc{
-			cmd:    fakeWaiter{result: newExitCode("failed", 1)},
-			stdout: strings.NewReader(jsonFailed),
-			stderr: strings.NewReader("anything here is an error\n"),
-		}
-	}
-	reset := patchStartGoTestFn(fn)
-	defer reset()
-
-	out := new(bytes.Buffer)
-	opts := &options{
-		rawCommand:                   true,
-		args:                         []string{"./test.test"},
-		format:                       "testname",
-		rerunFailsMaxAttempts:        3,
-		rerunFailsMaxInitialFailures: 1,
-		stdout:                       out,
-		stderr:                       os.Stderr,
-		hideSummary:                  newHideSummaryValue(),
-	}
-	err := run(opts)
-	assert.ErrorContains(t, err, "rerun aborted because previous run had errors", out.String())
-}
-
-// type checking of os/exec.ExitError is done in a test file so that users
-// installing from source can continue to use versions prior to go1.12.
-var _ exitCoder = &exec.ExitError{}
-
-func TestRun_RerunFails_PanicPreventsRerun(t *testing.T) {
-	jsonFailed := `{"Package": "pkg", "Action": "run"}
-{"Package": "pkg", "Test": "TestOne", "Action": "run"}
-{"Package": "pkg", "Test": "TestOne", "Action": "output","Output":"panic: something went wrong\n"}
-{"Package": "pkg", "Action": "fail"}
-`
-
-	fn := func(args []string) *proc {
-		return &proc{
-			cmd:    fakeWaiter{result: newExitCode("failed", 1)},
-			stdout: strings.NewReader(jsonFailed),
-			stderr: bytes.NewReader(nil),
-		}
-	}
-	reset := patchStartGoTestFn(fn)
-	defer reset()
-
-	out := new(bytes.Buffer)
-	opts := &options{
-		rawCommand:                   true,
-		args:                         []string{"./test.test"},
-		format:                       "testname",
-		rerunFailsMaxAttempts:        3,
-		rerunFailsMaxInitialFailures: 1,
-		stdout:                       out,
-		stderr:                       os.Stderr,
-		hideSummary:                  newHideSummaryValue(),
-	}
-	err := run(opts)
-	assert.ErrorContains(t, err, "rerun aborted because previous run had a suspected panic", out.String())
-}
-
 func TestRun_InputFromStdin(t *testing.T) {
 	stdin := os.Stdin
 	t.Cleanup(func() { os.Stdin = stdin })
@@ -452,17 +140,8 @@ func TestRun_InputFromStdin(t *testing.T) {
 func TestRun_JsonFileIsSyncedBeforePostRunCommand(t *testing.T) {
 	skip.If(t, runtime.GOOS == "windows")
 
-	input := golden.Get(t, "../../testjson/testdata/input/go-test-json.out")
-
-	fn := func(args []string) *proc {
-		return &proc{
-			cmd:    fakeWaiter{},
-			stdout: bytes.NewReader(input),
-			stderr: bytes.NewReader(nil),
-		}
-	}
-	reset := patchStartGoTestFn(fn)
-	defer reset()
+	inputFile := "../testjson/testdata/input/go-test-json.out"
+	input := golden.Get(t, "../"+inputFile)
 
 	tmp := t.TempDir()
 	jsonFile := filepath.Join(tmp, "json.log")
@@ -470,14 +149,14 @@ func TestRun_JsonFileIsSyncedBeforePostRunCommand(t *testing.T) {
 	out := new(bytes.Buffer)
 	opts := &options{
 		rawCommand:  true,
-		args:        []string{"./test.test"},
+		args:        []string{"cat", inputFile},
 		format:      "none",
 		stdout:      out,
 		stderr:      os.Stderr,
 		hideSummary: &hideSummaryValue{value: testjson.SummarizeNone},
 		jsonFile:    jsonFile,
-		postRunHookCmd: &commandValue{
-			command: []string{"cat", jsonFile},
+		postRunHookCmd: &commandsValue{
+			commands: []commandValue{{command: []string{"cat", jsonFile}}},
 		},
 	}
 	err := run(opts)
@@ -488,17 +167,9 @@ func TestRun_JsonFileIsSyncedBeforePostRunCommand(t *testing.T) {
 }
 
 func TestRun_JsonFileTimingEvents(t *testing.T) {
-	input := golden.Get(t, "../../testjson/testdata/input/go-test-json.out")
+	skip.If(t, runtime.GOOS == "windows")
 
-	fn := func(args []string) *proc {
-		return &proc{
-			cmd:    fakeWaiter{},
-			stdout: bytes.NewReader(input),
-			stderr: bytes.NewReader(nil),
-		}
-	}
-	reset := patchStartGoTestFn(fn)
-	defer reset()
+	inputFile := "../testjson/testdata/input/go-test-json.out"
 
 	tmp := t.TempDir()
 	jsonFileTiming := filepath.Join(tmp, "json.log")
@@ -506,7 +177,7 @@ func TestRun_JsonFileTimingEvents(t *testing.T) {
 	out := new(bytes.Buffer)
 	opts := &options{
 		rawCommand:           true,
-		args:                 []string{"./test.test"},
+		args:                 []string{"cat", inputFile},
 		format:               "none",
 		stdout:               out,
 		stderr:               os.Stderr,
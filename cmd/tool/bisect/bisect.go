@@ -0,0 +1,373 @@
+package bisect
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/dnephin/pflag"
+	"gotest.tools/gotestsum/internal/log"
+	"gotest.tools/gotestsum/internal/testbinary"
+	"gotest.tools/gotestsum/runner"
+	"gotest.tools/gotestsum/testjson"
+)
+
+// Run the command
+func Run(name string, args []string) error {
+	flags, opts := setupFlags(name)
+	switch err := flags.Parse(args); {
+	case err == pflag.ErrHelp:
+		return nil
+	case err != nil:
+		usage(os.Stderr, name, flags)
+		return err
+	}
+	if flags.NArg() < 2 {
+		usage(os.Stderr, name, flags)
+		return fmt.Errorf("a package and a test are required")
+	}
+	opts.pkg = flags.Arg(0)
+	opts.test = flags.Arg(1)
+	opts.buildFlags = flags.Args()[2:]
+	opts.stdout = os.Stdout
+	opts.stderr = os.Stderr
+	return run(opts)
+}
+
+type options struct {
+	shuffle string
+	format  string
+	debug   bool
+
+	pkg        string
+	test       string
+	buildFlags []string
+
+	// shims for testing
+	stdout io.Writer
+	stderr io.Writer
+}
+
+func setupFlags(name string) (*pflag.FlagSet, *options) {
+	opts := &options{}
+	flags := pflag.NewFlagSet(name, pflag.ContinueOnError)
+	flags.SetInterspersed(false)
+	flags.Usage = func() {
+		usage(os.Stdout, name, flags)
+	}
+	flags.StringVar(&opts.shuffle, "shuffle", "off",
+		"the -shuffle seed printed by the run where the test failed, or off")
+	flags.StringVarP(&opts.format, "format", "f", "none",
+		"print the test events of each run with this gotestsum format")
+	flags.BoolVar(&opts.debug, "debug", false,
+		"enable debug logging.")
+	return flags, opts
+}
+
+func usage(out io.Writer, name string, flags *pflag.FlagSet) {
+	fmt.Fprintf(out, `Usage:
+    %[1]s [flags] PACKAGE TEST [go test build flags]
+
+Find the tests that make TEST fail when they run before it. Use this command
+when TEST passes when it runs alone, but fails when it runs with the other
+tests in the package, because an earlier test changed some shared state.
+
+The command lists the tests in the package with -test.list, and bisects the
+tests that run before TEST. Each step runs TEST and some of the earlier tests
+with a -test.run pattern, until it finds the smallest set of earlier tests
+that make TEST fail. If TEST failed in a run with -shuffle, use --shuffle with
+the seed printed by that run, so that the tests run in the same order.
+
+Any args after TEST are passed to 'go test -c', ex: -race or -tags.
+
+    %[1]s --shuffle 1699999999 ./pkg/store TestStore_Get
+
+Flags:
+`, name)
+	flags.SetOutput(out)
+	flags.PrintDefaults()
+}
+
+func run(opts *options) error {
+	if opts.debug {
+		log.SetLevel(log.DebugLevel)
+	}
+	if strings.Contains(opts.test, "/") {
+		return fmt.Errorf("%v is a subtest, TEST must be the name of a top-level test", opts.test)
+	}
+	seed, err := parseShuffle(opts.shuffle)
+	if err != nil {
+		return err
+	}
+	formatter := testjson.NewEventFormatter(opts.stdout, opts.format, testjson.FormatOptions{})
+	if formatter == nil {
+		return fmt.Errorf("unknown format %v", opts.format)
+	}
+
+	dir, err := ioutil.TempDir("", "gotestsum-bisect")
+	if err != nil {
+		return fmt.Errorf("failed to create temp directory: %v", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(dir); err != nil {
+			log.Warnf("Failed to remove temp directory %v: %v", dir, err)
+		}
+	}()
+
+	binary, err := testbinary.Build(testbinary.BuildConfig{
+		Package:    opts.pkg,
+		BuildFlags: opts.buildFlags,
+		Dir:        dir,
+		Output:     opts.stderr,
+	})
+	if err != nil {
+		return err
+	}
+	names, err := binary.List("^Test")
+	if err != nil {
+		return err
+	}
+	before, err := testsBefore(testOrder(names, seed), opts.test)
+	if err != nil {
+		return fmt.Errorf("%v in %v", err, opts.pkg)
+	}
+
+	b := &bisector{
+		target:  opts.test,
+		out:     opts.stdout,
+		results: make(map[string]runResult),
+		runTests: func(tests []string) (*testjson.Execution, error) {
+			return binary.Run(context.Background(), &eventHandler{formatter: formatter, err: opts.stderr},
+				testArgs(tests, opts.shuffle)...)
+		},
+	}
+	polluters, err := b.bisect(before)
+	if err != nil {
+		return err
+	}
+	printReport(opts.stdout, b, polluters, reproduceCommand(opts, append(polluters, opts.test)))
+	return nil
+}
+
+// parseShuffle returns the seed from the value of --shuffle, or nil if the
+// tests were not shuffled.
+func parseShuffle(value string) (*int64, error) {
+	if value == "off" || value == "" {
+		return nil, nil
+	}
+	seed, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("--shuffle must be off or the seed printed by the test run: %v", err)
+	}
+	return &seed, nil
+}
+
+// testOrder returns the tests in the order they run. The testing package runs
+// the tests in the order they are defined, or when -shuffle is set, in the
+// order from shuffling all the tests with the seed. A -test.run pattern only
+// skips tests, so the tests which do run are always in the same order.
+func testOrder(names []string, seed *int64) []string {
+	order := make([]string, len(names))
+	copy(order, names)
+	if seed != nil {
+		rng := rand.New(rand.NewSource(*seed))
+		rng.Shuffle(len(order), func(i, j int) { order[i], order[j] = order[j], order[i] })
+	}
+	return order
+}
+
+// testsBefore returns the tests that run before test.
+func testsBefore(order []string, test string) ([]string, error) {
+	for i, name := range order {
+		if name == test {
+			return order[:i], nil
+		}
+	}
+	return nil, fmt.Errorf("test %v not found", test)
+}
+
+// runPattern returns a -test.run pattern which matches only the tests.
+func runPattern(tests []string) string {
+	quoted := make([]string, 0, len(tests))
+	for _, name := range tests {
+		quoted = append(quoted, regexp.QuoteMeta(name))
+	}
+	return "^(" + strings.Join(quoted, "|") + ")$"
+}
+
+// testArgs returns the args for the test binary to run the tests.
+func testArgs(tests []string, shuffle string) []string {
+	args := []string{"-test.count=1", "-test.run=" + runPattern(tests)}
+	if shuffle != "off" && shuffle != "" {
+		args = append(args, "-test.shuffle="+shuffle)
+	}
+	return args
+}
+
+// reproduceCommand returns the 'go test' command that runs the tests in the
+// same order as the run that found them.
+func reproduceCommand(opts *options, tests []string) string {
+	args := []string{"go", "test", "-run=" + runPattern(tests), "-count=1"}
+	if opts.shuffle != "off" && opts.shuffle != "" {
+		args = append(args, "-shuffle="+opts.shuffle)
+	}
+	args = append(args, opts.buildFlags...)
+	args = append(args, opts.pkg)
+	return runner.QuoteCommand(args)
+}
+
+type runResult struct {
+	failed bool
+	// summary of the run, when the target failed. The summary is printed when
+	// the run ends, so that the elapsed time is the elapsed time of the run.
+	summary string
+}
+
+// bisector finds the tests which make the target test fail when they run
+// before it.
+type bisector struct {
+	target string
+	out    io.Writer
+	// runTests runs the target test and the tests from before it, which are
+	// passed as the argument.
+	runTests func(tests []string) (*testjson.Execution, error)
+	// results of each set of tests, so that no set is run more than once.
+	results map[string]runResult
+}
+
+// fails runs the target after the tests, and returns true if the target
+// failed.
+func (b *bisector) fails(tests []string) (bool, error) {
+	key := strings.Join(tests, " ")
+	if result, ok := b.results[key]; ok {
+		return result.failed, nil
+	}
+
+	execution, err := b.runTests(append(tests[:len(tests):len(tests)], b.target))
+	if err != nil {
+		return false, err
+	}
+	result := runResult{failed: targetFailed(execution, b.target)}
+	if result.failed {
+		buf := new(strings.Builder)
+		testjson.PrintSummary(buf, execution, failureSummary)
+		result.summary = buf.String()
+	}
+	b.results[key] = result
+
+	status := "passed"
+	if result.failed {
+		status = "failed"
+	}
+	fmt.Fprintf(b.out, "%v after %d %s: %s\n", b.target, len(tests), pluralize(len(tests), "test"), status)
+	return result.failed, nil
+}
+
+// targetFailed returns true if target, or one of its subtests, failed.
+func targetFailed(execution *testjson.Execution, target string) bool {
+	for _, tc := range execution.Failed() {
+		root, _ := tc.Test.Split()
+		if root == target {
+			return true
+		}
+	}
+	return false
+}
+
+// bisect returns the smallest set of tests from before which make the target
+// fail. The target must fail after all the tests in before, and pass when it
+// runs alone.
+//
+// bisect finds the shortest prefix of before which makes the target fail. The
+// last test in that prefix is required for the failure, so it is kept, and
+// the tests before it are bisected again, until the target fails with only
+// the tests which were kept. A failure which needs more than one test, ex:
+// one test changes the shared state, and another test uses it, finds all of
+// those tests.
+func (b *bisector) bisect(before []string) ([]string, error) {
+	failed, err := b.fails(nil)
+	switch {
+	case err != nil:
+		return nil, err
+	case failed:
+		return nil, fmt.Errorf("%v fails when it runs alone, the failure is not caused by "+
+			"the tests that run before it", b.target)
+	}
+	failed, err = b.fails(before)
+	switch {
+	case err != nil:
+		return nil, err
+	case !failed:
+		return nil, fmt.Errorf("%v passed after the %d tests that run before it, "+
+			"the failure did not reproduce", b.target, len(before))
+	}
+
+	var required []string
+	candidates := before
+	for {
+		// find the smallest n where the target fails after candidates[:n]
+		// and the required tests.
+		low, high := 0, len(candidates)
+		for low < high {
+			mid := (low + high) / 2
+			failed, err := b.fails(append(candidates[:mid:mid], required...))
+			if err != nil {
+				return nil, err
+			}
+			if failed {
+				high = mid
+			} else {
+				low = mid + 1
+			}
+		}
+		if high == 0 {
+			return required, nil
+		}
+		required = append([]string{candidates[high-1]}, required...)
+		candidates = candidates[:high-1]
+	}
+}
+
+// failureSummary is the summary of a run where the target failed. Skipped
+// tests are omitted, because they are not about the target.
+const failureSummary = testjson.SummarizeAll &^ testjson.SummarizeSkipped
+
+func printReport(out io.Writer, b *bisector, polluters []string, reproduce string) {
+	fmt.Fprint(out, b.results[strings.Join(polluters, " ")].summary)
+
+	fmt.Fprintf(out, "\n%v fails when it runs after %d %s:\n",
+		b.target, len(polluters), pluralize(len(polluters), "test"))
+	for _, name := range polluters {
+		fmt.Fprintf(out, "    %v\n", name)
+	}
+	fmt.Fprintf(out, "reproduce: %v\n", reproduce)
+}
+
+func pluralize(count int, word string) string {
+	if count == 1 {
+		return word
+	}
+	return word + "s"
+}
+
+// eventHandler sends the events from each run to the formatter, and the
+// stderr from each run to err.
+type eventHandler struct {
+	formatter testjson.EventFormatter
+	err       io.Writer
+}
+
+func (h *eventHandler) Event(event testjson.TestEvent, execution *testjson.Execution) error {
+	return h.formatter.Format(event, execution)
+}
+
+func (h *eventHandler) Err(text string) error {
+	_, err := fmt.Fprintln(h.err, text)
+	return err
+}
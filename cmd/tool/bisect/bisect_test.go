@@ -0,0 +1,211 @@
+package bisect
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+
+	"gotest.tools/gotestsum/internal/text"
+	"gotest.tools/gotestsum/testjson"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+	"gotest.tools/v3/env"
+	"gotest.tools/v3/golden"
+)
+
+func TestUsage_WithFlagsFromSetupFlags(t *testing.T) {
+	defer env.PatchAll(t, nil)()
+
+	name := "gotestsum tool bisect-order"
+	flags, _ := setupFlags(name)
+	buf := new(bytes.Buffer)
+	usage(buf, name, flags)
+
+	golden.Assert(t, buf.String(), "cmd-flags-help-text")
+}
+
+// fakeRunTests returns a runTests function for a bisector. The target fails
+// when all of the polluters run before it.
+func fakeRunTests(t *testing.T, target string, polluters ...string) func([]string) (*testjson.Execution, error) {
+	return func(tests []string) (*testjson.Execution, error) {
+		assert.Equal(t, tests[len(tests)-1], target)
+
+		action := "fail"
+		for _, polluter := range polluters {
+			if !contains(tests, polluter) {
+				action = "pass"
+			}
+		}
+		out := new(strings.Builder)
+		for _, name := range tests {
+			result := "pass"
+			if name == target {
+				result = action
+			}
+			fmt.Fprintf(out, `{"Action":"run","Package":"example.com/pkg","Test":%q}
+{"Action":%q,"Package":"example.com/pkg","Test":%q}
+`, name, result, name)
+		}
+		fmt.Fprintf(out, `{"Action":%q,"Package":"example.com/pkg"}`+"\n", action)
+		return testjson.ScanTestOutput(testjson.ScanConfig{Stdout: strings.NewReader(out.String())})
+	}
+}
+
+func contains(tests []string, name string) bool {
+	for _, test := range tests {
+		if test == name {
+			return true
+		}
+	}
+	return false
+}
+
+func TestBisector_Bisect(t *testing.T) {
+	before := []string{"TestA", "TestB", "TestC", "TestD", "TestE", "TestF", "TestG", "TestH", "TestI"}
+
+	type testCase struct {
+		name      string
+		polluters []string
+	}
+	fn := func(t *testing.T, tc testCase) {
+		b := &bisector{
+			target:   "TestTarget",
+			out:      new(bytes.Buffer),
+			results:  make(map[string]runResult),
+			runTests: fakeRunTests(t, "TestTarget", tc.polluters...),
+		}
+		actual, err := b.bisect(before)
+		assert.NilError(t, err)
+		assert.DeepEqual(t, actual, tc.polluters)
+	}
+
+	testCases := []testCase{
+		{name: "first test", polluters: []string{"TestA"}},
+		{name: "middle test", polluters: []string{"TestE"}},
+		{name: "last test", polluters: []string{"TestI"}},
+		{name: "two tests", polluters: []string{"TestB", "TestG"}},
+		{name: "adjacent tests", polluters: []string{"TestD", "TestE"}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fn(t, tc)
+		})
+	}
+}
+
+func TestBisector_Bisect_FailsAlone(t *testing.T) {
+	b := &bisector{
+		target:   "TestTarget",
+		out:      new(bytes.Buffer),
+		results:  make(map[string]runResult),
+		runTests: fakeRunTests(t, "TestTarget"),
+	}
+	_, err := b.bisect([]string{"TestA", "TestB"})
+	assert.Error(t, err, "TestTarget fails when it runs alone, "+
+		"the failure is not caused by the tests that run before it")
+}
+
+func TestBisector_Bisect_DoesNotReproduce(t *testing.T) {
+	b := &bisector{
+		target:   "TestTarget",
+		out:      new(bytes.Buffer),
+		results:  make(map[string]runResult),
+		runTests: fakeRunTests(t, "TestTarget", "TestNotBefore"),
+	}
+	_, err := b.bisect([]string{"TestA", "TestB"})
+	assert.Error(t, err, "TestTarget passed after the 2 tests that run before it, "+
+		"the failure did not reproduce")
+}
+
+func TestTestOrder(t *testing.T) {
+	names := []string{"TestA", "TestB", "TestC", "TestD", "TestE"}
+	assert.DeepEqual(t, testOrder(names, nil), names)
+
+	seed := int64(3)
+	shuffled := testOrder(names, &seed)
+	assert.Equal(t, len(shuffled), len(names))
+	for _, name := range names {
+		assert.Assert(t, contains(shuffled, name), "missing %v", name)
+	}
+	assert.DeepEqual(t, testOrder(names, &seed), shuffled)
+	assert.DeepEqual(t, names, []string{"TestA", "TestB", "TestC", "TestD", "TestE"})
+}
+
+func TestReproduceCommand(t *testing.T) {
+	opts := &options{shuffle: "12", pkg: "./store", buildFlags: []string{"-race"}}
+	actual := reproduceCommand(opts, []string{"TestSetup", "TestGet"})
+	assert.Equal(t, actual, `go test '-run=^(TestSetup|TestGet)$' -count=1 -shuffle=12 -race ./store`)
+}
+
+func TestRun_Subtest(t *testing.T) {
+	opts := &options{test: "TestA/sub", shuffle: "off", format: "none"}
+	err := run(opts)
+	assert.ErrorContains(t, err, "TEST must be the name of a top-level test")
+}
+
+func TestRun(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for short run")
+	}
+	if strings.HasPrefix(runtime.Version(), "go1.1") {
+		t.Skip("-test.v=test2json requires go1.20+")
+	}
+
+	type testCase struct {
+		name        string
+		test        string
+		shuffle     string
+		expectedErr string
+	}
+	fn := func(t *testing.T, tc testCase) {
+		out := new(bytes.Buffer)
+		opts := &options{
+			shuffle: tc.shuffle,
+			format:  "none",
+			pkg:     "./testdata/polluted",
+			test:    tc.test,
+			stdout:  out,
+			stderr:  new(bytes.Buffer),
+		}
+		err := run(opts)
+		if tc.expectedErr != "" {
+			assert.Assert(t, is.ErrorContains(err, tc.expectedErr))
+		} else {
+			assert.NilError(t, err)
+		}
+		actual := text.ProcessLines(t, out,
+			text.OpRemoveSummaryLineElapsedTime,
+			text.OpRemoveTestElapsedTime)
+		golden.Assert(t, actual, fmt.Sprintf("expected-run-%v", tc.name))
+	}
+
+	testCases := []testCase{
+		{name: "in-order", test: "TestPolluted", shuffle: "off"},
+		{name: "shuffled", test: "TestPolluted", shuffle: "5"},
+		{
+			name:        "shuffled-does-not-reproduce",
+			test:        "TestPolluted",
+			shuffle:     "2",
+			expectedErr: "the failure did not reproduce",
+		},
+		{
+			name:        "fails-alone",
+			test:        "TestFailsAlone",
+			shuffle:     "off",
+			expectedErr: "TestFailsAlone fails when it runs alone",
+		},
+		{
+			name:        "not-found",
+			test:        "TestDoesNotExist",
+			shuffle:     "off",
+			expectedErr: "test TestDoesNotExist not found in ./testdata/polluted",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fn(t, tc)
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package polluted
+
+import "testing"
+
+var state = map[string]int{}
+
+func TestA(t *testing.T) {}
+
+func TestSetup(t *testing.T) {
+	state["ready"] = 1
+}
+
+func TestB(t *testing.T) {}
+
+func TestC(t *testing.T) {}
+
+func TestMutate(t *testing.T) {
+	if state["ready"] == 1 {
+		state["count"] = 2
+	}
+}
+
+func TestD(t *testing.T) {}
+
+func TestE(t *testing.T) {}
+
+func TestFailsAlone(t *testing.T) {
+	t.Fatal("this test always fails")
+}
+
+func TestPolluted(t *testing.T) {
+	if state["count"] != 0 {
+		t.Fatalf("expected count 0, got %d", state["count"])
+	}
+}
+
+func TestF(t *testing.T) {}
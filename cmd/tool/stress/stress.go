@@ -0,0 +1,346 @@
+package stress
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dnephin/pflag"
+	"gotest.tools/gotestsum/internal/log"
+	"gotest.tools/gotestsum/internal/testbinary"
+	"gotest.tools/gotestsum/testjson"
+)
+
+// Run the command
+func Run(name string, args []string) error {
+	flags, opts := setupFlags(name)
+	switch err := flags.Parse(args); {
+	case err == pflag.ErrHelp:
+		return nil
+	case err != nil:
+		usage(os.Stderr, name, flags)
+		return err
+	}
+	if flags.NArg() == 0 {
+		usage(os.Stderr, name, flags)
+		return fmt.Errorf("a package is required")
+	}
+	opts.pkg = flags.Arg(0)
+	opts.buildFlags = flags.Args()[1:]
+	opts.stdout = os.Stdout
+	opts.stderr = os.Stderr
+	return run(opts)
+}
+
+type options struct {
+	run         string
+	parallel    int
+	count       int
+	duration    time.Duration
+	maxFailures int
+	format      string
+	debug       bool
+
+	pkg        string
+	buildFlags []string
+
+	// progressInterval is the time between progress lines, or 0 to disable
+	// the progress lines.
+	progressInterval time.Duration
+
+	// shims for testing
+	stdout io.Writer
+	stderr io.Writer
+}
+
+func setupFlags(name string) (*pflag.FlagSet, *options) {
+	opts := &options{progressInterval: 5 * time.Second}
+	flags := pflag.NewFlagSet(name, pflag.ContinueOnError)
+	flags.SetInterspersed(false)
+	flags.Usage = func() {
+		usage(os.Stdout, name, flags)
+	}
+	flags.StringVar(&opts.run, "run", "",
+		"run only the tests matching the regular expression, passed to the test binary as -test.run")
+	flags.IntVarP(&opts.parallel, "parallel", "p", 0,
+		"number of test binaries to run at the same time, defaults to the number of CPUs")
+	flags.IntVar(&opts.count, "count", 0,
+		"stop after this many runs, 0 for no limit")
+	flags.DurationVar(&opts.duration, "duration", 0,
+		"stop after this much time, 0 for no limit")
+	flags.IntVar(&opts.maxFailures, "max-failures", 1,
+		"stop after this many failed runs, 0 for no limit")
+	flags.StringVarP(&opts.format, "format", "f", "none",
+		"print the test events of each run with this gotestsum format")
+	flags.BoolVar(&opts.debug, "debug", false,
+		"enable debug logging.")
+	return flags, opts
+}
+
+func usage(out io.Writer, name string, flags *pflag.FlagSet) {
+	fmt.Fprintf(out, `Usage:
+    %[1]s [flags] PACKAGE [go test build flags]
+
+Compile the tests for a package once, and run the test binary repeatedly to
+reproduce a flaky test. The test binary is run by --parallel processes at the
+same time, until --max-failures runs failed, --count runs finished, or
+--duration has passed. Press Ctrl-C to stop early.
+
+The output of each run is read as test2json output, so the test events can be
+printed with any --format supported by gotestsum. When the command stops it
+prints the number of runs, the failure rate, and the summary of the first run
+that failed.
+
+Any args after the package are passed to 'go test -c', ex: -race or -tags.
+
+    %[1]s --run TestFlaky --duration 10m ./pkg/store -race
+
+Flags:
+`, name)
+	flags.SetOutput(out)
+	flags.PrintDefaults()
+}
+
+func run(opts *options) error {
+	if opts.debug {
+		log.SetLevel(log.DebugLevel)
+	}
+	switch {
+	case opts.parallel < 0:
+		return fmt.Errorf("--parallel must not be negative")
+	case opts.parallel == 0:
+		opts.parallel = runtime.NumCPU()
+	}
+	formatter := testjson.NewEventFormatter(opts.stdout, opts.format, testjson.FormatOptions{})
+	if formatter == nil {
+		return fmt.Errorf("unknown format %v", opts.format)
+	}
+
+	dir, err := ioutil.TempDir("", "gotestsum-stress")
+	if err != nil {
+		return fmt.Errorf("failed to create temp directory: %v", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(dir); err != nil {
+			log.Warnf("Failed to remove temp directory %v: %v", dir, err)
+		}
+	}()
+
+	binary, err := testbinary.Build(testbinary.BuildConfig{
+		Package:    opts.pkg,
+		BuildFlags: opts.buildFlags,
+		Dir:        dir,
+		Output:     opts.stderr,
+	})
+	if err != nil {
+		return err
+	}
+	runOnce := func(ctx context.Context, handler testjson.EventHandler) (*testjson.Execution, error) {
+		return binary.Run(ctx, handler, testArgs(opts)...)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cancelOnInterrupt(ctx, cancel)
+
+	handler := &eventHandler{formatter: formatter, err: opts.stderr}
+	result := stress(ctx, opts, handler, runOnce)
+	if result.err != nil {
+		return result.err
+	}
+	printReport(opts.stdout, result)
+	if result.failures > 0 {
+		return fmt.Errorf("%d of %d runs failed", result.failures, result.runs)
+	}
+	return nil
+}
+
+// cancelOnInterrupt calls cancel when the process receives an interrupt, so
+// that the report can be printed before the command exits.
+func cancelOnInterrupt(ctx context.Context, cancel func()) {
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, os.Interrupt)
+	go func() {
+		defer signal.Stop(c)
+		select {
+		case <-c:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+}
+
+// testArgs returns the args for each run of the test binary.
+func testArgs(opts *options) []string {
+	args := []string{"-test.count=1"}
+	if opts.run != "" {
+		args = append(args, "-test.run="+opts.run)
+	}
+	return args
+}
+
+// eventHandler sends the events from all the runs to the same formatter. The
+// formatters are not safe for concurrent use, so the runs take turns.
+type eventHandler struct {
+	mu        sync.Mutex
+	formatter testjson.EventFormatter
+	err       io.Writer
+}
+
+func (h *eventHandler) Event(event testjson.TestEvent, execution *testjson.Execution) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.formatter.Format(event, execution)
+}
+
+func (h *eventHandler) Err(text string) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := fmt.Fprintln(h.err, text)
+	return err
+}
+
+type runFunc func(ctx context.Context, handler testjson.EventHandler) (*testjson.Execution, error)
+
+type result struct {
+	runs     int
+	failures int
+	elapsed  time.Duration
+	// firstFailure is the summary of the first run that failed. The summary
+	// is printed when the run ends, so that the elapsed time is the elapsed
+	// time of the run.
+	firstFailure string
+	// err stops the stress run when a run could not be started, or when the
+	// run did not run any tests.
+	err error
+}
+
+// failureRate returns the percentage of runs that failed.
+func (r result) failureRate() float64 {
+	if r.runs == 0 {
+		return 0
+	}
+	return float64(r.failures) / float64(r.runs) * 100
+}
+
+// stress calls runOnce from opts.parallel goroutines until one of the limits
+// in opts is reached, or ctx is cancelled. A run that is stopped because a
+// limit was reached is not included in the result.
+func stress(ctx context.Context, opts *options, handler testjson.EventHandler, runOnce runFunc) result {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	if opts.duration > 0 {
+		ctx, cancel = context.WithTimeout(ctx, opts.duration)
+		defer cancel()
+	}
+
+	var (
+		mu      sync.Mutex
+		res     result
+		started int
+		wg      sync.WaitGroup
+	)
+	start := time.Now()
+
+	// next returns false when no more runs should be started.
+	next := func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		if ctx.Err() != nil || (opts.count > 0 && started >= opts.count) {
+			return false
+		}
+		started++
+		return true
+	}
+	done := func(execution *testjson.Execution, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case ctx.Err() != nil:
+			return
+		case err != nil:
+			res.err = err
+			cancel()
+			return
+		case execution.Total() == 0 && len(execution.Failed()) == 0:
+			res.err = fmt.Errorf("no tests to run, check the --run pattern")
+			cancel()
+			return
+		}
+		res.runs++
+		if len(execution.Failed()) == 0 {
+			return
+		}
+		res.failures++
+		if res.firstFailure == "" {
+			res.firstFailure = formatFailure(execution)
+		}
+		if opts.maxFailures > 0 && res.failures >= opts.maxFailures {
+			cancel()
+		}
+	}
+
+	for i := 0; i < opts.parallel; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for next() {
+				done(runOnce(ctx, handler))
+			}
+		}()
+	}
+
+	stopProgress := make(chan struct{})
+	if opts.progressInterval > 0 {
+		go func() {
+			ticker := time.NewTicker(opts.progressInterval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ticker.C:
+					mu.Lock()
+					fmt.Fprintf(opts.stderr, "%v: %d runs so far, %d failed (%.2f%%)\n",
+						time.Since(start).Round(time.Second), res.runs, res.failures, res.failureRate())
+					mu.Unlock()
+				case <-stopProgress:
+					return
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+	close(stopProgress)
+	res.elapsed = time.Since(start)
+	return res
+}
+
+// failureSummary is the summary of a failed run. Skipped tests are omitted
+// because a run that passed would skip the same tests.
+const failureSummary = testjson.SummarizeAll &^ testjson.SummarizeSkipped
+
+func formatFailure(execution *testjson.Execution) string {
+	buf := new(strings.Builder)
+	testjson.PrintSummary(buf, execution, failureSummary)
+	return buf.String()
+}
+
+func printReport(out io.Writer, res result) {
+	if res.firstFailure != "" {
+		fmt.Fprint(out, "\nFirst failed run:\n"+res.firstFailure)
+	}
+	runs := "runs"
+	if res.runs == 1 {
+		runs = "run"
+	}
+	fmt.Fprintf(out, "\n%d %s, %d failed (%.2f%%) in %s\n",
+		res.runs, runs, res.failures, res.failureRate(),
+		testjson.FormatDurationAsSeconds(res.elapsed, 3))
+}
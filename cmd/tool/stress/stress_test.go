@@ -0,0 +1,226 @@
+package stress
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gotest.tools/gotestsum/internal/text"
+	"gotest.tools/gotestsum/testjson"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/env"
+	"gotest.tools/v3/golden"
+)
+
+func TestUsage_WithFlagsFromSetupFlags(t *testing.T) {
+	defer env.PatchAll(t, nil)()
+
+	name := "gotestsum tool stress"
+	flags, _ := setupFlags(name)
+	buf := new(bytes.Buffer)
+	usage(buf, name, flags)
+
+	golden.Assert(t, buf.String(), "cmd-flags-help-text")
+}
+
+const (
+	passingRun = `{"Action":"run","Package":"example.com/pkg","Test":"TestFlaky"}
+{"Action":"output","Package":"example.com/pkg","Test":"TestFlaky","Output":"=== RUN   TestFlaky\n"}
+{"Action":"output","Package":"example.com/pkg","Test":"TestFlaky","Output":"--- PASS: TestFlaky (0.00s)\n"}
+{"Action":"pass","Package":"example.com/pkg","Test":"TestFlaky","Elapsed":0}
+{"Action":"output","Package":"example.com/pkg","Output":"PASS\n"}
+{"Action":"pass","Package":"example.com/pkg","Elapsed":0.01}
+`
+	failingRun = `{"Action":"run","Package":"example.com/pkg","Test":"TestFlaky"}
+{"Action":"output","Package":"example.com/pkg","Test":"TestFlaky","Output":"=== RUN   TestFlaky\n"}
+{"Action":"output","Package":"example.com/pkg","Test":"TestFlaky","Output":"    flaky_test.go:12: unlucky\n"}
+{"Action":"output","Package":"example.com/pkg","Test":"TestFlaky","Output":"--- FAIL: TestFlaky (0.00s)\n"}
+{"Action":"fail","Package":"example.com/pkg","Test":"TestFlaky","Elapsed":0}
+{"Action":"output","Package":"example.com/pkg","Output":"FAIL\n"}
+{"Action":"fail","Package":"example.com/pkg","Elapsed":0.01}
+`
+	noTestsRun = `{"Action":"output","Package":"example.com/pkg","Output":"testing: warning: no tests to run\n"}
+{"Action":"output","Package":"example.com/pkg","Output":"PASS\n"}
+{"Action":"pass","Package":"example.com/pkg","Elapsed":0.01}
+`
+)
+
+// fakeRuns returns a runFunc which scans the output from output. The number
+// of the run, starting at 1, is passed to output.
+func fakeRuns(output func(n int64) string) (runFunc, *int64) {
+	var count int64
+	return func(_ context.Context, handler testjson.EventHandler) (*testjson.Execution, error) {
+		n := atomic.AddInt64(&count, 1)
+		return testjson.ScanTestOutput(testjson.ScanConfig{
+			Stdout:  strings.NewReader(output(n)),
+			Handler: handler,
+		})
+	}, &count
+}
+
+func TestStress_StopsAfterCount(t *testing.T) {
+	runOnce, count := fakeRuns(func(int64) string { return passingRun })
+	opts := &options{parallel: 3, count: 10, maxFailures: 1}
+
+	res := stress(context.Background(), opts, noopHandler{}, runOnce)
+	assert.NilError(t, res.err)
+	assert.Equal(t, res.runs, 10)
+	assert.Equal(t, res.failures, 0)
+	assert.Equal(t, res.firstFailure, "")
+	assert.Equal(t, *count, int64(10))
+}
+
+func TestStress_StopsAfterMaxFailures(t *testing.T) {
+	runOnce, _ := fakeRuns(func(n int64) string {
+		if n%3 == 0 {
+			return failingRun
+		}
+		return passingRun
+	})
+	opts := &options{parallel: 1, maxFailures: 2}
+
+	res := stress(context.Background(), opts, noopHandler{}, runOnce)
+	assert.NilError(t, res.err)
+	assert.Equal(t, res.runs, 6)
+	assert.Equal(t, res.failures, 2)
+	assert.Equal(t, fmt.Sprintf("%.2f", res.failureRate()), "33.33")
+	assert.Assert(t, strings.Contains(res.firstFailure, "=== FAIL: example.com/pkg TestFlaky"),
+		res.firstFailure)
+}
+
+func TestStress_StopsAfterDuration(t *testing.T) {
+	runOnce, _ := fakeRuns(func(n int64) string {
+		time.Sleep(5 * time.Millisecond)
+		return passingRun
+	})
+	opts := &options{parallel: 2, duration: 100 * time.Millisecond}
+
+	res := stress(context.Background(), opts, noopHandler{}, runOnce)
+	assert.NilError(t, res.err)
+	assert.Assert(t, res.runs > 0)
+	assert.Equal(t, res.failures, 0)
+	assert.Assert(t, res.elapsed >= opts.duration, res.elapsed)
+}
+
+func TestStress_StopsWhenNoTestsRun(t *testing.T) {
+	runOnce, _ := fakeRuns(func(int64) string { return noTestsRun })
+	opts := &options{parallel: 2}
+
+	res := stress(context.Background(), opts, noopHandler{}, runOnce)
+	assert.Error(t, res.err, "no tests to run, check the --run pattern")
+}
+
+func TestStress_StopsWhenRunFails(t *testing.T) {
+	runOnce := func(context.Context, testjson.EventHandler) (*testjson.Execution, error) {
+		return nil, errors.New("failed to start")
+	}
+	opts := &options{parallel: 2}
+
+	res := stress(context.Background(), opts, noopHandler{}, runOnce)
+	assert.Error(t, res.err, "failed to start")
+}
+
+func TestStress_SendsEventsToHandler(t *testing.T) {
+	runOnce, _ := fakeRuns(func(int64) string { return passingRun })
+	opts := &options{parallel: 4, count: 20}
+	out := new(bytes.Buffer)
+	handler := &eventHandler{
+		formatter: testjson.NewEventFormatter(out, "pkgname", testjson.FormatOptions{}),
+		err:       new(bytes.Buffer),
+	}
+
+	res := stress(context.Background(), opts, handler, runOnce)
+	assert.NilError(t, res.err)
+	assert.Equal(t, strings.Count(out.String(), "example.com/pkg"), 20, out.String())
+}
+
+func TestPrintReport(t *testing.T) {
+	execution, err := testjson.ScanTestOutput(testjson.ScanConfig{
+		Stdout: strings.NewReader(failingRun),
+	})
+	assert.NilError(t, err)
+
+	out := new(bytes.Buffer)
+	printReport(out, result{
+		runs:         400,
+		failures:     3,
+		elapsed:      12345 * time.Millisecond,
+		firstFailure: formatFailure(execution),
+	})
+	actual := text.ProcessLines(t, out, func(line string) string {
+		if strings.HasPrefix(line, "DONE") {
+			return text.OpRemoveSummaryLineElapsedTime(line)
+		}
+		return line
+	})
+	golden.Assert(t, actual, "expected-report")
+}
+
+func TestRun(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for short run")
+	}
+	if strings.HasPrefix(runtime.Version(), "go1.1") {
+		t.Skip("-test.v=test2json requires go1.20+")
+	}
+
+	type testCase struct {
+		name        string
+		run         string
+		expectedErr string
+	}
+	fn := func(t *testing.T, tc testCase) {
+		out := new(bytes.Buffer)
+		opts := &options{
+			run:         tc.run,
+			parallel:    1,
+			count:       3,
+			maxFailures: 1,
+			format:      "testname",
+			pkg:         "./testdata/flaky",
+			stdout:      out,
+			stderr:      new(bytes.Buffer),
+		}
+		err := run(opts)
+		if tc.expectedErr != "" {
+			assert.Error(t, err, tc.expectedErr)
+		} else {
+			assert.NilError(t, err)
+		}
+		actual := text.ProcessLines(t, out,
+			text.OpRemoveSummaryLineElapsedTime,
+			text.OpRemoveTestElapsedTime)
+		golden.Assert(t, actual, fmt.Sprintf("expected-run-%v", tc.name))
+	}
+
+	testCases := []testCase{
+		{name: "passes", run: "TestPasses"},
+		{name: "fails", run: "TestFails", expectedErr: "1 of 1 runs failed"},
+		{
+			name:        "no-tests",
+			run:         "TestDoesNotExist",
+			expectedErr: "no tests to run, check the --run pattern",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fn(t, tc)
+		})
+	}
+}
+
+type noopHandler struct{}
+
+func (noopHandler) Event(testjson.TestEvent, *testjson.Execution) error {
+	return nil
+}
+
+func (noopHandler) Err(string) error {
+	return nil
+}
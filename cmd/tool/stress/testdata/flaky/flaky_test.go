@@ -0,0 +1,9 @@
+package flaky
+
+import "testing"
+
+func TestPasses(t *testing.T) {}
+
+func TestFails(t *testing.T) {
+	t.Fatal("this test always fails")
+}
@@ -0,0 +1,152 @@
+package timeline
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/dnephin/pflag"
+	"gotest.tools/gotestsum/internal/log"
+	"gotest.tools/gotestsum/testjson"
+)
+
+// Run the command
+func Run(name string, args []string) error {
+	flags, opts := setupFlags(name)
+	switch err := flags.Parse(args); {
+	case err == pflag.ErrHelp:
+		return nil
+	case err != nil:
+		usage(os.Stderr, name, flags)
+		return err
+	}
+	opts.stdin = os.Stdin
+	opts.stdout = os.Stdout
+	return run(opts)
+}
+
+type options struct {
+	jsonfile string
+	output   string
+	numTests int
+	debug    bool
+
+	// shims for testing
+	stdin  io.Reader
+	stdout io.Writer
+}
+
+func setupFlags(name string) (*pflag.FlagSet, *options) {
+	opts := &options{}
+	flags := pflag.NewFlagSet(name, pflag.ContinueOnError)
+	flags.SetInterspersed(false)
+	flags.Usage = func() {
+		usage(os.Stdout, name, flags)
+	}
+	flags.StringVar(&opts.jsonfile, "jsonfile", os.Getenv("GOTESTSUM_JSONFILE"),
+		"path to test2json output, defaults to stdin")
+	flags.StringVarP(&opts.output, "output", "o", "timeline.json",
+		"write the trace to this file")
+	flags.IntVar(&opts.numTests, "num", 10,
+		"print at most num of the slowest tests which did not call t.Parallel")
+	flags.BoolVar(&opts.debug, "debug", false,
+		"enable debug logging.")
+	return flags, opts
+}
+
+func usage(out io.Writer, name string, flags *pflag.FlagSet) {
+	fmt.Fprintf(out, `Usage:
+    %[1]s [flags]
+
+Read a json file and write a timeline of the test run in the Chrome trace event
+format. The json file may be created with 'gotestsum --jsonfile' or
+'go test -json'. The timeline can be viewed with https://ui.perfetto.dev or
+chrome://tracing.
+
+Each package is shown as a process, and each test as a slice. Tests which call
+t.Parallel are paused until the sequential tests in the package finish, the
+time a test was paused is shown as a gap in the slice.
+
+The command also prints statistics about the parallelism of the run, and the
+list of the slowest tests which did not call t.Parallel.
+
+    go test -json ./... > test.json
+    %[1]s --jsonfile test.json --output timeline.json
+
+Flags:
+`, name)
+	flags.SetOutput(out)
+	flags.PrintDefaults()
+}
+
+func run(opts *options) error {
+	if opts.debug {
+		log.SetLevel(log.DebugLevel)
+	}
+	in, err := jsonfileReader(opts.jsonfile, opts.stdin)
+	if err != nil {
+		return fmt.Errorf("failed to read jsonfile: %v", err)
+	}
+	defer func() {
+		if err := in.Close(); err != nil {
+			log.Errorf("Failed to close file %v: %v", opts.jsonfile, err)
+		}
+	}()
+
+	handler := &eventCollector{}
+	_, err = testjson.ScanTestOutput(testjson.ScanConfig{Stdout: in, Handler: handler})
+	if err != nil {
+		return fmt.Errorf("failed to scan testjson: %v", err)
+	}
+
+	tl := newTimeline(handler.events)
+	statsOut := opts.stdout
+	if opts.output == "-" {
+		// the trace is written to stdout
+		statsOut = os.Stderr
+	}
+	if err := writeTrace(opts.output, opts.stdout, tl); err != nil {
+		return fmt.Errorf("failed to write trace: %v", err)
+	}
+	printStats(statsOut, tl, opts.numTests)
+	return nil
+}
+
+type eventCollector struct {
+	events []testjson.TestEvent
+}
+
+func (h *eventCollector) Event(event testjson.TestEvent, _ *testjson.Execution) error {
+	if event.Action == testjson.ActionOutput || event.Time.IsZero() {
+		return nil
+	}
+	h.events = append(h.events, event)
+	return nil
+}
+
+func (h *eventCollector) Err(string) error {
+	return nil
+}
+
+func writeTrace(path string, stdout io.Writer, tl *timeline) error {
+	raw, err := json.Marshal(newTraceFile(tl))
+	if err != nil {
+		return err
+	}
+	if path == "-" {
+		_, err = stdout.Write(append(raw, '\n'))
+		return err
+	}
+	return ioutil.WriteFile(path, append(raw, '\n'), 0o644)
+}
+
+func jsonfileReader(v string, stdin io.Reader) (io.ReadCloser, error) {
+	switch v {
+	case "", "-":
+		return ioutil.NopCloser(stdin), nil
+	default:
+		return os.Open(v)
+	}
+}
@@ -0,0 +1,76 @@
+package timeline
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/env"
+	"gotest.tools/v3/fs"
+	"gotest.tools/v3/golden"
+)
+
+func TestUsage_WithFlagsFromSetupFlags(t *testing.T) {
+	defer env.PatchAll(t, nil)()
+
+	name := "gotestsum tool timeline"
+	flags, _ := setupFlags(name)
+	buf := new(bytes.Buffer)
+	usage(buf, name, flags)
+
+	golden.Assert(t, buf.String(), "cmd-flags-help-text")
+}
+
+func TestRun(t *testing.T) {
+	dir := fs.NewDir(t, t.Name())
+	out := new(bytes.Buffer)
+	opts := &options{
+		jsonfile: filepath.Join("testdata", "input.json"),
+		output:   dir.Join("timeline.json"),
+		numTests: 10,
+		stdout:   out,
+	}
+	assert.NilError(t, run(opts))
+	golden.Assert(t, out.String(), "expected-stats")
+
+	raw, err := ioutil.ReadFile(opts.output)
+	assert.NilError(t, err)
+	indented := new(bytes.Buffer)
+	assert.NilError(t, json.Indent(indented, raw, "", "  "))
+	golden.Assert(t, indented.String(), "expected-trace.json")
+}
+
+func TestNewTimeline_Lanes(t *testing.T) {
+	dir := fs.NewDir(t, t.Name())
+	out := new(bytes.Buffer)
+	opts := &options{
+		jsonfile: filepath.Join("testdata", "input.json"),
+		output:   dir.Join("timeline.json"),
+		stdout:   out,
+	}
+	assert.NilError(t, run(opts))
+
+	raw, err := ioutil.ReadFile(opts.output)
+	assert.NilError(t, err)
+	var file traceFile
+	assert.NilError(t, json.Unmarshal(raw, &file))
+
+	lanes := map[string][]int{}
+	for _, event := range file.TraceEvents {
+		if event.Ph == "X" {
+			lanes[event.Name] = append(lanes[event.Name], event.Tid)
+		}
+	}
+	// the parallel tests are paused until TestSequential ends, then run at
+	// the same time on different lanes.
+	assert.DeepEqual(t, lanes["TestSequential"], []int{1})
+	assert.DeepEqual(t, lanes["TestParallelOne"], []int{1})
+	assert.DeepEqual(t, lanes["TestParallelTwo"], []int{2})
+	// subtests are shown on a different lane than their parent
+	assert.DeepEqual(t, lanes["TestParent"], []int{1})
+	assert.DeepEqual(t, lanes["TestParent/sub_one"], []int{2})
+	assert.DeepEqual(t, lanes["TestParent/sub_two"], []int{2})
+}
@@ -0,0 +1,405 @@
+package timeline
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"gotest.tools/gotestsum/testjson"
+)
+
+// timeline of a test run, built from the TestEvents of the run.
+type timeline struct {
+	start time.Time
+	end   time.Time
+	// packages in the order they were first seen.
+	packages []string
+	tests    []*testRun
+}
+
+// testRun is a single run of a test.
+type testRun struct {
+	pkg    string
+	name   string
+	runID  int
+	result testjson.Action
+	// segments are the periods of time the test was running. A test that
+	// calls t.Parallel has a gap between segments while it is paused.
+	segments []segment
+	// parallel is true if the test was paused by t.Parallel.
+	parallel bool
+	// hasSubTests is true if another test in the run is a subtest of this test.
+	hasSubTests bool
+}
+
+type segment struct {
+	start time.Time
+	end   time.Time
+	// lane is the row used to show the segment, so that overlapping segments
+	// in the same package are shown on different rows.
+	lane int
+}
+
+// actionIncomplete is the result of a test which has no pass, fail, or skip
+// event, because the test binary exited before the test finished.
+const actionIncomplete testjson.Action = "incomplete"
+
+func (t *testRun) running() bool {
+	n := len(t.segments)
+	return n > 0 && t.segments[n-1].end.IsZero()
+}
+
+func (t *testRun) elapsed() time.Duration {
+	var d time.Duration
+	for _, seg := range t.segments {
+		d += seg.end.Sub(seg.start)
+	}
+	return d
+}
+
+func newTimeline(events []testjson.TestEvent) *timeline {
+	tl := &timeline{}
+	type key struct {
+		pkg   string
+		runID int
+		test  string
+	}
+	active := make(map[key]*testRun)
+	seenPkg := make(map[string]bool)
+
+	for _, event := range events {
+		if tl.start.IsZero() || event.Time.Before(tl.start) {
+			tl.start = event.Time
+		}
+		if event.Time.After(tl.end) {
+			tl.end = event.Time
+		}
+		if !seenPkg[event.Package] {
+			seenPkg[event.Package] = true
+			tl.packages = append(tl.packages, event.Package)
+		}
+
+		if event.PackageEvent() {
+			if !event.Action.IsTerminal() {
+				continue
+			}
+			// the package ended, any tests still running did not finish
+			for k, test := range active {
+				if k.pkg == event.Package && k.runID == event.RunID {
+					test.stop(event.Time)
+					test.result = actionIncomplete
+					delete(active, k)
+				}
+			}
+			continue
+		}
+
+		k := key{pkg: event.Package, runID: event.RunID, test: event.Test}
+		test, ok := active[k]
+		switch {
+		case event.Action == testjson.ActionRun:
+			test = &testRun{pkg: event.Package, name: event.Test, runID: event.RunID}
+			test.segments = append(test.segments, segment{start: event.Time})
+			active[k] = test
+			tl.tests = append(tl.tests, test)
+		case !ok:
+			continue
+		case event.Action == testjson.ActionPause:
+			test.stop(event.Time)
+			test.parallel = true
+		case event.Action == testjson.ActionCont:
+			if !test.running() {
+				test.segments = append(test.segments, segment{start: event.Time})
+			}
+		case event.Action.IsTerminal():
+			test.stop(event.Time)
+			test.result = event.Action
+			delete(active, k)
+		}
+	}
+	for _, test := range active {
+		test.stop(tl.end)
+		test.result = actionIncomplete
+	}
+
+	markParents(tl.tests)
+	assignLanes(tl)
+	return tl
+}
+
+func (t *testRun) stop(at time.Time) {
+	if !t.running() {
+		return
+	}
+	last := len(t.segments) - 1
+	// t.Parallel is often the first statement in a test, so the test is
+	// paused as soon as it starts. Drop the empty segment.
+	if !at.After(t.segments[last].start) {
+		t.segments = t.segments[:last]
+		return
+	}
+	t.segments[last].end = at
+}
+
+func markParents(tests []*testRun) {
+	type key struct {
+		pkg   string
+		runID int
+		test  string
+	}
+	byName := make(map[key]*testRun, len(tests))
+	for _, test := range tests {
+		byName[key{test.pkg, test.runID, test.name}] = test
+	}
+	for _, test := range tests {
+		parent := testjson.TestName(test.name).Parent()
+		if p, ok := byName[key{test.pkg, test.runID, parent}]; ok && parent != "" {
+			p.hasSubTests = true
+		}
+	}
+}
+
+// assignLanes places each segment in the lowest numbered lane of its package
+// which is not used by another segment at the same time.
+func assignLanes(tl *timeline) {
+	byPkg := make(map[string][]*segment)
+	for _, test := range tl.tests {
+		for i := range test.segments {
+			byPkg[test.pkg] = append(byPkg[test.pkg], &test.segments[i])
+		}
+	}
+	for _, segments := range byPkg {
+		sort.SliceStable(segments, func(i, j int) bool {
+			return segments[i].start.Before(segments[j].start)
+		})
+		var laneEnd []time.Time
+		for _, seg := range segments {
+			lane := 0
+			for ; lane < len(laneEnd); lane++ {
+				if !laneEnd[lane].After(seg.start) {
+					break
+				}
+			}
+			if lane == len(laneEnd) {
+				laneEnd = append(laneEnd, time.Time{})
+			}
+			laneEnd[lane] = seg.end
+			seg.lane = lane
+		}
+	}
+}
+
+// traceFile is the JSON Object Format of the Trace Event Format, documented
+// at https://docs.google.com/document/d/1CvAClvFfyA5R-PhYUmn5OOQtYMH4h6I0nSsKchNAySU
+type traceFile struct {
+	TraceEvents     []traceEvent `json:"traceEvents"`
+	DisplayTimeUnit string       `json:"displayTimeUnit"`
+}
+
+type traceEvent struct {
+	Name string `json:"name"`
+	Cat  string `json:"cat,omitempty"`
+	Ph   string `json:"ph"`
+	// Ts and Dur are in microseconds.
+	Ts    float64                `json:"ts"`
+	Dur   float64                `json:"dur,omitempty"`
+	Pid   int                    `json:"pid"`
+	Tid   int                    `json:"tid"`
+	Cname string                 `json:"cname,omitempty"`
+	Args  map[string]interface{} `json:"args,omitempty"`
+}
+
+func newTraceFile(tl *timeline) traceFile {
+	pids := make(map[string]int, len(tl.packages))
+	file := traceFile{DisplayTimeUnit: "ms", TraceEvents: []traceEvent{}}
+	for i, pkg := range tl.packages {
+		pid := i + 1
+		pids[pkg] = pid
+		file.TraceEvents = append(file.TraceEvents,
+			traceEvent{
+				Name: "process_name", Ph: "M", Pid: pid,
+				Args: map[string]interface{}{"name": pkg},
+			},
+			traceEvent{
+				Name: "process_sort_index", Ph: "M", Pid: pid,
+				Args: map[string]interface{}{"sort_index": pid},
+			})
+	}
+
+	micros := func(d time.Duration) float64 {
+		return float64(d) / float64(time.Microsecond)
+	}
+	for _, test := range tl.tests {
+		for _, seg := range test.segments {
+			args := map[string]interface{}{
+				"result":   string(test.result),
+				"parallel": test.parallel,
+			}
+			if test.runID > 0 {
+				args["runID"] = test.runID
+			}
+			file.TraceEvents = append(file.TraceEvents, traceEvent{
+				Name:  test.name,
+				Cat:   "test",
+				Ph:    "X",
+				Ts:    micros(seg.start.Sub(tl.start)),
+				Dur:   micros(seg.end.Sub(seg.start)),
+				Pid:   pids[test.pkg],
+				Tid:   seg.lane + 1,
+				Cname: colorName(test.result),
+				Args:  args,
+			})
+		}
+	}
+	return file
+}
+
+// colorName returns one of the reserved color names of the trace viewer.
+func colorName(result testjson.Action) string {
+	switch result {
+	case testjson.ActionPass:
+		return "good"
+	case testjson.ActionFail:
+		return "terrible"
+	case actionIncomplete:
+		return "bad"
+	default:
+		return "grey"
+	}
+}
+
+type stats struct {
+	wall     time.Duration
+	testTime time.Duration
+	// idle is the time when no tests were running.
+	idle time.Duration
+	// serial is the time when exactly one test was running.
+	serial         time.Duration
+	maxParallelism int
+}
+
+// newStats returns the parallelism statistics of the timeline. Only tests
+// without subtests are counted, because a parent test is running for as long
+// as any of its subtests.
+func newStats(tl *timeline) stats {
+	type point struct {
+		at    time.Time
+		delta int
+	}
+	var points []point
+	s := stats{wall: tl.end.Sub(tl.start)}
+	for _, test := range tl.tests {
+		if test.hasSubTests {
+			continue
+		}
+		for _, seg := range test.segments {
+			points = append(points, point{seg.start, 1}, point{seg.end, -1})
+			s.testTime += seg.end.Sub(seg.start)
+		}
+	}
+	sort.SliceStable(points, func(i, j int) bool {
+		if points[i].at.Equal(points[j].at) {
+			return points[i].delta < points[j].delta
+		}
+		return points[i].at.Before(points[j].at)
+	})
+
+	running := 0
+	last := tl.start
+	for _, p := range points {
+		d := p.at.Sub(last)
+		switch running {
+		case 0:
+			s.idle += d
+		case 1:
+			s.serial += d
+		}
+		running += p.delta
+		if running > s.maxParallelism {
+			s.maxParallelism = running
+		}
+		last = p.at
+	}
+	s.idle += tl.end.Sub(last)
+	return s
+}
+
+func (s stats) avgParallelism() float64 {
+	if s.wall == 0 {
+		return 0
+	}
+	return float64(s.testTime) / float64(s.wall)
+}
+
+func percent(d, total time.Duration) float64 {
+	if total == 0 {
+		return 0
+	}
+	return 100 * float64(d) / float64(total)
+}
+
+func printStats(out io.Writer, tl *timeline, num int) {
+	s := newStats(tl)
+	fmt.Fprintf(out, "Wall time:           %v\n", formatDuration(s.wall))
+	fmt.Fprintf(out, "Test time:           %v\n", formatDuration(s.testTime))
+	fmt.Fprintf(out, "Average parallelism: %.2f\n", s.avgParallelism())
+	fmt.Fprintf(out, "Max parallelism:     %d\n", s.maxParallelism)
+	fmt.Fprintf(out, "Serial time:         %v (%.1f%%) with only one test running\n",
+		formatDuration(s.serial), percent(s.serial, s.wall))
+	fmt.Fprintf(out, "Idle time:           %v (%.1f%%) with no tests running\n",
+		formatDuration(s.idle), percent(s.idle, s.wall))
+
+	slowest := slowestSequential(tl, num)
+	if len(slowest) == 0 {
+		return
+	}
+	fmt.Fprintf(out, "\nSlowest tests which did not call t.Parallel:\n")
+	for _, test := range slowest {
+		fmt.Fprintf(out, "%10v  %s %s\n",
+			formatDuration(test.elapsed()), testjson.RelativePackagePath(test.pkg), test.name)
+	}
+}
+
+// slowestSequential returns the num slowest tests which were not paused by
+// t.Parallel, and have no subtests that were.
+func slowestSequential(tl *timeline, num int) []*testRun {
+	parallelParents := make(map[string]bool)
+	for _, test := range tl.tests {
+		if !test.parallel {
+			continue
+		}
+		name := test.name
+		for {
+			name = testjson.TestName(name).Parent()
+			if name == "" {
+				break
+			}
+			parallelParents[test.pkg+"."+name] = true
+		}
+	}
+
+	var result []*testRun
+	for _, test := range tl.tests {
+		if test.parallel || parallelParents[test.pkg+"."+test.name] {
+			continue
+		}
+		// subtests of a sequential test are already included in its elapsed time
+		if strings.Contains(test.name, "/") {
+			continue
+		}
+		result = append(result, test)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].elapsed() > result[j].elapsed()
+	})
+	if num >= 0 && len(result) > num {
+		result = result[:num]
+	}
+	return result
+}
+
+func formatDuration(d time.Duration) string {
+	return testjson.FormatDurationAsSeconds(d, 3)
+}
@@ -7,8 +7,10 @@ import (
 	"io/ioutil"
 	"os"
 	"os/exec"
+	"sync"
 
 	"gotest.tools/gotestsum/internal/filewatcher"
+	"gotest.tools/gotestsum/runner"
 	"gotest.tools/gotestsum/testjson"
 )
 
@@ -17,17 +19,37 @@ func runWatcher(opts *options) error {
 	defer cancel()
 
 	w := &watchRuns{opts: *opts}
-	return filewatcher.Watch(ctx, opts.packages, w.run)
+
+	var requests chan filewatcher.Request
+	if opts.watchListen != "" {
+		srv := newControlServer(w.lastExec)
+		stop, err := srv.serve(opts.watchListen)
+		if err != nil {
+			return err
+		}
+		defer stop()
+		requests = srv.requests
+	}
+	return filewatcher.Watch(ctx, opts.packages, w.run, requests)
 }
 
 type watchRuns struct {
-	opts     options
+	opts options
+
+	// lock guards prevExec, which is read by the control server.
+	lock     sync.Mutex
 	prevExec *testjson.Execution
 }
 
+func (w *watchRuns) lastExec() *testjson.Execution {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return w.prevExec
+}
+
 func (w *watchRuns) run(event filewatcher.Event) error {
 	if event.Debug {
-		path, cleanup, err := delveInitFile(w.prevExec)
+		path, cleanup, err := delveInitFile(w.lastExec())
 		if err != nil {
 			return fmt.Errorf("failed to write delve init file: %w", err)
 		}
@@ -53,47 +75,74 @@ func (w *watchRuns) run(event filewatcher.Event) error {
 	opts.packages = append(opts.packages, event.PkgPath)
 	opts.packages = append(opts.packages, event.Args...)
 
-	var err error
-	if w.prevExec, err = runSingle(&opts, dir); !IsExitCoder(err) {
+	runs := []runner.Selection{{Run: event.RunFilter}}
+	if event.FailedOnly {
+		runs = selectionsForFailed(w.lastExec())
+		if len(runs) == 0 {
+			fmt.Println("No tests failed in the previous run.")
+			return nil
+		}
+	}
+
+	exec, err := runSingle(&opts, dir, runs)
+	w.lock.Lock()
+	w.prevExec = exec
+	w.lock.Unlock()
+	if !IsExitCoder(err) {
 		return err
 	}
 	return nil
 }
 
-// runSingle is similar to run. It doesn't support rerun-fails. It may be
-// possible to share runSingle with run, but the defer close on the handler
-// would require at least 3 return values, so for now it is a copy.
-func runSingle(opts *options, dir string) (*testjson.Execution, error) {
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+// selectionsForFailed returns the Selection used to run each of the tests that
+// failed in exec. Packages that failed without any failed tests are run
+// without a -run flag.
+func selectionsForFailed(exec *testjson.Execution) []runner.Selection {
+	var result []runner.Selection // nolint: prealloc
+	for _, tc := range testjson.FilterFailedUnique(exec.Failed()) {
+		if tc.Test == "" {
+			result = append(result, runner.Selection{Package: tc.Package})
+			continue
+		}
+		result = append(result, runner.SelectionForTestCase(tc))
+	}
+	return result
+}
 
+// runSingle is similar to run, but it doesn't support rerun-fails. A
+// 'go test' process is started for each item in runs, and the results are
+// collected into a single Execution.
+func runSingle(opts *options, dir string, runs []runner.Selection) (*testjson.Execution, error) {
 	if err := opts.Validate(); err != nil {
 		return nil, err
 	}
+	if err := preRunHook(opts, dir); err != nil {
+		return nil, fmt.Errorf("pre run command failed: %w", err)
+	}
 
-	goTestProc, err := startGoTestFn(ctx, dir, goTestCmdArgs(opts, rerunOpts{}))
+	formatter, err := newFormatter(opts)
 	if err != nil {
 		return nil, err
 	}
-
 	handler, err := newEventHandler(opts)
 	if err != nil {
 		return nil, err
 	}
 	defer handler.Close() // nolint: errcheck
-	cfg := testjson.ScanConfig{
-		Stdout:  goTestProc.stdout,
-		Stderr:  goTestProc.stderr,
-		Handler: handler,
-		Stop:    cancel,
-	}
-	exec, err := testjson.ScanTestOutput(cfg)
+
+	cfg := runnerConfig(opts)
+	cfg.Dir = dir
+	cfg.Selections = runs
+	cfg.RerunFails = runner.RerunFailsConfig{}
+	cfg.Formatter = formatter
+	cfg.Handlers = []testjson.EventHandler{handler}
+	exec, exitErr := runner.Run(context.Background(), cfg)
 	handler.Flush()
-	if err != nil {
-		return exec, finishRun(opts, exec, err)
+	finishFormatter(formatter, exec, exitErr)
+	if exec == nil {
+		return nil, exitErr
 	}
-	err = goTestProc.cmd.Wait()
-	return exec, finishRun(opts, exec, err)
+	return exec, finishRun(opts, exec, exitErr)
 }
 
 func delveInitFile(exec *testjson.Execution) (string, func(), error) {
@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"testing"
+
+	"gotest.tools/gotestsum/runner"
+	"gotest.tools/v3/assert"
+)
+
+func TestSelectionsForFailed(t *testing.T) {
+	exec := newExecFromTestData(t)
+	actual := selectionsForFailed(exec)
+	assert.Equal(t, len(actual), 11)
+
+	// package failed without any failed tests
+	assert.Equal(t, actual[0], runner.Selection{Package: "gotest.tools/gotestsum/testjson/internal/badmain"})
+	assert.Equal(t, actual[1], runner.Selection{
+		Run:     "^TestParallelTheFirst$",
+		Package: "gotest.tools/gotestsum/testjson/internal/parallelfails",
+	})
+	// only the failed subtest is run, not the parent test
+	assert.Equal(t, actual[10], runner.Selection{
+		Run:     "^TestNestedWithFailure$/^c$",
+		Package: "gotest.tools/gotestsum/testjson/internal/withfails",
+	})
+}
+
+func TestSelectionsForFailed_NoPreviousRun(t *testing.T) {
+	assert.Equal(t, len(selectionsForFailed(nil)), 0)
+}
@@ -0,0 +1,202 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"gotest.tools/gotestsum/internal/log"
+	"gotest.tools/gotestsum/internal/notify"
+	"gotest.tools/gotestsum/testjson"
+)
+
+const webhookFormats = "json, slack, mattermost, teams"
+
+// webhookMaxFailures is the maximum number of failed tests listed in a chat
+// message, to keep the message readable.
+const webhookMaxFailures = 10
+
+// webhookAttempts is the number of times a request is sent before giving up.
+const webhookAttempts = 3
+
+// webhookBackoff is the time to wait before the first retry. The time doubles
+// for each retry. It is a variable so that tests can make it shorter.
+var webhookBackoff = time.Second
+
+func validWebhookFormat(format string) bool {
+	switch format {
+	case "", "json", "slack", "mattermost", "teams":
+		return true
+	}
+	return false
+}
+
+// sendWebhook posts a summary of the run to --webhook-url. Errors are logged
+// instead of returned, so that a failed request does not change the exit code.
+func sendWebhook(opts *options, exec *testjson.Execution) {
+	if opts.webhookURL == "" {
+		return
+	}
+	payload, err := newWebhookPayload(opts, exec)
+	if err != nil {
+		log.Warnf("failed to create webhook payload: %v", err)
+		return
+	}
+
+	ctx := context.Background()
+	if opts.webhookTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.webhookTimeout)
+		defer cancel()
+	}
+	if err := postWithRetry(ctx, opts.webhookURL, payload); err != nil {
+		log.Warnf("failed to send webhook: %v", err)
+	}
+}
+
+func newWebhookPayload(opts *options, exec *testjson.Execution) ([]byte, error) {
+	switch opts.webhookFormat {
+	case "slack", "mattermost":
+		text, err := webhookMessage(opts, exec, webhookText)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(map[string]string{"text": text})
+	case "teams":
+		n := notify.NewNotification(exec)
+		text, err := webhookMessage(opts, exec, func(exec *testjson.Execution) string {
+			return webhookDetails(exec, n)
+		})
+		if err != nil {
+			return nil, err
+		}
+		color := "2EB67D"
+		if n.Failed {
+			color = "E01E5A"
+		}
+		return json.Marshal(map[string]string{
+			"@type":      "MessageCard",
+			"@context":   "https://schema.org/extensions",
+			"summary":    n.Title,
+			"themeColor": color,
+			"title":      n.Title,
+			"text":       strings.ReplaceAll(text, "\n", "\n\n"),
+		})
+	default:
+		return json.Marshal(newJSONSummary(exec))
+	}
+}
+
+// webhookTemplateData is the data used to execute --webhook-template. The
+// methods of the Execution can be used directly in the template,
+// ex: {{len .Failed}}.
+type webhookTemplateData struct {
+	*testjson.Execution
+	// Title and Message are the same as the title and message of --notify.
+	Title   string
+	Message string
+}
+
+// webhookMessage returns the text of a chat message from --webhook-template,
+// or from defaultText when there is no template.
+func webhookMessage(
+	opts *options,
+	exec *testjson.Execution,
+	defaultText func(*testjson.Execution) string,
+) (string, error) {
+	if opts.webhookTemplate == "" {
+		return defaultText(exec), nil
+	}
+	tmpl, err := testjson.ParseTemplate(opts.webhookTemplate)
+	if err != nil {
+		return "", err
+	}
+	n := notify.NewNotification(exec)
+	buf := new(strings.Builder)
+	data := webhookTemplateData{Execution: exec, Title: n.Title, Message: n.Message}
+	if err := tmpl.Execute(buf, data); err != nil {
+		return "", fmt.Errorf("failed to execute webhook template: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// webhookText returns the text of a chat message in the Markdown dialect
+// accepted by both Slack and Mattermost.
+func webhookText(exec *testjson.Execution) string {
+	n := notify.NewNotification(exec)
+	return n.Title + "\n" + webhookDetails(exec, n)
+}
+
+// webhookDetails returns the counts, elapsed time, and the list of failed tests.
+func webhookDetails(exec *testjson.Execution, n notify.Notification) string {
+	buf := new(strings.Builder)
+	fmt.Fprintf(buf, "%s in %s\n", n.Message, testjson.FormatDurationAsSeconds(exec.Elapsed(), 3))
+
+	failed := exec.Failed()
+	for i, tc := range failed {
+		if i == webhookMaxFailures {
+			fmt.Fprintf(buf, "… and %d more\n", len(failed)-i)
+			break
+		}
+		name := testjson.RelativePackagePath(tc.Package)
+		if tc.Test != "" {
+			name += "." + tc.Test.Name()
+		}
+		fmt.Fprintf(buf, "• `%s`\n", name)
+	}
+	return buf.String()
+}
+
+// postWithRetry sends payload to url, and retries with an exponential backoff
+// when the request fails, or the server responds with a 5xx or 429 status.
+func postWithRetry(ctx context.Context, url string, payload []byte) error {
+	backoff := webhookBackoff
+	var err error
+	for attempt := 1; ; attempt++ {
+		var retry bool
+		retry, err = post(ctx, url, payload)
+		if err == nil || !retry || attempt == webhookAttempts {
+			return err
+		}
+		log.Debugf("webhook attempt %d failed, retrying in %v: %v", attempt, backoff, err)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// post sends a single request. The returned bool is true when the request
+// should be retried.
+func post(ctx context.Context, url string, payload []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "gotestsum/"+version)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return ctx.Err() == nil, err
+	}
+	defer resp.Body.Close() // nolint: errcheck
+	_, _ = io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 4096))
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return false, nil
+	case resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests:
+		return true, fmt.Errorf("server responded with %v", resp.Status)
+	default:
+		return false, fmt.Errorf("server responded with %v", resp.Status)
+	}
+}
@@ -0,0 +1,184 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+type webhookRecorder struct {
+	mu       sync.Mutex
+	bodies   []string
+	statuses []int
+}
+
+func (r *webhookRecorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	body, _ := ioutil.ReadAll(req.Body)
+	r.bodies = append(r.bodies, string(body))
+	status := http.StatusOK
+	if len(r.statuses) > 0 {
+		status, r.statuses = r.statuses[0], r.statuses[1:]
+	}
+	w.WriteHeader(status)
+}
+
+func setShortWebhookBackoff(t *testing.T) {
+	orig := webhookBackoff
+	webhookBackoff = time.Millisecond
+	t.Cleanup(func() {
+		webhookBackoff = orig
+	})
+}
+
+func TestSendWebhook_JSON(t *testing.T) {
+	rec := &webhookRecorder{}
+	srv := httptest.NewServer(rec)
+	defer srv.Close()
+
+	opts := &options{webhookURL: srv.URL, webhookFormat: "json"}
+	sendWebhook(opts, newExecFromTestData(t))
+
+	assert.Equal(t, len(rec.bodies), 1)
+	var summary jsonSummary
+	assert.NilError(t, json.Unmarshal([]byte(rec.bodies[0]), &summary))
+	assert.Equal(t, summary.Status, statusFail)
+	assert.Equal(t, summary.Total, 59)
+	assert.Equal(t, summary.Failed, 13)
+}
+
+func TestSendWebhook_Slack(t *testing.T) {
+	rec := &webhookRecorder{}
+	srv := httptest.NewServer(rec)
+	defer srv.Close()
+
+	opts := &options{webhookURL: srv.URL, webhookFormat: "slack"}
+	sendWebhook(opts, newExecFromTestData(t))
+
+	assert.Equal(t, len(rec.bodies), 1)
+	var payload map[string]string
+	assert.NilError(t, json.Unmarshal([]byte(rec.bodies[0]), &payload))
+	text := payload["text"]
+	assert.Assert(t, strings.HasPrefix(text, "❌ Failed\n59 Tests Run, 13 Failed, 5 Skipped in "), text)
+	assert.Assert(t, is.Contains(text, "• `testjson/internal/badmain`\n"))
+	assert.Assert(t, is.Contains(text, "… and 3 more\n"))
+}
+
+func TestSendWebhook_Teams(t *testing.T) {
+	rec := &webhookRecorder{}
+	srv := httptest.NewServer(rec)
+	defer srv.Close()
+
+	opts := &options{webhookURL: srv.URL, webhookFormat: "teams"}
+	sendWebhook(opts, newExecFromTestData(t))
+
+	assert.Equal(t, len(rec.bodies), 1)
+	var payload map[string]string
+	assert.NilError(t, json.Unmarshal([]byte(rec.bodies[0]), &payload))
+	assert.Equal(t, payload["@type"], "MessageCard")
+	assert.Equal(t, payload["title"], "❌ Failed")
+	assert.Equal(t, payload["themeColor"], "E01E5A")
+}
+
+func TestSendWebhook_SlackWithTemplate(t *testing.T) {
+	rec := &webhookRecorder{}
+	srv := httptest.NewServer(rec)
+	defer srv.Close()
+
+	opts := &options{
+		webhookURL:      srv.URL,
+		webhookFormat:   "slack",
+		webhookTemplate: `{{.Title}} nightly: {{len .Failed}} of {{.Total}} failed`,
+	}
+	sendWebhook(opts, newExecFromTestData(t))
+
+	assert.Equal(t, len(rec.bodies), 1)
+	var payload map[string]string
+	assert.NilError(t, json.Unmarshal([]byte(rec.bodies[0]), &payload))
+	assert.Equal(t, payload["text"], "❌ Failed nightly: 13 of 59 failed")
+}
+
+func TestSendWebhook_TeamsWithTemplate(t *testing.T) {
+	rec := &webhookRecorder{}
+	srv := httptest.NewServer(rec)
+	defer srv.Close()
+
+	opts := &options{
+		webhookURL:      srv.URL,
+		webhookFormat:   "teams",
+		webhookTemplate: "{{.Message}}\n{{range .Failed}}{{.Test}}{{end}}",
+	}
+	exec := newExecFromTestData(t)
+	sendWebhook(opts, exec)
+
+	assert.Equal(t, len(rec.bodies), 1)
+	var payload map[string]string
+	assert.NilError(t, json.Unmarshal([]byte(rec.bodies[0]), &payload))
+	assert.Equal(t, payload["title"], "❌ Failed")
+	assert.Assert(t, strings.HasPrefix(payload["text"], "59 Tests Run, 13 Failed, 5 Skipped\n\n"),
+		payload["text"])
+}
+
+func TestSendWebhook_RetriesServerErrors(t *testing.T) {
+	setShortWebhookBackoff(t)
+	rec := &webhookRecorder{
+		statuses: []int{http.StatusBadGateway, http.StatusTooManyRequests, http.StatusOK},
+	}
+	srv := httptest.NewServer(rec)
+	defer srv.Close()
+
+	err := postWithRetry(context.Background(), srv.URL, []byte(`{}`))
+	assert.NilError(t, err)
+	assert.Equal(t, len(rec.bodies), 3)
+}
+
+func TestSendWebhook_GivesUpAfterMaxAttempts(t *testing.T) {
+	setShortWebhookBackoff(t)
+	rec := &webhookRecorder{
+		statuses: []int{500, 500, 500, 500},
+	}
+	srv := httptest.NewServer(rec)
+	defer srv.Close()
+
+	err := postWithRetry(context.Background(), srv.URL, []byte(`{}`))
+	assert.ErrorContains(t, err, "server responded with 500")
+	assert.Equal(t, len(rec.bodies), webhookAttempts)
+}
+
+func TestSendWebhook_DoesNotRetryClientErrors(t *testing.T) {
+	setShortWebhookBackoff(t)
+	rec := &webhookRecorder{statuses: []int{http.StatusNotFound}}
+	srv := httptest.NewServer(rec)
+	defer srv.Close()
+
+	err := postWithRetry(context.Background(), srv.URL, []byte(`{}`))
+	assert.ErrorContains(t, err, "server responded with 404")
+	assert.Equal(t, len(rec.bodies), 1)
+}
+
+func TestSendWebhook_Timeout(t *testing.T) {
+	done := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-done:
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+	defer close(done)
+
+	opts := &options{webhookURL: srv.URL, webhookTimeout: 50 * time.Millisecond}
+	start := time.Now()
+	sendWebhook(opts, newExecFromTestData(t))
+	assert.Assert(t, time.Since(start) < 5*time.Second)
+}
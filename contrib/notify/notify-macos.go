@@ -1,3 +1,7 @@
+// Command notify sends a macOS notification using terminal-notifier, when used
+// as a gotestsum --post-run-command.
+//
+// Deprecated: use gotestsum --notify instead.
 package main
 
 import (
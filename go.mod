@@ -1,6 +1,7 @@
 module gotest.tools/gotestsum
 
 require (
+	github.com/BurntSushi/toml v1.2.1
 	github.com/dnephin/pflag v1.0.7
 	github.com/fatih/color v1.13.0
 	github.com/fsnotify/fsnotify v1.5.4
@@ -11,6 +12,7 @@ require (
 	golang.org/x/sys v0.0.0-20220715151400-c0bba94af5f8
 	golang.org/x/term v0.0.0-20220526004731-065cf7ba2467
 	golang.org/x/tools v0.1.11
+	gopkg.in/yaml.v3 v3.0.1
 	gotest.tools/v3 v3.3.0
 )
 
@@ -24,7 +24,15 @@ func Slowest(exec *testjson.Execution, threshold time.Duration, num int) []testj
 		tests = append(tests, pkgTests...)
 	}
 	sort.Slice(tests, func(i, j int) bool {
-		return tests[i].Elapsed > tests[j].Elapsed
+		a, b := tests[i], tests[j]
+		if a.Elapsed != b.Elapsed {
+			return a.Elapsed > b.Elapsed
+		}
+		// sort by name when elapsed is equal so the order is stable
+		if a.Package != b.Package {
+			return a.Package < b.Package
+		}
+		return a.Test < b.Test
 	})
 	if num >= len(tests) {
 		return tests
@@ -0,0 +1,224 @@
+/*
+Package config reads the gotestsum project configuration file.
+
+The file may be written in YAML (.gotestsum.yaml or .gotestsum.yml) or TOML
+(.gotestsum.toml). Each key is the name of a flag, with a string, number,
+boolean, or list value. The profiles table contains a table of keys for each
+profile.
+
+	format: testname
+	packages: [./...]
+	profiles:
+	  ci:
+	    junitfile: junit.xml
+	    rerun-fails: 2
+*/
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FileNames are the names of the configuration file, in the order they are
+// searched for in each directory.
+var FileNames = []string{".gotestsum.yaml", ".gotestsum.yml", ".gotestsum.toml"}
+
+// File is a configuration file.
+type File struct {
+	// Path to the file.
+	Path string
+	// Values maps the name of a flag to its values. Scalar values are stored
+	// as a slice with a single item.
+	Values map[string][]string
+	// Profiles maps the name of a profile to the values of the profile.
+	Profiles map[string]map[string][]string
+}
+
+// ProfileValues returns the values of the file, with the values from profile
+// replacing the top-level values of the same name. It returns an error if the
+// profile does not exist.
+func (f *File) ProfileValues(profile string) (map[string][]string, error) {
+	result := make(map[string][]string, len(f.Values))
+	for key, value := range f.Values {
+		result[key] = value
+	}
+	if profile == "" {
+		return result, nil
+	}
+	values, ok := f.Profiles[profile]
+	if !ok {
+		return nil, fmt.Errorf("profile %q not found in %v, must be one of: %v",
+			profile, f.Path, strings.Join(f.profileNames(), ", "))
+	}
+	for key, value := range values {
+		result[key] = value
+	}
+	return result, nil
+}
+
+func (f *File) profileNames() []string {
+	names := make([]string, 0, len(f.Profiles))
+	for name := range f.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Find searches for a configuration file in dir, and each parent of dir,
+// stopping at the root of the Go module (the directory which contains a
+// go.mod file). Find returns nil if no file is found.
+func Find(dir string) (*File, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		path, err := findInDir(dir)
+		switch {
+		case err != nil:
+			return nil, err
+		case path != "":
+			return Load(path)
+		}
+		if isModuleRoot(dir) {
+			return nil, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, nil
+		}
+		dir = parent
+	}
+}
+
+func findInDir(dir string) (string, error) {
+	var found []string
+	for _, name := range FileNames {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			found = append(found, path)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return "", nil
+	case 1:
+		return found[0], nil
+	default:
+		return "", fmt.Errorf("found more than one configuration file: %v",
+			strings.Join(found, ", "))
+	}
+}
+
+func isModuleRoot(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, "go.mod"))
+	return err == nil
+}
+
+// Load reads and parses the configuration file at path.
+func Load(path string) (*File, error) {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var doc map[string]interface{}
+	switch filepath.Ext(path) {
+	case ".toml":
+		doc, err = parseTOML(raw)
+	default:
+		doc, err = parseYAML(raw)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %v: %w", path, err)
+	}
+	f, err := newFile(doc)
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration in %v: %w", path, err)
+	}
+	f.Path = path
+	return f, nil
+}
+
+func newFile(doc map[string]interface{}) (*File, error) {
+	f := &File{Profiles: make(map[string]map[string][]string)}
+	var err error
+	profiles, hasProfiles := doc["profiles"]
+	delete(doc, "profiles")
+	if f.Values, err = toValues(doc); err != nil {
+		return nil, err
+	}
+	if !hasProfiles {
+		return f, nil
+	}
+
+	table, ok := profiles.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("profiles must be a table of profiles")
+	}
+	for name, profile := range table {
+		values, ok := profile.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("profile %v must be a table of options", name)
+		}
+		if f.Profiles[name], err = toValues(values); err != nil {
+			return nil, fmt.Errorf("profile %v: %w", name, err)
+		}
+	}
+	return f, nil
+}
+
+func toValues(doc map[string]interface{}) (map[string][]string, error) {
+	result := make(map[string][]string, len(doc))
+	for key, value := range doc {
+		switch v := value.(type) {
+		case nil:
+			continue
+		case []interface{}:
+			items := make([]string, 0, len(v))
+			for _, item := range v {
+				s, ok := scalarString(item)
+				if !ok {
+					return nil, fmt.Errorf("option %v must be a list of values", key)
+				}
+				items = append(items, s)
+			}
+			result[key] = items
+		default:
+			s, ok := scalarString(v)
+			if !ok {
+				return nil, fmt.Errorf("option %v must be a value or a list of values", key)
+			}
+			result[key] = []string{s}
+		}
+	}
+	return result, nil
+}
+
+// scalarString returns the value as it would be written in a flag. It returns
+// false if the value is a table or a list.
+func scalarString(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case string:
+		return v, true
+	case bool:
+		return strconv.FormatBool(v), true
+	case int64:
+		return strconv.FormatInt(v, 10), true
+	case uint64:
+		return strconv.FormatUint(v, 10), true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case time.Time:
+		return v.Format(time.RFC3339Nano), true
+	default:
+		return "", false
+	}
+}
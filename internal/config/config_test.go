@@ -0,0 +1,256 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+)
+
+const yamlConfig = `
+# shared by all profiles
+format: testname
+packages: [./cmd/..., "./internal/..."]
+post-run-command:
+  - notify-send "tests done"   # quoted
+  - 'echo #1'
+rerun-fails: 2
+profiles:
+  ci:
+    format: pkgname
+    junitfile: junit.xml
+    hide-summary:
+    - skipped
+  local:
+    watch: true
+`
+
+const tomlConfig = `
+# shared by all profiles
+format = "testname"
+packages = ["./cmd/...", './internal/...']
+post-run-command = ['notify-send "tests done"', "echo #1"] # quoted
+rerun-fails = 2
+
+[profiles.ci]
+format = "pkgname"
+junitfile = "junit.xml"
+hide-summary = ["skipped"]
+
+[profiles.local]
+watch = true
+`
+
+func TestLoad(t *testing.T) {
+	dir := fs.NewDir(t, t.Name(),
+		fs.WithFile(".gotestsum.yaml", yamlConfig),
+		fs.WithDir("toml", fs.WithFile(".gotestsum.toml", tomlConfig)))
+
+	expected := &File{
+		Values: map[string][]string{
+			"format":           {"testname"},
+			"packages":         {"./cmd/...", "./internal/..."},
+			"post-run-command": {`notify-send "tests done"`, "echo #1"},
+			"rerun-fails":      {"2"},
+		},
+		Profiles: map[string]map[string][]string{
+			"ci": {
+				"format":       {"pkgname"},
+				"junitfile":    {"junit.xml"},
+				"hide-summary": {"skipped"},
+			},
+			"local": {"watch": {"true"}},
+		},
+	}
+
+	for _, path := range []string{
+		dir.Join(".gotestsum.yaml"),
+		dir.Join("toml", ".gotestsum.toml"),
+	} {
+		t.Run(filepath.Ext(path), func(t *testing.T) {
+			f, err := Load(path)
+			assert.NilError(t, err)
+			expected.Path = path
+			assert.DeepEqual(t, f, expected)
+		})
+	}
+}
+
+func TestLoad_Values(t *testing.T) {
+	type testCase struct {
+		name     string
+		file     string
+		content  string
+		expected map[string][]string
+	}
+	var testCases = []testCase{
+		{
+			name:     "yaml single quoted string with a quote",
+			file:     ".gotestsum.yaml",
+			content:  "post-run-command: 'echo it''s done'\n",
+			expected: map[string][]string{"post-run-command": {"echo it's done"}},
+		},
+		{
+			name:     "yaml flow list on several lines",
+			file:     ".gotestsum.yaml",
+			content:  "packages: [\n  ./cmd/...,\n  ./internal/...,\n]\n",
+			expected: map[string][]string{"packages": {"./cmd/...", "./internal/..."}},
+		},
+		{
+			name:     "yaml 1.1 booleans",
+			file:     ".gotestsum.yaml",
+			content:  "watch: yes\nno-color: off\nformat: 'yes'\n",
+			expected: map[string][]string{"watch": {"true"}, "no-color": {"false"}, "format": {"yes"}},
+		},
+		{
+			name:     "yaml null value",
+			file:     ".gotestsum.yaml",
+			content:  "format:\njunitfile: junit.xml\n",
+			expected: map[string][]string{"junitfile": {"junit.xml"}},
+		},
+		{
+			name:    "toml numbers, booleans, and dates",
+			file:    ".gotestsum.toml",
+			content: "rerun-fails = 2\nwatch = true\ntimeout = 1.5\ndate = 2024-01-02T10:00:00Z\n",
+			expected: map[string][]string{
+				"rerun-fails": {"2"},
+				"watch":       {"true"},
+				"timeout":     {"1.5"},
+				"date":        {"2024-01-02T10:00:00Z"},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := fs.NewDir(t, t.Name(), fs.WithFile(tc.file, tc.content))
+			f, err := Load(dir.Join(tc.file))
+			assert.NilError(t, err)
+			assert.DeepEqual(t, f.Values, tc.expected)
+		})
+	}
+}
+
+func TestLoad_TOMLInlineTable(t *testing.T) {
+	content := "format = \"dots\"\n[profiles]\nci = { format = \"pkgname\", packages = [\"./...\"] }\n"
+	dir := fs.NewDir(t, t.Name(), fs.WithFile(".gotestsum.toml", content))
+	f, err := Load(dir.Join(".gotestsum.toml"))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, f.Profiles, map[string]map[string][]string{
+		"ci": {"format": {"pkgname"}, "packages": {"./..."}},
+	})
+}
+
+func TestLoad_Errors(t *testing.T) {
+	type testCase struct {
+		name     string
+		file     string
+		content  string
+		expected string
+	}
+	var testCases = []testCase{
+		{
+			name:     "yaml nested value",
+			file:     ".gotestsum.yaml",
+			content:  "format:\n  short: true\n",
+			expected: "option format must be a value or a list of values",
+		},
+		{
+			name:     "yaml bad indentation",
+			file:     ".gotestsum.yaml",
+			content:  "format: short\n  junitfile: junit.xml\n",
+			expected: "yaml: line 2: mapping values are not allowed in this context",
+		},
+		{
+			name:     "yaml duplicate key",
+			file:     ".gotestsum.yaml",
+			content:  "format: short\nformat: dots\n",
+			expected: "line 2: duplicate key format",
+		},
+		{
+			name:     "yaml list of lists",
+			file:     ".gotestsum.yaml",
+			content:  "packages: [[./...]]\n",
+			expected: "option packages must be a list of values",
+		},
+		{
+			name:     "yaml profile is not a table",
+			file:     ".gotestsum.yaml",
+			content:  "profiles: ci\n",
+			expected: "profiles must be a table of profiles",
+		},
+		{
+			name:     "toml unquoted string",
+			file:     ".gotestsum.toml",
+			content:  "format = short\n",
+			expected: `line 1 (last key "format"): expected value but found "short" instead`,
+		},
+		{
+			name:     "toml missing quote",
+			file:     ".gotestsum.toml",
+			content:  "packages = [\"./...]\n",
+			expected: `line 1 (last key "packages"): strings cannot contain newlines`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := fs.NewDir(t, t.Name(), fs.WithFile(tc.file, tc.content))
+			_, err := Load(dir.Join(tc.file))
+			assert.ErrorContains(t, err, tc.expected)
+		})
+	}
+}
+
+func TestFind(t *testing.T) {
+	dir := fs.NewDir(t, t.Name(),
+		fs.WithFile(".gotestsum.yaml", "format: dots\n"),
+		fs.WithDir("mod",
+			fs.WithFile("go.mod", "module example.com/mod\n"),
+			fs.WithDir("pkg")),
+		fs.WithDir("nested",
+			fs.WithFile("go.mod", "module example.com/nested\n"),
+			fs.WithFile(".gotestsum.toml", "format = \"testname\"\n"),
+			fs.WithDir("pkg", fs.WithDir("sub"))))
+
+	t.Run("found in a parent", func(t *testing.T) {
+		f, err := Find(dir.Join("nested", "pkg", "sub"))
+		assert.NilError(t, err)
+		assert.Equal(t, f.Path, dir.Join("nested", ".gotestsum.toml"))
+	})
+
+	t.Run("stops at the module root", func(t *testing.T) {
+		f, err := Find(dir.Join("mod", "pkg"))
+		assert.NilError(t, err)
+		assert.Assert(t, f == nil)
+	})
+
+	t.Run("more than one file", func(t *testing.T) {
+		dir := fs.NewDir(t, t.Name(),
+			fs.WithFile(".gotestsum.yaml", ""),
+			fs.WithFile(".gotestsum.toml", ""))
+		_, err := Find(dir.Path())
+		assert.ErrorContains(t, err, "found more than one configuration file")
+	})
+}
+
+func TestFile_ProfileValues(t *testing.T) {
+	f := &File{
+		Path:   ".gotestsum.yaml",
+		Values: map[string][]string{"format": {"dots"}, "packages": {"./..."}},
+		Profiles: map[string]map[string][]string{
+			"ci":    {"format": {"pkgname"}},
+			"local": {"watch": {"true"}},
+		},
+	}
+
+	values, err := f.ProfileValues("ci")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, values, map[string][]string{
+		"format":   {"pkgname"},
+		"packages": {"./..."},
+	})
+
+	_, err = f.ProfileValues("other")
+	assert.Error(t, err,
+		`profile "other" not found in .gotestsum.yaml, must be one of: ci, local`)
+}
@@ -0,0 +1,14 @@
+package config
+
+import (
+	"github.com/BurntSushi/toml"
+)
+
+// parseTOML parses the configuration file with github.com/BurntSushi/toml.
+func parseTOML(raw []byte) (map[string]interface{}, error) {
+	doc := make(map[string]interface{})
+	if _, err := toml.Decode(string(raw), &doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
@@ -0,0 +1,87 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// parseYAML parses the configuration file with gopkg.in/yaml.v3. Scalars are
+// returned as strings, lists as []interface{}, and mappings as
+// map[string]interface{}.
+func parseYAML(raw []byte) (map[string]interface{}, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(raw, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 {
+		return map[string]interface{}{}, nil
+	}
+	value, err := yamlValue(doc.Content[0])
+	if err != nil {
+		return nil, err
+	}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return v, nil
+	case nil:
+		return map[string]interface{}{}, nil
+	default:
+		return nil, fmt.Errorf("line %d: expected a mapping of options", doc.Content[0].Line)
+	}
+}
+
+func yamlValue(node *yaml.Node) (interface{}, error) {
+	switch node.Kind {
+	case yaml.AliasNode:
+		return yamlValue(node.Alias)
+	case yaml.MappingNode:
+		result := make(map[string]interface{}, len(node.Content)/2)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i]
+			if _, exists := result[key.Value]; exists {
+				return nil, fmt.Errorf("line %d: duplicate key %v", key.Line, key.Value)
+			}
+			value, err := yamlValue(node.Content[i+1])
+			if err != nil {
+				return nil, err
+			}
+			result[key.Value] = value
+		}
+		return result, nil
+	case yaml.SequenceNode:
+		result := make([]interface{}, 0, len(node.Content))
+		for _, item := range node.Content {
+			value, err := yamlValue(item)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, value)
+		}
+		return result, nil
+	case yaml.ScalarNode:
+		return yamlScalar(node), nil
+	default:
+		return nil, fmt.Errorf("line %d: unexpected YAML node", node.Line)
+	}
+}
+
+// yamlScalar returns the value of a scalar. gopkg.in/yaml.v3 follows YAML 1.2,
+// where yes, no, on, and off are strings. They are booleans in YAML 1.1, which
+// is what most people expect in a configuration file, so unquoted values are
+// converted to true or false.
+func yamlScalar(node *yaml.Node) interface{} {
+	if node.Tag == "!!null" {
+		return nil
+	}
+	if node.Style&(yaml.SingleQuotedStyle|yaml.DoubleQuotedStyle) == 0 {
+		switch strings.ToLower(node.Value) {
+		case "yes", "on":
+			return "true"
+		case "no", "off":
+			return "false"
+		}
+	}
+	return node.Value
+}
@@ -9,6 +9,8 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"regexp"
+	"strings"
 
 	"golang.org/x/sys/unix"
 	"gotest.tools/gotestsum/internal/log"
@@ -16,11 +18,12 @@ import (
 
 type terminal struct {
 	ch    chan Event
+	in    *bufio.Reader
 	reset func()
 }
 
 func newTerminal() *terminal {
-	h := &terminal{ch: make(chan Event)}
+	h := &terminal{ch: make(chan Event), in: bufio.NewReader(stdin)}
 	h.Start()
 	return h
 }
@@ -71,9 +74,8 @@ func (r *terminal) Monitor(ctx context.Context) {
 	if r == nil {
 		return
 	}
-	in := bufio.NewReader(stdin)
 	for {
-		char, err := in.ReadByte()
+		char, err := r.in.ReadByte()
 		if err != nil {
 			log.Warnf("failed to read input: %v", err)
 			return
@@ -92,6 +94,12 @@ func (r *terminal) Monitor(ctx context.Context) {
 			r.ch <- Event{resume: chResume, reloadPaths: true}
 		case 'u':
 			r.ch <- Event{resume: chResume, useLastPath: true, Args: []string{"-update"}}
+		case 'f':
+			r.ch <- Event{resume: chResume, useLastPath: true, FailedOnly: true}
+		case 't':
+			r.ch <- Event{resume: chResume, useLastPath: true, promptRunFilter: true}
+		case 'c':
+			r.ch <- Event{resume: chResume, useLastPath: true, setRunFilter: true}
 		case '\n':
 			fmt.Println()
 			continue
@@ -107,6 +115,28 @@ func (r *terminal) Monitor(ctx context.Context) {
 	}
 }
 
+// promptRunFilter prints a prompt and reads a -run regex from the terminal.
+// The terminal must be in normal mode while the line is read, so that the input
+// is echoed and can be edited. Returns false if the input could not be read, or
+// is not a valid regex.
+//
+// promptRunFilter is called by the Watch goroutine while Monitor is blocked
+// waiting for the event to resume.
+func (r *terminal) promptRunFilter() (string, bool) {
+	fmt.Print("\nTest name filter (-run regex): ")
+	line, err := r.in.ReadString('\n')
+	if err != nil {
+		log.Warnf("failed to read input: %v", err)
+		return "", false
+	}
+	filter := strings.TrimSpace(line)
+	if _, err := regexp.Compile(filter); err != nil {
+		log.Warnf("invalid test name filter %q: %v", filter, err)
+		return "", false
+	}
+	return filter, true
+}
+
 // Events returns a channel which will receive events when keys are pressed.
 // When an event is received, the caller must close the resume channel to
 // resume monitoring for events.
@@ -16,4 +16,8 @@ func (r *terminal) Events() <-chan Event {
 
 func (r *terminal) Start() {}
 
+func (r *terminal) promptRunFilter() (string, bool) {
+	return "", false
+}
+
 func (r *terminal) Reset() {}
@@ -25,6 +25,12 @@ type Event struct {
 	Args []string
 	// Debug runs the tests with delve.
 	Debug bool
+	// RunFilter is a regex used as the value of the 'go test -run' flag. The
+	// filter is set by a key press, and persists for all subsequent runs until
+	// it is cleared.
+	RunFilter string
+	// FailedOnly runs only the tests which failed in the previous run.
+	FailedOnly bool
 	// resume the Watch goroutine when this channel is closed. Used to block
 	// the Watch goroutine while tests are running.
 	resume chan struct{}
@@ -33,11 +39,49 @@ type Event struct {
 	reloadPaths bool
 	// useLastPath when true will use the PkgPath from the previous run.
 	useLastPath bool
+	// setRunFilter when true replaces the persisted RunFilter with the value
+	// from this event. An empty RunFilter clears the filter.
+	setRunFilter bool
+	// promptRunFilter when true reads the RunFilter from the terminal before
+	// the tests are run, and sets it as the persisted RunFilter.
+	promptRunFilter bool
+}
+
+// Request to run tests, received from a source other than the terminal or the
+// file system. For example, a control socket used by an editor.
+type Request struct {
+	// PkgPath of the package to test. If PkgPath is empty the package from the
+	// previous run is used.
+	PkgPath string
+	// RunFilter is used as the value of the 'go test -run' flag for this run
+	// only. If RunFilter is empty, the filter set from the terminal is used.
+	RunFilter string
+	// Debug runs the tests with delve.
+	Debug bool
+	// Done is closed when the run is complete. Done may be nil.
+	Done chan struct{}
+}
+
+func (r Request) event() Event {
+	return Event{
+		PkgPath:     r.PkgPath,
+		RunFilter:   r.RunFilter,
+		Debug:       r.Debug,
+		useLastPath: r.PkgPath == "",
+	}
+}
+
+func (r Request) done() {
+	if r.Done != nil {
+		close(r.Done)
+	}
 }
 
 // Watch dirs for filesystem events, and run tests when .go files are saved.
+// Tests are also run for every Request received from requests. requests may
+// be nil.
 // nolint: gocyclo
-func Watch(ctx context.Context, dirs []string, run func(Event) error) error {
+func Watch(ctx context.Context, dirs []string, run func(Event) error, requests <-chan Request) error {
 	watcher, err := fsnotify.NewWatcher()
 	if err != nil {
 		return fmt.Errorf("failed to create file watcher: %w", err)
@@ -75,12 +119,32 @@ func Watch(ctx context.Context, dirs []string, run func(Event) error) error {
 			}
 
 			term.Reset()
+			if event.promptRunFilter {
+				filter, ok := term.promptRunFilter()
+				if !ok {
+					term.Start()
+					close(event.resume)
+					continue
+				}
+				event.RunFilter, event.setRunFilter = filter, true
+			}
 			if err := h.runTests(event); err != nil {
 				return fmt.Errorf("failed to rerun tests for %v: %v", event.PkgPath, err)
 			}
 			term.Start()
 			close(event.resume)
 
+		case req := <-requests:
+			resetTimer(timer)
+
+			term.Reset()
+			err := h.runTests(req.event())
+			req.done()
+			if err != nil {
+				return fmt.Errorf("failed to run tests for %v: %v", req.PkgPath, err)
+			}
+			term.Start()
+
 		case event := <-watcher.Events:
 			resetTimer(timer)
 			log.Debugf("handling event %v", event)
@@ -228,9 +292,10 @@ func handleDirCreated(watcher *fsnotify.Watcher, event fsnotify.Event) (handled
 }
 
 type fsEventHandler struct {
-	last     time.Time
-	lastPath string
-	fn       func(opts Event) error
+	last      time.Time
+	lastPath  string
+	runFilter string
+	fn        func(opts Event) error
 }
 
 var floodThreshold = 250 * time.Millisecond
@@ -255,7 +320,13 @@ func (h *fsEventHandler) runTests(opts Event) error {
 	if opts.useLastPath {
 		opts.PkgPath = h.lastPath
 	}
-	fmt.Printf("\nRunning tests in %v\n", opts.PkgPath)
+	if opts.setRunFilter {
+		h.runFilter = opts.RunFilter
+	}
+	if opts.RunFilter == "" {
+		opts.RunFilter = h.runFilter
+	}
+	fmt.Printf("\nRunning tests in %v%v\n", opts.PkgPath, formatRunFilter(opts))
 
 	if err := h.fn(opts); err != nil {
 		return err
@@ -264,3 +335,14 @@ func (h *fsEventHandler) runTests(opts Event) error {
 	h.lastPath = opts.PkgPath
 	return nil
 }
+
+func formatRunFilter(opts Event) string {
+	switch {
+	case opts.FailedOnly:
+		return " (previously failed tests only)"
+	case opts.RunFilter != "":
+		return fmt.Sprintf(" (filter: -run=%v)", opts.RunFilter)
+	default:
+		return ""
+	}
+}
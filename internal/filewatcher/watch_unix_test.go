@@ -31,7 +31,7 @@ func TestWatch(t *testing.T) {
 	}
 
 	go func() {
-		err := Watch(ctx, []string{dir.Path()}, capture)
+		err := Watch(ctx, []string{dir.Path()}, capture, nil)
 		assert.Check(t, err)
 	}()
 
@@ -85,6 +85,123 @@ func TestWatch(t *testing.T) {
 			}
 			assert.DeepEqual(t, event, expected, cmpEvent)
 		})
+
+		t.Run("and failed only", func(t *testing.T) {
+			_, err := w.Write([]byte("f"))
+			assert.NilError(t, err)
+
+			event := <-chEvents
+			expected := Event{
+				PkgPath:     "./" + dir.Path(),
+				FailedOnly:  true,
+				useLastPath: true,
+			}
+			assert.DeepEqual(t, event, expected, cmpEvent)
+		})
+
+		t.Run("and set run filter", func(t *testing.T) {
+			_, err := w.Write([]byte("tTestOne|TestTwo\n"))
+			assert.NilError(t, err)
+
+			event := <-chEvents
+			expected := Event{
+				PkgPath:         "./" + dir.Path(),
+				RunFilter:       "TestOne|TestTwo",
+				useLastPath:     true,
+				setRunFilter:    true,
+				promptRunFilter: true,
+			}
+			assert.DeepEqual(t, event, expected, cmpEvent)
+
+			t.Run("filter persists", func(t *testing.T) {
+				_, err := w.Write([]byte("r"))
+				assert.NilError(t, err)
+
+				event := <-chEvents
+				expected := Event{
+					PkgPath:     "./" + dir.Path(),
+					RunFilter:   "TestOne|TestTwo",
+					useLastPath: true,
+				}
+				assert.DeepEqual(t, event, expected, cmpEvent)
+			})
+		})
+
+		t.Run("and invalid run filter is ignored", func(t *testing.T) {
+			_, err := w.Write([]byte("tTestOne(\nr"))
+			assert.NilError(t, err)
+
+			event := <-chEvents
+			expected := Event{
+				PkgPath:     "./" + dir.Path(),
+				RunFilter:   "TestOne|TestTwo",
+				useLastPath: true,
+			}
+			assert.DeepEqual(t, event, expected, cmpEvent)
+		})
+
+		t.Run("and clear run filter", func(t *testing.T) {
+			_, err := w.Write([]byte("c"))
+			assert.NilError(t, err)
+
+			event := <-chEvents
+			expected := Event{
+				PkgPath:      "./" + dir.Path(),
+				useLastPath:  true,
+				setRunFilter: true,
+			}
+			assert.DeepEqual(t, event, expected, cmpEvent)
+		})
+	})
+}
+
+func TestWatch_Requests(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	dir := fs.NewDir(t, t.Name())
+
+	r, w := io.Pipe()
+	patchStdin(t, r)
+
+	chEvents := make(chan Event, 1)
+	capture := func(event Event) error {
+		chEvents <- event
+		return nil
+	}
+
+	requests := make(chan Request)
+	go func() {
+		err := Watch(ctx, []string{dir.Path()}, capture, requests)
+		assert.Check(t, err)
+	}()
+
+	t.Run("run package", func(t *testing.T) {
+		req := Request{PkgPath: "./pkg", RunFilter: "^TestOne$", Done: make(chan struct{})}
+		requests <- req
+
+		event := <-chEvents
+		expected := Event{PkgPath: "./pkg", RunFilter: "^TestOne$"}
+		assert.DeepEqual(t, event, expected, cmpEvent)
+		<-req.Done
+	})
+
+	t.Run("rerun", func(t *testing.T) {
+		req := Request{Done: make(chan struct{})}
+		requests <- req
+
+		event := <-chEvents
+		expected := Event{PkgPath: "./pkg", useLastPath: true}
+		assert.DeepEqual(t, event, expected, cmpEvent)
+		<-req.Done
+	})
+
+	t.Run("and key press", func(t *testing.T) {
+		_, err := w.Write([]byte("r"))
+		assert.NilError(t, err)
+
+		event := <-chEvents
+		expected := Event{PkgPath: "./pkg", useLastPath: true}
+		assert.DeepEqual(t, event, expected, cmpEvent)
 	})
 }
 
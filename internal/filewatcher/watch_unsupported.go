@@ -4,15 +4,26 @@
 package filewatcher
 
 import (
+	"context"
 	"fmt"
 	"runtime"
 )
 
 type Event struct {
-	PkgPath string
-	Debug   bool
+	PkgPath    string
+	Args       []string
+	Debug      bool
+	RunFilter  string
+	FailedOnly bool
 }
 
-func Watch(dirs []string, run func(Event) error) error {
+type Request struct {
+	PkgPath   string
+	RunFilter string
+	Debug     bool
+	Done      chan struct{}
+}
+
+func Watch(_ context.Context, _ []string, _ func(Event) error, _ <-chan Request) error {
 	return fmt.Errorf("file watching is not supported on %v/%v", runtime.GOOS, runtime.GOARCH)
 }
@@ -33,6 +33,7 @@ type JUnitTestSuite struct {
 	XMLName    xml.Name        `xml:"testsuite"`
 	Tests      int             `xml:"tests,attr"`
 	Failures   int             `xml:"failures,attr"`
+	Errors     int             `xml:"errors,attr,omitempty"`
 	Time       string          `xml:"time,attr"`
 	Name       string          `xml:"name,attr"`
 	Properties []JUnitProperty `xml:"properties>property,omitempty"`
@@ -46,8 +47,10 @@ type JUnitTestCase struct {
 	Classname   string            `xml:"classname,attr"`
 	Name        string            `xml:"name,attr"`
 	Time        string            `xml:"time,attr"`
+	Properties  *JUnitProperties  `xml:"properties,omitempty"`
 	SkipMessage *JUnitSkipMessage `xml:"skipped,omitempty"`
 	Failure     *JUnitFailure     `xml:"failure,omitempty"`
+	Error       *JUnitError       `xml:"error,omitempty"`
 }
 
 // JUnitSkipMessage contains the reason why a testcase was skipped.
@@ -55,6 +58,11 @@ type JUnitSkipMessage struct {
 	Message string `xml:"message,attr"`
 }
 
+// JUnitProperties are the properties of a test case.
+type JUnitProperties struct {
+	Properties []JUnitProperty `xml:"property"`
+}
+
 // JUnitProperty represents a key/value pair used to define properties.
 type JUnitProperty struct {
 	Name  string `xml:"name,attr"`
@@ -68,12 +76,24 @@ type JUnitFailure struct {
 	Contents string `xml:",chardata"`
 }
 
+// JUnitError contains data related to a test case which could not be run,
+// like a package that failed to build.
+type JUnitError struct {
+	Message  string `xml:"message,attr"`
+	Type     string `xml:"type,attr"`
+	Contents string `xml:",chardata"`
+}
+
 // Config used to write a junit XML document.
 type Config struct {
 	ProjectName             string
 	FormatTestSuiteName     FormatFunc
 	FormatTestCaseClassname FormatFunc
 	HideEmptyPackages       bool
+	// ReproduceCommand returns the command that runs a failed test again.
+	// When ReproduceCommand is set, the command is added to the contents of
+	// the failure, or error, of each failed test.
+	ReproduceCommand func(testjson.TestCase) string
 	// This is used for tests to have a consistent timestamp
 	customTimestamp string
 	customElapsed   string
@@ -96,17 +116,22 @@ func generate(exec *testjson.Execution, cfg Config) JUnitTestSuites {
 	suites := JUnitTestSuites{
 		Name:     cfg.ProjectName,
 		Tests:    exec.Total(),
-		Failures: len(exec.Failed()),
-		Errors:   len(exec.Errors()),
+		Failures: len(exec.Failed()) - len(exec.Incomplete()),
+		Errors:   exec.ErrorCount() + len(exec.Incomplete()),
 		Time:     formatDurationAsSeconds(time.Since(exec.Started())),
 	}
 
 	if cfg.customElapsed != "" {
 		suites.Time = cfg.customElapsed
 	}
-	for _, pkgname := range exec.Packages() {
+	for _, pkgname := range packageNames(exec) {
 		pkg := exec.Package(pkgname)
-		if cfg.HideEmptyPackages && pkg.IsEmpty() {
+		buildErrors := exec.PackageBuildErrors(pkgname)
+		if pkg == nil {
+			// the package failed to build, and there was no output for it
+			pkg = &testjson.Package{}
+		}
+		if cfg.HideEmptyPackages && pkg.IsEmpty() && len(buildErrors) == 0 {
 			continue
 		}
 		junitpkg := JUnitTestSuite{
@@ -114,10 +139,14 @@ func generate(exec *testjson.Execution, cfg Config) JUnitTestSuites {
 			Tests:      pkg.Total,
 			Time:       formatDurationAsSeconds(pkg.Elapsed()),
 			Properties: packageProperties(version),
-			TestCases:  packageTestCases(pkg, cfg.FormatTestCaseClassname),
-			Failures:   len(pkg.Failed),
+			TestCases:  packageTestCases(pkgname, pkg, cfg),
+			Failures:   len(pkg.Failed) - len(pkg.Incomplete()),
+			Errors:     len(pkg.Incomplete()),
 			Timestamp:  cfg.customTimestamp,
 		}
+		if pkg.BuildFailed() || len(buildErrors) > 0 {
+			junitpkg.TestCases = buildFailedTestCases(pkgname, pkg, buildErrors, cfg.FormatTestCaseClassname)
+		}
 		if cfg.customTimestamp == "" {
 			junitpkg.Timestamp = exec.Started().Format(time.RFC3339)
 		}
@@ -126,6 +155,44 @@ func generate(exec *testjson.Execution, cfg Config) JUnitTestSuites {
 	return suites
 }
 
+// packageNames returns the names of all the packages in exec, and the
+// packages with build errors which are not in exec.
+func packageNames(exec *testjson.Execution) []string {
+	names := exec.Packages()
+	for _, name := range exec.BuildFailedPackages() {
+		if exec.Package(name) == nil {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// buildFailedTestCases returns a test case with an error for a package that
+// failed to build. The contents of the error are the build errors, and the time
+// of the test case is the time it took to build the package.
+func buildFailedTestCases(
+	pkgname string,
+	pkg *testjson.Package,
+	buildErrors []testjson.BuildError,
+	formatClassname FormatFunc,
+) []JUnitTestCase {
+	lines := make([]string, 0, len(buildErrors))
+	for _, err := range buildErrors {
+		lines = append(lines, err.String())
+	}
+	tc := testjson.TestCase{Package: pkgname, Test: "BuildFailed", Elapsed: pkg.BuildElapsed()}
+	jtc := newJUnitTestCase(tc, formatClassname)
+	jtc.Error = &JUnitError{
+		Message:  "Build failed",
+		Type:     "build",
+		Contents: strings.Join(lines, "\n"),
+	}
+	if len(buildErrors) == 1 {
+		jtc.Error.Message = buildErrors[0].String()
+	}
+	return []JUnitTestCase{jtc}
+}
+
 func configWithDefaults(cfg Config) Config {
 	noop := func(v string) string {
 		return v
@@ -169,7 +236,8 @@ func goVersion() string {
 	return strings.TrimPrefix(strings.TrimSpace(string(out)), "go version ")
 }
 
-func packageTestCases(pkg *testjson.Package, formatClassname FormatFunc) []JUnitTestCase {
+func packageTestCases(pkgname string, pkg *testjson.Package, cfg Config) []JUnitTestCase {
+	formatClassname := cfg.FormatTestCaseClassname
 	cases := []JUnitTestCase{}
 
 	if pkg.TestMainFailed() {
@@ -178,16 +246,31 @@ func packageTestCases(pkg *testjson.Package, formatClassname FormatFunc) []JUnit
 		jtc := newJUnitTestCase(testjson.TestCase{Test: "TestMain"}, formatClassname)
 		jtc.Failure = &JUnitFailure{
 			Message:  "Failed",
-			Contents: buf.String(),
+			Contents: buf.String() + reproduceContents(cfg, testjson.TestCase{Package: pkgname}),
 		}
 		cases = append(cases, jtc)
 	}
 
+	raced := racedTestCases(pkg)
+	panicked := panickedTestCases(pkg)
 	for _, tc := range pkg.Failed {
 		jtc := newJUnitTestCase(tc, formatClassname)
+		if tc.Incomplete != nil {
+			jtc.Error = incompleteError(tc.Incomplete)
+			jtc.Error.Contents += reproduceContents(cfg, tc)
+			cases = append(cases, jtc)
+			continue
+		}
 		jtc.Failure = &JUnitFailure{
 			Message:  "Failed",
-			Contents: strings.Join(pkg.OutputLines(tc), ""),
+			Contents: strings.Join(pkg.OutputLines(tc), "") + reproduceContents(cfg, tc),
+		}
+		if raced[tc.ID] {
+			jtc.Failure.Message = "Data race detected"
+			jtc.Failure.Type = "race"
+		} else if p, ok := panicked[tc.Test.Name()]; ok {
+			jtc.Failure.Message = p.Message()
+			jtc.Failure.Type = "panic"
 		}
 		cases = append(cases, jtc)
 	}
@@ -207,12 +290,73 @@ func packageTestCases(pkg *testjson.Package, formatClassname FormatFunc) []JUnit
 	return cases
 }
 
+// reproduceContents returns the line with the command that runs tc again, or
+// an empty string if cfg.ReproduceCommand is not set.
+func reproduceContents(cfg Config, tc testjson.TestCase) string {
+	if cfg.ReproduceCommand == nil {
+		return ""
+	}
+	return "reproduce: " + cfg.ReproduceCommand(tc) + "\n"
+}
+
+// incompleteError returns the error for a test which did not finish, because
+// the test binary exited while it was running.
+func incompleteError(incomplete *testjson.Incomplete) *JUnitError {
+	return &JUnitError{
+		Message:  incomplete.Reason,
+		Type:     "incomplete",
+		Contents: strings.Join(incomplete.Output, ""),
+	}
+}
+
+// racedTestCases returns the IDs of the test cases which reported a data race.
+func racedTestCases(pkg *testjson.Package) map[int]bool {
+	result := make(map[int]bool)
+	for _, race := range pkg.Races() {
+		for _, tc := range race.Tests {
+			result[tc.ID] = true
+		}
+	}
+	return result
+}
+
+// panickedTestCases returns the panics in the package by the name of the test
+// that caused them. A timeout panic is returned for each of the tests that
+// were running when the timeout happened.
+func panickedTestCases(pkg *testjson.Package) map[string]testjson.Panic {
+	result := make(map[string]testjson.Panic)
+	for _, p := range pkg.Panics() {
+		if !p.Timeout {
+			if p.Test.ID != 0 {
+				result[p.Test.Test.Name()] = p
+			}
+			continue
+		}
+		for _, running := range p.RunningTests {
+			if i := strings.Index(running, " ("); i > 0 {
+				running = running[:i]
+			}
+			result[running] = p
+		}
+	}
+	return result
+}
+
 func newJUnitTestCase(tc testjson.TestCase, formatClassname FormatFunc) JUnitTestCase {
-	return JUnitTestCase{
+	jtc := JUnitTestCase{
 		Classname: formatClassname(tc.Package),
 		Name:      tc.Test.Name(),
 		Time:      formatDurationAsSeconds(tc.Elapsed),
 	}
+	// attributes set with testing.T.Attr
+	if len(tc.Attrs) > 0 {
+		jtc.Properties = &JUnitProperties{}
+		for _, attr := range tc.Attrs {
+			jtc.Properties.Properties = append(jtc.Properties.Properties,
+				JUnitProperty{Name: attr.Key, Value: attr.Value})
+		}
+	}
+	return jtc
 }
 
 func write(out io.Writer, suites JUnitTestSuites) error {
@@ -6,6 +6,7 @@ import (
 	"io"
 	"io/ioutil"
 	"runtime"
+	"strings"
 	"testing"
 	"time"
 
@@ -70,3 +71,214 @@ func TestGoVersion(t *testing.T) {
 		assert.Equal(t, goVersion(), expected)
 	})
 }
+
+func TestWrite_WithDataRace(t *testing.T) {
+	raw, err := ioutil.ReadFile("../../testjson/testdata/input/go-test-json-race.out")
+	assert.NilError(t, err)
+	exec, err := testjson.ScanTestOutput(testjson.ScanConfig{Stdout: bytes.NewReader(raw)})
+	assert.NilError(t, err)
+
+	env.Patch(t, "GOVERSION", "go7.7.7")
+	suites := generate(exec, Config{})
+	assert.Equal(t, len(suites.Suites), 1)
+	var failures []JUnitFailure
+	for _, tc := range suites.Suites[0].TestCases {
+		if tc.Failure != nil {
+			failures = append(failures, *tc.Failure)
+			assert.Equal(t, tc.Name, "TestRaceOne")
+		}
+	}
+	assert.Equal(t, len(failures), 1)
+	assert.Equal(t, failures[0].Type, "race")
+	assert.Equal(t, failures[0].Message, "Data race detected")
+	assert.Assert(t, strings.Contains(failures[0].Contents, "WARNING: DATA RACE"))
+}
+
+func TestWrite_WithPanics(t *testing.T) {
+	env.Patch(t, "GOVERSION", "go7.7.7")
+	failures := func(t *testing.T, filename string) map[string]JUnitFailure {
+		raw, err := ioutil.ReadFile("../../testjson/testdata/input/" + filename)
+		assert.NilError(t, err)
+		exec, err := testjson.ScanTestOutput(testjson.ScanConfig{Stdout: bytes.NewReader(raw)})
+		assert.NilError(t, err)
+
+		result := make(map[string]JUnitFailure)
+		for _, suite := range generate(exec, Config{}).Suites {
+			for _, tc := range suite.TestCases {
+				if tc.Failure != nil {
+					result[tc.Name] = *tc.Failure
+				}
+			}
+		}
+		return result
+	}
+
+	t.Run("panic", func(t *testing.T) {
+		result := failures(t, "go-test-json-panic.out")
+		assert.Equal(t, result["TestPanic"].Type, "panic")
+		assert.Equal(t, result["TestPanic"].Message,
+			"panic: runtime error: invalid memory address or nil pointer dereference")
+		assert.Equal(t, result["TestPanic/sub"].Type, "")
+		assert.Equal(t, result["TestPanic/sub"].Message, "Failed")
+	})
+}
+
+func TestWrite_WithIncompleteTests(t *testing.T) {
+	env.Patch(t, "GOVERSION", "go7.7.7")
+	generateFrom := func(t *testing.T, filename string) JUnitTestSuites {
+		raw, err := ioutil.ReadFile("../../testjson/testdata/input/" + filename)
+		assert.NilError(t, err)
+		exec, err := testjson.ScanTestOutput(testjson.ScanConfig{Stdout: bytes.NewReader(raw)})
+		assert.NilError(t, err)
+		return generate(exec, Config{})
+	}
+	errors := func(suites JUnitTestSuites) map[string]JUnitError {
+		result := make(map[string]JUnitError)
+		for _, suite := range suites.Suites {
+			for _, tc := range suite.TestCases {
+				assert.Assert(t, tc.Failure == nil || tc.Error == nil, tc.Name)
+				if tc.Error != nil {
+					result[tc.Name] = *tc.Error
+				}
+			}
+		}
+		return result
+	}
+
+	t.Run("exit", func(t *testing.T) {
+		suites := generateFrom(t, "go-test-json-exit.out")
+		assert.Equal(t, suites.Failures, 0)
+		assert.Equal(t, suites.Errors, 2)
+		assert.Equal(t, suites.Suites[0].Errors, 2)
+		assert.DeepEqual(t, errors(suites), map[string]JUnitError{
+			"TestExit": {
+				Message: "the test binary exited before the test finished",
+				Type:    "incomplete",
+			},
+			"TestExit/sub": {
+				Message:  "the test binary exited before the test finished",
+				Type:     "incomplete",
+				Contents: "    inc_test.go:12: about to exit\n",
+			},
+		})
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		result := errors(generateFrom(t, "go-test-json-timeout.out"))
+		for _, name := range []string{"TestSlow", "TestSlow/inner"} {
+			assert.Equal(t, result[name].Type, "incomplete", name)
+			assert.Equal(t, result[name].Message, "test timed out after 1s", name)
+		}
+	})
+}
+
+func TestWrite_WithBuildErrors(t *testing.T) {
+	stdout, err := ioutil.ReadFile("../../testjson/testdata/input/go-test-json-build-failed.out")
+	assert.NilError(t, err)
+	stderr, err := ioutil.ReadFile("../../testjson/testdata/input/go-test-json-build-failed.err")
+	assert.NilError(t, err)
+	exec, err := testjson.ScanTestOutput(testjson.ScanConfig{
+		Stdout: bytes.NewReader(stdout),
+		Stderr: bytes.NewReader(stderr),
+	})
+	assert.NilError(t, err)
+
+	env.Patch(t, "GOVERSION", "go7.7.7")
+	suites := generate(exec, Config{})
+	assert.Equal(t, len(suites.Suites), 3)
+
+	broken := suites.Suites[1]
+	assert.Equal(t, broken.Name, "example.com/be/broken")
+	assert.Equal(t, len(broken.TestCases), 1)
+	tc := broken.TestCases[0]
+	assert.Equal(t, tc.Name, "BuildFailed")
+	assert.Assert(t, tc.Failure == nil)
+	expected := &JUnitError{
+		Message: "Build failed",
+		Type:    "build",
+		Contents: "broken/broken.go:4:9: undefined: undefinedThing\n" +
+			"broken/broken.go:8:9: cannot use 1 (untyped int constant) as string value in return statement",
+	}
+	assert.DeepEqual(t, tc.Error, expected)
+
+	badtest := suites.Suites[0]
+	assert.Equal(t, badtest.TestCases[0].Error.Message,
+		`badtest/badtest_test.go:9:14: fmt.Printf format %d has arg "not a number" of wrong type string`)
+
+	var buf bytes.Buffer
+	assert.NilError(t, write(&buf, suites))
+	assert.Assert(t, strings.Contains(buf.String(),
+		`<error message="Build failed" type="build">broken/broken.go:4:9: undefined: undefinedThing`))
+}
+
+func TestWrite_WithBuildEvents(t *testing.T) {
+	raw, err := ioutil.ReadFile("../../testjson/testdata/input/go-test-json-build-events.out")
+	assert.NilError(t, err)
+	exec, err := testjson.ScanTestOutput(testjson.ScanConfig{Stdout: bytes.NewReader(raw)})
+	assert.NilError(t, err)
+
+	env.Patch(t, "GOVERSION", "go7.7.7")
+	suites := generate(exec, Config{})
+	assert.Equal(t, suites.Errors, 3)
+	assert.Equal(t, len(suites.Suites), 3)
+
+	broken := suites.Suites[1]
+	assert.Equal(t, len(broken.TestCases), 1)
+	assert.Equal(t, broken.TestCases[0].Name, "BuildFailed")
+	assert.Equal(t, broken.TestCases[0].Error.Type, "build")
+	assert.Equal(t, broken.TestCases[0].Error.Contents,
+		"broken/broken.go:4:9: undefined: undefinedThing\n"+
+			"broken/broken.go:8:9: cannot use 1 (untyped int constant) as string value in return statement")
+}
+
+func TestWrite_WithAttrs(t *testing.T) {
+	raw, err := ioutil.ReadFile("../../testjson/testdata/input/go-test-json-attr.out")
+	assert.NilError(t, err)
+	exec, err := testjson.ScanTestOutput(testjson.ScanConfig{Stdout: bytes.NewReader(raw)})
+	assert.NilError(t, err)
+
+	env.Patch(t, "GOVERSION", "go7.7.7")
+	suites := generate(exec, Config{})
+	assert.Equal(t, len(suites.Suites), 1)
+
+	properties := make(map[string]*JUnitProperties)
+	for _, tc := range suites.Suites[0].TestCases {
+		properties[tc.Name] = tc.Properties
+	}
+	assert.DeepEqual(t, properties, map[string]*JUnitProperties{
+		"TestOwned": {Properties: []JUnitProperty{
+			{Name: "owner", Value: "team-a"},
+			{Name: "ticket", Value: "ABC-123"},
+		}},
+		"TestOwned/sub": {Properties: []JUnitProperty{{Name: "owner", Value: "team-b"}}},
+		"TestOther":     {Properties: []JUnitProperty{{Name: "owner", Value: "team-b"}}},
+		"TestPlain":     nil,
+	})
+}
+
+func TestWrite_WithReproduceCommand(t *testing.T) {
+	env.Patch(t, "GOVERSION", "go7.7.7")
+	exec := createExecution(t)
+	suites := generate(exec, Config{
+		ReproduceCommand: func(tc testjson.TestCase) string {
+			return "go test -run=" + tc.Test.Name() + " " + tc.Package
+		},
+	})
+
+	var failures int
+	for _, suite := range suites.Suites {
+		for _, tc := range suite.TestCases {
+			if tc.Failure == nil {
+				continue
+			}
+			failures++
+			name := tc.Name
+			if name == "TestMain" {
+				name = "" // the package failed
+			}
+			assert.Assert(t, strings.HasSuffix(tc.Failure.Contents,
+				"\nreproduce: go test -run="+name+" "+suite.Name+"\n"), tc.Name)
+		}
+	}
+	assert.Equal(t, failures, suites.Failures)
+}
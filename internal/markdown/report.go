@@ -0,0 +1,231 @@
+/*
+Package markdown creates a Markdown summary of a testjson.Execution.
+
+The summary is intended to be used in a pull request comment, or as a
+GitHub Actions job summary.
+*/
+package markdown
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"gotest.tools/gotestsum/internal/aggregate"
+	"gotest.tools/gotestsum/testjson"
+)
+
+// Config used to write a markdown summary.
+type Config struct {
+	// MaxOutputBytes is the maximum number of bytes of output included for
+	// each test. Output longer than this is truncated. If MaxOutputBytes is 0
+	// all the output is included.
+	MaxOutputBytes int
+	// NumSlowest is the number of tests to include in the list of slowest
+	// tests. If NumSlowest is 0 the section is omitted.
+	NumSlowest int
+	// Clusters includes the groups of failed tests with the same output, from
+	// testjson.Execution.FailureClusters.
+	Clusters bool
+	// This is used for tests to have a consistent elapsed time
+	customElapsed time.Duration
+}
+
+// Write a markdown summary of exec to out.
+func Write(out io.Writer, exec *testjson.Execution, cfg Config) error {
+	buf := bufio.NewWriter(out)
+	elapsed := exec.Elapsed()
+	if cfg.customElapsed != 0 {
+		elapsed = cfg.customElapsed
+	}
+
+	failed := exec.Failed()
+	skipped := exec.Skipped()
+	errors := exec.ErrorCount()
+
+	writeHeader(buf, len(failed) == 0 && errors == 0)
+	writeCounts(buf, exec, len(failed), len(skipped), errors, elapsed)
+	if cfg.Clusters {
+		writeClusters(buf, exec.FailureClusters(), cfg)
+	}
+	writeTestCases(buf, "Failed", exec, failed, cfg)
+	writeTestCases(buf, "Skipped", exec, skipped, cfg)
+	writeErrors(buf, exec, cfg)
+	writeSlowest(buf, exec, cfg)
+
+	if err := buf.Flush(); err != nil {
+		return fmt.Errorf("failed to write markdown summary: %v", err)
+	}
+	return nil
+}
+
+func writeHeader(out *bufio.Writer, passed bool) {
+	if passed {
+		fmt.Fprintln(out, "## ✅ Tests passed")
+		return
+	}
+	fmt.Fprintln(out, "## ❌ Tests failed")
+}
+
+func writeCounts(out *bufio.Writer, exec *testjson.Execution, failed, skipped, errors int, elapsed time.Duration) {
+	var passed int
+	for _, name := range exec.Packages() {
+		passed += len(exec.Package(name).Passed)
+	}
+
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "| Tests | Passed | Failed | Skipped | Errors | Elapsed |")
+	fmt.Fprintln(out, "|------:|-------:|-------:|--------:|-------:|--------:|")
+	fmt.Fprintf(out, "| %d | %d | %d | %d | %d | %s |\n",
+		exec.Total(), passed, failed, skipped, errors,
+		testjson.FormatDurationAsSeconds(elapsed, 3))
+}
+
+// writeClusters writes the groups of failures with the same signature, so that
+// a single cause of many failures is visible before the list of failures.
+func writeClusters(out *bufio.Writer, clusters []testjson.FailureCluster, cfg Config) {
+	if len(clusters) == 0 {
+		return
+	}
+	fmt.Fprintln(out, "\n### Failure clusters")
+	for _, cluster := range clusters {
+		fmt.Fprintf(out, "\n**%d failures** with the same output:\n\n", cluster.Count)
+		for _, tc := range cluster.Tests {
+			fmt.Fprintf(out, "* `%s`\n", testCaseName(tc))
+		}
+		fmt.Fprintln(out)
+		writeCodeBlock(out, cluster.Signature, cfg.MaxOutputBytes)
+	}
+}
+
+func writeTestCases(out *bufio.Writer, header string, exec *testjson.Execution, tcs []testjson.TestCase, cfg Config) {
+	if len(tcs) == 0 {
+		return
+	}
+	fmt.Fprintf(out, "\n### %s\n", header)
+	for _, tc := range tcs {
+		fmt.Fprintln(out)
+		fmt.Fprintln(out, "<details>")
+		fmt.Fprintf(out, "<summary><code>%s</code>%s (%s)</summary>\n\n",
+			escapeHTML(testCaseName(tc)),
+			formatRunID(tc.RunID),
+			testjson.FormatDurationAsSeconds(tc.Elapsed, 2))
+		if output := testOutput(exec, tc); output != "" {
+			writeCodeBlock(out, output, cfg.MaxOutputBytes)
+		}
+		fmt.Fprintln(out, "</details>")
+	}
+}
+
+// testOutput returns the output of the test, without the framing lines that
+// are also omitted by testjson.PrintSummary.
+func testOutput(exec *testjson.Execution, tc testjson.TestCase) string {
+	var buf strings.Builder
+	for _, line := range exec.OutputLines(tc) {
+		if isFramingLine(line, tc.Test.Name()) {
+			continue
+		}
+		buf.WriteString(line)
+	}
+	return buf.String()
+}
+
+func isFramingLine(line string, testName string) bool {
+	return strings.HasPrefix(line, "=== RUN   Test") ||
+		strings.HasPrefix(line, "=== PAUSE Test") ||
+		strings.HasPrefix(line, "=== CONT  Test") ||
+		strings.HasPrefix(line, "--- FAIL: "+testName+" ") ||
+		strings.HasPrefix(line, "--- SKIP: "+testName+" ")
+}
+
+func testCaseName(tc testjson.TestCase) string {
+	pkg := testjson.RelativePackagePath(tc.Package)
+	if tc.Test == "" {
+		return pkg
+	}
+	return pkg + "." + tc.Test.Name()
+}
+
+func formatRunID(runID int) string {
+	if runID <= 0 {
+		return ""
+	}
+	return fmt.Sprintf(" (re-run %d)", runID)
+}
+
+func writeErrors(out *bufio.Writer, exec *testjson.Execution, cfg Config) {
+	others := exec.OtherErrors()
+	if len(exec.BuildErrors()) == 0 && len(others) == 0 {
+		return
+	}
+	fmt.Fprintln(out, "\n### Errors")
+	for _, pkg := range exec.BuildFailedPackages() {
+		var lines []string
+		for _, err := range exec.PackageBuildErrors(pkg) {
+			lines = append(lines, err.String())
+		}
+		fmt.Fprintf(out, "\n**Build failed:** `%s`\n\n", testjson.RelativePackagePath(pkg))
+		writeCodeBlock(out, strings.Join(lines, "\n")+"\n", cfg.MaxOutputBytes)
+	}
+	if len(others) > 0 {
+		fmt.Fprintln(out)
+		writeCodeBlock(out, strings.Join(others, "\n")+"\n", cfg.MaxOutputBytes)
+	}
+}
+
+func writeSlowest(out *bufio.Writer, exec *testjson.Execution, cfg Config) {
+	if cfg.NumSlowest == 0 {
+		return
+	}
+	tcs := aggregate.Slowest(exec, 0, cfg.NumSlowest)
+	if len(tcs) == 0 {
+		return
+	}
+	fmt.Fprintln(out, "\n### Slowest tests")
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "| Test | Elapsed |")
+	fmt.Fprintln(out, "|------|--------:|")
+	for _, tc := range tcs {
+		fmt.Fprintf(out, "| `%s` | %s |\n",
+			strings.ReplaceAll(testCaseName(tc), "|", `\|`),
+			testjson.FormatDurationAsSeconds(tc.Elapsed, 2))
+	}
+}
+
+// writeCodeBlock writes text to out as a fenced code block. The fence is
+// longer than any sequence of backticks in text, so that text can not end the
+// code block.
+func writeCodeBlock(out *bufio.Writer, text string, maxBytes int) {
+	text = truncate(text, maxBytes)
+	fence := "```"
+	for strings.Contains(text, fence) {
+		fence += "`"
+	}
+	fmt.Fprintln(out, fence)
+	out.WriteString(text) // nolint: errcheck
+	if !strings.HasSuffix(text, "\n") {
+		fmt.Fprintln(out)
+	}
+	fmt.Fprintln(out, fence)
+	fmt.Fprintln(out)
+}
+
+// truncate text to at most maxBytes, on a line boundary when possible.
+func truncate(text string, maxBytes int) string {
+	if maxBytes <= 0 || len(text) <= maxBytes {
+		return text
+	}
+	cut := text[:maxBytes]
+	if i := strings.LastIndex(cut, "\n"); i > 0 {
+		cut = cut[:i+1]
+	}
+	return cut + fmt.Sprintf("... (%d bytes truncated)\n", len(text)-len(cut))
+}
+
+var htmlEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+func escapeHTML(s string) string {
+	return htmlEscaper.Replace(s)
+}
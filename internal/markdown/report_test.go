@@ -0,0 +1,69 @@
+package markdown
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"gotest.tools/gotestsum/testjson"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/golden"
+)
+
+func TestWrite(t *testing.T) {
+	out := new(bytes.Buffer)
+	exec := createExecution(t)
+
+	err := Write(out, exec, Config{
+		MaxOutputBytes: 200,
+		NumSlowest:     3,
+		customElapsed:  2100 * time.Millisecond,
+	})
+	assert.NilError(t, err)
+	golden.Assert(t, out.String(), "markdown-report.golden")
+}
+
+func TestWrite_WithFailureClusters(t *testing.T) {
+	raw, err := ioutil.ReadFile("../../testjson/testdata/input/go-test-json-clusters.out")
+	assert.NilError(t, err)
+	exec, err := testjson.ScanTestOutput(testjson.ScanConfig{Stdout: bytes.NewReader(raw)})
+	assert.NilError(t, err)
+
+	out := new(bytes.Buffer)
+	err = Write(out, exec, Config{Clusters: true, customElapsed: 2100 * time.Millisecond})
+	assert.NilError(t, err)
+	golden.Assert(t, out.String(), "markdown-report-with-clusters.golden")
+}
+
+func TestTruncate(t *testing.T) {
+	text := "first line\nsecond line\nthird line\n"
+	assert.Equal(t, truncate(text, 0), text)
+	assert.Equal(t, truncate(text, 100), text)
+	assert.Equal(t, truncate(text, 25), "first line\nsecond line\n... (11 bytes truncated)\n")
+}
+
+func TestWriteCodeBlock_WithBackticks(t *testing.T) {
+	out := new(bytes.Buffer)
+	buf := bufio.NewWriter(out)
+	writeCodeBlock(buf, "some ```code```", 0)
+	assert.NilError(t, buf.Flush())
+	assert.Equal(t, out.String(), "````\nsome ```code```\n````\n\n")
+}
+
+func createExecution(t *testing.T) *testjson.Execution {
+	exec, err := testjson.ScanTestOutput(testjson.ScanConfig{
+		Stdout: readTestData(t, "out"),
+		Stderr: readTestData(t, "err"),
+	})
+	assert.NilError(t, err)
+	return exec
+}
+
+func readTestData(t *testing.T, stream string) io.Reader {
+	raw, err := ioutil.ReadFile("../../testjson/testdata/input/go-test-json." + stream)
+	assert.NilError(t, err)
+	return bytes.NewReader(raw)
+}
@@ -0,0 +1,238 @@
+/*
+Package metrics writes metrics about a testjson.Execution in the Prometheus
+text exposition format.
+
+The metrics describe a single run, so every metric is a gauge. The metrics can
+be collected by the textfile collector of the node_exporter, or pushed to a
+Pushgateway.
+*/
+package metrics
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"gotest.tools/gotestsum/testjson"
+)
+
+// Config used to write metrics.
+type Config struct {
+	// This is used for tests to have a consistent run duration
+	customElapsed time.Duration
+}
+
+// durationBuckets are the upper bounds of the buckets of the test duration
+// histogram, in seconds.
+var durationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 300}
+
+var results = []testjson.Action{testjson.ActionPass, testjson.ActionFail, testjson.ActionSkip}
+
+// Write the metrics for exec to out.
+func Write(out io.Writer, exec *testjson.Execution, cfg Config) error {
+	buf := bufio.NewWriter(out)
+	elapsed := exec.Elapsed()
+	if cfg.customElapsed != 0 {
+		elapsed = cfg.customElapsed
+	}
+	pkgs := exec.Packages()
+
+	writeHeader(buf, "gotestsum_run_duration_seconds", "gauge",
+		"Time elapsed for the whole test run.")
+	fmt.Fprintf(buf, "gotestsum_run_duration_seconds %s\n", formatFloat(elapsed.Seconds()))
+
+	writeHeader(buf, "gotestsum_run_errors", "gauge",
+		"Number of errors, like build failures, which are not attributed to a test.")
+	fmt.Fprintf(buf, "gotestsum_run_errors %d\n", exec.ErrorCount())
+
+	writeHeader(buf, "gotestsum_tests", "gauge",
+		"Number of tests by package and result. Reruns are counted separately.")
+	for _, name := range pkgs {
+		pkg := exec.Package(name)
+		counts := map[testjson.Action]int{
+			testjson.ActionPass: len(pkg.Passed),
+			testjson.ActionFail: len(pkg.Failed),
+			testjson.ActionSkip: len(pkg.Skipped),
+		}
+		for _, result := range results {
+			fmt.Fprintf(buf, "gotestsum_tests{package=%s,result=%s} %d\n",
+				quote(name), quote(string(result)), counts[result])
+		}
+	}
+
+	writeHeader(buf, "gotestsum_test_reruns", "gauge",
+		"Number of tests which were run again by --rerun-fails.")
+	for _, name := range pkgs {
+		fmt.Fprintf(buf, "gotestsum_test_reruns{package=%s} %d\n",
+			quote(name), countReruns(exec.Package(name)))
+	}
+
+	writeHeader(buf, "gotestsum_flaky_tests", "gauge",
+		"Number of tests which failed, and then passed when they were run again.")
+	for _, name := range pkgs {
+		fmt.Fprintf(buf, "gotestsum_flaky_tests{package=%s} %d\n",
+			quote(name), countFlaky(exec.Package(name)))
+	}
+
+	writeHeader(buf, "gotestsum_package_duration_seconds", "gauge",
+		"Time elapsed for the package, as reported by go test.")
+	for _, name := range pkgs {
+		fmt.Fprintf(buf, "gotestsum_package_duration_seconds{package=%s} %s\n",
+			quote(name), formatFloat(exec.Package(name).Elapsed().Seconds()))
+	}
+
+	writeHeader(buf, "gotestsum_coverage_ratio", "gauge",
+		"Ratio of statements covered by tests, for packages run with -cover.")
+	for _, name := range pkgs {
+		if ratio, ok := coverageRatio(exec.Package(name).Coverage()); ok {
+			fmt.Fprintf(buf, "gotestsum_coverage_ratio{package=%s} %s\n",
+				quote(name), formatFloat(ratio))
+		}
+	}
+
+	writeHeader(buf, "gotestsum_test_duration_seconds", "histogram",
+		"Time elapsed for each test that finished.")
+	for _, name := range pkgs {
+		writeHistogram(buf, name, exec.Package(name).TestCases())
+	}
+
+	if err := buf.Flush(); err != nil {
+		return fmt.Errorf("failed to write metrics: %w", err)
+	}
+	return nil
+}
+
+func writeHeader(out *bufio.Writer, name, kind, help string) {
+	fmt.Fprintf(out, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(out, "# TYPE %s %s\n", name, kind)
+}
+
+// writeHistogram writes the duration of each test case in tcs. Tests that did
+// not finish have a negative Elapsed, and are not included.
+func writeHistogram(out *bufio.Writer, pkg string, tcs []testjson.TestCase) {
+	counts := make([]int, len(durationBuckets))
+	var sum float64
+	var total int
+	for _, tc := range tcs {
+		if tc.Elapsed < 0 {
+			continue
+		}
+		total++
+		seconds := tc.Elapsed.Seconds()
+		sum += seconds
+		for i, bound := range durationBuckets {
+			if seconds <= bound {
+				counts[i]++
+			}
+		}
+	}
+	const name = "gotestsum_test_duration_seconds"
+	for i, bound := range durationBuckets {
+		fmt.Fprintf(out, "%s_bucket{package=%s,le=%s} %d\n",
+			name, quote(pkg), quote(formatFloat(bound)), counts[i])
+	}
+	fmt.Fprintf(out, "%s_bucket{package=%s,le=\"+Inf\"} %d\n", name, quote(pkg), total)
+	fmt.Fprintf(out, "%s_sum{package=%s} %s\n", name, quote(pkg), formatFloat(sum))
+	fmt.Fprintf(out, "%s_count{package=%s} %d\n", name, quote(pkg), total)
+}
+
+func countReruns(pkg *testjson.Package) int {
+	var n int
+	for _, tc := range pkg.TestCases() {
+		if tc.RunID > 0 {
+			n++
+		}
+	}
+	return n
+}
+
+func countFlaky(pkg *testjson.Package) int {
+	failed := make(map[testjson.TestName]bool)
+	for _, tc := range pkg.Failed {
+		failed[tc.Test] = true
+	}
+	flaky := make(map[testjson.TestName]bool)
+	for _, tc := range pkg.Passed {
+		if tc.RunID > 0 && failed[tc.Test] {
+			flaky[tc.Test] = true
+		}
+	}
+	return len(flaky)
+}
+
+// coverageRatio parses the coverage output of a package
+// (ex: coverage: 91.1% of statements) and returns the ratio (ex: 0.911).
+func coverageRatio(coverage string) (float64, bool) {
+	coverage = strings.TrimPrefix(coverage, "coverage: ")
+	idx := strings.Index(coverage, "%")
+	if idx < 0 {
+		return 0, false
+	}
+	// parse with an exponent instead of dividing by 100, to avoid rounding
+	// errors like 0.9109999999999999
+	ratio, err := strconv.ParseFloat(coverage[:idx]+"e-2", 64)
+	if err != nil {
+		return 0, false
+	}
+	return ratio, true
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// quote returns the value as a quoted label value.
+func quote(value string) string {
+	return `"` + labelEscaper.Replace(value) + `"`
+}
+
+// Push sends metrics to a Prometheus Pushgateway at baseURL. The metrics
+// replace any metrics previously pushed with the same job name.
+func Push(ctx context.Context, baseURL string, job string, metrics []byte) error {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return fmt.Errorf("invalid pushgateway URL: %w", err)
+	}
+	u.RawPath = strings.TrimSuffix(u.EscapedPath(), "/") + jobPath(job)
+	u.Path, err = url.PathUnescape(u.RawPath)
+	if err != nil {
+		return fmt.Errorf("invalid pushgateway URL: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, u.String(), bytes.NewReader(metrics))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; version=0.0.4")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() // nolint: errcheck
+	msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("pushgateway responded with %v: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
+
+// jobPath returns the path of the job in the Pushgateway API. A job name which
+// contains a slash is base64 encoded, because the Pushgateway would read the
+// slash as the end of the job name, even when it is escaped.
+func jobPath(job string) string {
+	if strings.Contains(job, "/") {
+		return "/metrics/job@base64/" + base64.URLEncoding.EncodeToString([]byte(job))
+	}
+	return "/metrics/job/" + url.PathEscape(job)
+}
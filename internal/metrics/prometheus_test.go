@@ -0,0 +1,136 @@
+package metrics
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/gotestsum/testjson"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/golden"
+)
+
+const firstRun = `{"Action":"run","Package":"example.com/pkg","Test":"TestFast"}
+{"Action":"pass","Package":"example.com/pkg","Test":"TestFast","Elapsed":0.001}
+{"Action":"run","Package":"example.com/pkg","Test":"TestFlaky"}
+{"Action":"fail","Package":"example.com/pkg","Test":"TestFlaky","Elapsed":0.3}
+{"Action":"run","Package":"example.com/pkg","Test":"TestSkip"}
+{"Action":"skip","Package":"example.com/pkg","Test":"TestSkip","Elapsed":0}
+{"Action":"output","Package":"example.com/pkg","Output":"coverage: 72.5% of statements\n"}
+{"Action":"fail","Package":"example.com/pkg","Elapsed":0.35}
+{"Action":"run","Package":"example.com/other","Test":"TestSlow"}
+{"Action":"pass","Package":"example.com/other","Test":"TestSlow","Elapsed":12}
+{"Action":"pass","Package":"example.com/other","Elapsed":12.1}
+`
+
+const rerun = `{"Action":"run","Package":"example.com/pkg","Test":"TestFlaky"}
+{"Action":"pass","Package":"example.com/pkg","Test":"TestFlaky","Elapsed":0.2}
+{"Action":"pass","Package":"example.com/pkg","Elapsed":0.25}
+`
+
+func newExecution(t *testing.T) *testjson.Execution {
+	t.Helper()
+	exec, err := testjson.ScanTestOutput(testjson.ScanConfig{
+		Stdout: strings.NewReader(firstRun),
+		Stderr: strings.NewReader("# example.com/broken\nbroken.go:3:1: syntax error\n"),
+	})
+	assert.NilError(t, err)
+	_, err = testjson.ScanTestOutput(testjson.ScanConfig{
+		Stdout:    strings.NewReader(rerun),
+		Execution: exec,
+		RunID:     1,
+	})
+	assert.NilError(t, err)
+	return exec
+}
+
+func TestWrite(t *testing.T) {
+	out := new(bytes.Buffer)
+	err := Write(out, newExecution(t), Config{customElapsed: 13 * time.Second})
+	assert.NilError(t, err)
+	golden.Assert(t, out.String(), "metrics.golden")
+}
+
+func TestWriteHistogram_SkipsIncompleteTests(t *testing.T) {
+	tcs := []testjson.TestCase{
+		{Elapsed: 2 * time.Second},
+		{Elapsed: -1},
+	}
+	out := new(bytes.Buffer)
+	buf := bufio.NewWriter(out)
+	writeHistogram(buf, "example.com/pkg", tcs)
+	assert.NilError(t, buf.Flush())
+
+	assert.Assert(t, strings.Contains(out.String(),
+		`gotestsum_test_duration_seconds_bucket{package="example.com/pkg",le="0.005"} 0`))
+	assert.Assert(t, strings.Contains(out.String(),
+		`gotestsum_test_duration_seconds_sum{package="example.com/pkg"} 2`))
+	assert.Assert(t, strings.Contains(out.String(),
+		`gotestsum_test_duration_seconds_count{package="example.com/pkg"} 1`))
+}
+
+func TestCoverageRatio(t *testing.T) {
+	ratio, ok := coverageRatio("coverage: 91.1% of statements")
+	assert.Assert(t, ok)
+	assert.Equal(t, ratio, 0.911)
+
+	ratio, ok = coverageRatio("coverage: 50.0% of statements in ./...")
+	assert.Assert(t, ok)
+	assert.Equal(t, ratio, 0.5)
+
+	_, ok = coverageRatio("")
+	assert.Assert(t, !ok)
+}
+
+func TestQuote(t *testing.T) {
+	assert.Equal(t, quote("a\"b\\c\nd"), `"a\"b\\c\nd"`)
+}
+
+func TestPush(t *testing.T) {
+	var method, path, contentType, body string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw, _ := ioutil.ReadAll(r.Body)
+		method, path, contentType, body = r.Method, r.URL.Path, r.Header.Get("Content-Type"), string(raw)
+	}))
+	defer srv.Close()
+
+	err := Push(context.Background(), srv.URL+"/", "nightly tests", []byte("metric 1\n"))
+	assert.NilError(t, err)
+	assert.Equal(t, method, http.MethodPut)
+	assert.Equal(t, path, "/metrics/job/nightly tests")
+	assert.Equal(t, contentType, "text/plain; version=0.0.4")
+	assert.Equal(t, body, "metric 1\n")
+}
+
+func TestPush_EscapesJob(t *testing.T) {
+	var paths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.EscapedPath())
+	}))
+	defer srv.Close()
+
+	for _, job := range []string{"nightly?tests#1", "org/repo"} {
+		err := Push(context.Background(), srv.URL+"/gateway", job, []byte("metric 1\n"))
+		assert.NilError(t, err)
+	}
+	assert.DeepEqual(t, paths, []string{
+		"/gateway/metrics/job/nightly%3Ftests%231",
+		"/gateway/metrics/job@base64/b3JnL3JlcG8=",
+	})
+}
+
+func TestPush_ErrorResponse(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "text format parsing error", http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	err := Push(context.Background(), srv.URL, "job", []byte("bogus"))
+	assert.Error(t, err, "pushgateway responded with 400 Bad Request: text format parsing error")
+}
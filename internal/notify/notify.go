@@ -0,0 +1,152 @@
+// Package notify sends a desktop or terminal notification when a test run
+// completes.
+package notify
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"text/template"
+
+	"github.com/google/shlex"
+	"gotest.tools/gotestsum/internal/log"
+	"gotest.tools/gotestsum/testjson"
+)
+
+// Notification is the message sent by a Notifier.
+type Notification struct {
+	Title   string
+	Message string
+	// Failed is true when the test run failed.
+	Failed bool
+}
+
+// NewNotification returns a Notification which summarizes the result of exec.
+func NewNotification(exec *testjson.Execution) Notification {
+	total := exec.Total()
+	failed := len(exec.Failed())
+	skipped := len(exec.Skipped())
+	errors := exec.ErrorCount()
+
+	n := Notification{Title: "✅ Passed"}
+	switch {
+	case errors > 0:
+		n.Title = "⚠️ Errored"
+		n.Failed = true
+	case failed > 0:
+		n.Title = "❌ Failed"
+		n.Failed = true
+	case skipped > 0:
+		n.Title = "✅ Passed with skipped"
+	}
+
+	n.Message = fmt.Sprintf("%d Tests Run", total)
+	if errors > 0 {
+		n.Message += fmt.Sprintf(", %d Errored", errors)
+	}
+	if failed > 0 {
+		n.Message += fmt.Sprintf(", %d Failed", failed)
+	}
+	if skipped > 0 {
+		n.Message += fmt.Sprintf(", %d Skipped", skipped)
+	}
+	return n
+}
+
+// Notifier sends a Notification.
+type Notifier interface {
+	Notify(n Notification) error
+}
+
+// Names is the list of notifiers accepted by New, for use in help text.
+const Names = "notify-send, terminal, command=TEMPLATE"
+
+// New returns the Notifier identified by name. Output from the notifier is
+// written to out.
+func New(name string, out io.Writer) (Notifier, error) {
+	if tmpl := strings.TrimPrefix(name, "command="); tmpl != name {
+		return newCommandNotifier(tmpl, out)
+	}
+	switch name {
+	case "notify-send":
+		return notifySend{out: out}, nil
+	case "terminal":
+		return terminal{out: out}, nil
+	}
+	return nil, fmt.Errorf("unknown notifier %v, must be one of: %v", name, Names)
+}
+
+// notifySend uses the notify-send command to send a notification to the
+// freedesktop.org notification service over D-Bus.
+type notifySend struct {
+	out io.Writer
+}
+
+func (n notifySend) Notify(msg Notification) error {
+	urgency := "normal"
+	if msg.Failed {
+		urgency = "critical"
+	}
+	args := []string{"--app-name=gotestsum", "--urgency=" + urgency, msg.Title, msg.Message}
+	return run(n.out, "notify-send", args...)
+}
+
+// terminal rings the terminal bell, and sends an OSC 9 escape sequence which
+// is shown as a notification by terminals that support it (ex: iTerm2,
+// Windows Terminal, kitty). Other terminals ignore the sequence.
+type terminal struct {
+	out io.Writer
+}
+
+func (n terminal) Notify(msg Notification) error {
+	_, err := fmt.Fprintf(n.out, "\a\x1b]9;%s: %s\a", msg.Title, msg.Message)
+	return err
+}
+
+// commandNotifier runs a command to send a notification. Each argument of the
+// command is a text/template which is executed with the Notification.
+type commandNotifier struct {
+	out  io.Writer
+	args []*template.Template
+}
+
+func newCommandNotifier(raw string, out io.Writer) (*commandNotifier, error) {
+	args, err := shlex.Split(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse notify command: %w", err)
+	}
+	if len(args) == 0 {
+		return nil, fmt.Errorf("notify command must not be empty")
+	}
+	n := &commandNotifier{out: out}
+	for _, arg := range args {
+		tmpl, err := template.New("notify").Parse(arg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse notify command: %w", err)
+		}
+		n.args = append(n.args, tmpl)
+	}
+	return n, nil
+}
+
+func (n *commandNotifier) Notify(msg Notification) error {
+	args := make([]string, 0, len(n.args))
+	for _, tmpl := range n.args {
+		buf := new(bytes.Buffer)
+		if err := tmpl.Execute(buf, msg); err != nil {
+			return err
+		}
+		args = append(args, buf.String())
+	}
+	return run(n.out, args[0], args[1:]...)
+}
+
+func run(out io.Writer, name string, args ...string) error {
+	log.Debugf("exec: %s %s", name, args)
+	cmd := exec.Command(name, args...)
+	cmd.Stdout = out
+	cmd.Stderr = out
+	return cmd.Run()
+}
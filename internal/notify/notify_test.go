@@ -0,0 +1,78 @@
+package notify
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"gotest.tools/gotestsum/testjson"
+	"gotest.tools/v3/assert"
+)
+
+func TestNewNotification(t *testing.T) {
+	exec := newExecFromTestData(t)
+	n := NewNotification(exec)
+	expected := Notification{
+		Title:   "❌ Failed",
+		Message: "59 Tests Run, 13 Failed, 5 Skipped",
+		Failed:  true,
+	}
+	assert.DeepEqual(t, n, expected)
+}
+
+func TestNewNotification_Passed(t *testing.T) {
+	exec, err := testjson.ScanTestOutput(testjson.ScanConfig{
+		Stdout: strings.NewReader(`{"Action":"pass","Package":"example.com/pkg","Test":"TestOne"}
+{"Action":"pass","Package":"example.com/pkg"}
+`),
+	})
+	assert.NilError(t, err)
+	n := NewNotification(exec)
+	assert.DeepEqual(t, n, Notification{Title: "✅ Passed", Message: "1 Tests Run"})
+}
+
+func TestNew_UnknownNotifier(t *testing.T) {
+	_, err := New("bogus", nil)
+	assert.ErrorContains(t, err, "unknown notifier bogus")
+
+	_, err = New("command=", nil)
+	assert.ErrorContains(t, err, "notify command must not be empty")
+
+	_, err = New("command=notify {{.Title", nil)
+	assert.ErrorContains(t, err, "failed to parse notify command")
+}
+
+func TestTerminal_Notify(t *testing.T) {
+	buf := new(bytes.Buffer)
+	n, err := New("terminal", buf)
+	assert.NilError(t, err)
+
+	err = n.Notify(Notification{Title: "❌ Failed", Message: "3 Tests Run, 1 Failed"})
+	assert.NilError(t, err)
+	assert.Equal(t, buf.String(), "\a\x1b]9;❌ Failed: 3 Tests Run, 1 Failed\a")
+}
+
+func TestCommandNotifier_Notify(t *testing.T) {
+	buf := new(bytes.Buffer)
+	n, err := New(`command=echo "{{.Title}}" '{{if .Failed}}fail{{else}}pass{{end}}' {{.Message}}`, buf)
+	assert.NilError(t, err)
+
+	err = n.Notify(Notification{Title: "❌ Failed", Message: "3 Tests Run", Failed: true})
+	assert.NilError(t, err)
+	assert.Equal(t, buf.String(), "❌ Failed fail 3 Tests Run\n")
+}
+
+func newExecFromTestData(t *testing.T) *testjson.Execution {
+	t.Helper()
+	f, err := os.Open("../../testjson/testdata/input/go-test-json.out")
+	assert.NilError(t, err)
+	defer f.Close() // nolint: errcheck
+
+	exec, err := testjson.ScanTestOutput(testjson.ScanConfig{
+		Stdout: f,
+		Stderr: strings.NewReader(""),
+	})
+	assert.NilError(t, err)
+	return exec
+}
@@ -0,0 +1,283 @@
+package otlp
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"math"
+	"strconv"
+	"time"
+)
+
+// spanKindInternal is the value of SpanKind SPAN_KIND_INTERNAL.
+const spanKindInternal = 1
+
+// MarshalJSON encodes the trace as an OTLP ExportTraceServiceRequest using the
+// JSON protobuf encoding defined by the OTLP specification.
+func (t *Trace) MarshalJSON() ([]byte, error) {
+	spans := make([]jsonSpan, 0, len(t.Spans))
+	for _, span := range t.Spans {
+		s := jsonSpan{
+			TraceID:           hex.EncodeToString(span.TraceID[:]),
+			SpanID:            hex.EncodeToString(span.SpanID[:]),
+			Name:              span.Name,
+			Kind:              spanKindInternal,
+			StartTimeUnixNano: unixNano(span.Start),
+			EndTimeUnixNano:   unixNano(span.End),
+			Attributes:        jsonAttributes(span.Attributes),
+			Status:            jsonStatus{Code: int(span.Status.Code), Message: span.Status.Message},
+		}
+		if span.ParentSpanID != ([8]byte{}) {
+			s.ParentSpanID = hex.EncodeToString(span.ParentSpanID[:])
+		}
+		for _, event := range span.Events {
+			s.Events = append(s.Events, jsonEvent{
+				TimeUnixNano: unixNano(event.Time),
+				Name:         event.Name,
+				Attributes:   jsonAttributes(event.Attributes),
+			})
+		}
+		spans = append(spans, s)
+	}
+
+	req := jsonRequest{ResourceSpans: []jsonResourceSpans{{
+		Resource: jsonResource{Attributes: jsonAttributes(t.resourceAttributes())},
+		ScopeSpans: []jsonScopeSpans{{
+			Scope: jsonScope{Name: "gotestsum", Version: t.Version},
+			Spans: spans,
+		}},
+	}}}
+	return json.Marshal(req)
+}
+
+func (t *Trace) resourceAttributes() []KeyValue {
+	return []KeyValue{{Key: "service.name", Value: t.ServiceName}}
+}
+
+type jsonRequest struct {
+	ResourceSpans []jsonResourceSpans `json:"resourceSpans"`
+}
+
+type jsonResourceSpans struct {
+	Resource   jsonResource     `json:"resource"`
+	ScopeSpans []jsonScopeSpans `json:"scopeSpans"`
+}
+
+type jsonResource struct {
+	Attributes []jsonKeyValue `json:"attributes"`
+}
+
+type jsonScopeSpans struct {
+	Scope jsonScope  `json:"scope"`
+	Spans []jsonSpan `json:"spans"`
+}
+
+type jsonScope struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+type jsonSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              int            `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []jsonKeyValue `json:"attributes,omitempty"`
+	Events            []jsonEvent    `json:"events,omitempty"`
+	Status            jsonStatus     `json:"status"`
+}
+
+type jsonEvent struct {
+	TimeUnixNano string         `json:"timeUnixNano"`
+	Name         string         `json:"name"`
+	Attributes   []jsonKeyValue `json:"attributes,omitempty"`
+}
+
+type jsonStatus struct {
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+type jsonKeyValue struct {
+	Key   string                 `json:"key"`
+	Value map[string]interface{} `json:"value"`
+}
+
+func jsonAttributes(attrs []KeyValue) []jsonKeyValue {
+	result := make([]jsonKeyValue, 0, len(attrs))
+	for _, attr := range attrs {
+		var value map[string]interface{}
+		switch v := attr.Value.(type) {
+		case bool:
+			value = map[string]interface{}{"boolValue": v}
+		case int:
+			// 64 bit integers are encoded as strings in JSON
+			value = map[string]interface{}{"intValue": strconv.Itoa(v)}
+		case float64:
+			value = map[string]interface{}{"doubleValue": v}
+		default:
+			value = map[string]interface{}{"stringValue": attr.Value}
+		}
+		result = append(result, jsonKeyValue{Key: attr.Key, Value: value})
+	}
+	return result
+}
+
+func unixNano(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+// MarshalProto encodes the trace as an OTLP ExportTraceServiceRequest using
+// the protobuf binary encoding.
+func (t *Trace) MarshalProto() []byte {
+	scope := new(protoBuffer)
+	scope.string(1, "gotestsum")
+	scope.string(2, t.Version)
+
+	scopeSpans := new(protoBuffer)
+	scopeSpans.message(1, scope)
+	for _, span := range t.Spans {
+		scopeSpans.message(2, protoSpan(span))
+	}
+
+	resource := new(protoBuffer)
+	for _, attr := range t.resourceAttributes() {
+		resource.message(1, protoKeyValue(attr))
+	}
+
+	resourceSpans := new(protoBuffer)
+	resourceSpans.message(1, resource)
+	resourceSpans.message(2, scopeSpans)
+
+	req := new(protoBuffer)
+	req.message(1, resourceSpans)
+	return req.buf
+}
+
+func protoSpan(span Span) *protoBuffer {
+	b := new(protoBuffer)
+	b.bytes(1, span.TraceID[:])
+	b.bytes(2, span.SpanID[:])
+	if span.ParentSpanID != ([8]byte{}) {
+		b.bytes(4, span.ParentSpanID[:])
+	}
+	b.string(5, span.Name)
+	b.varint(6, spanKindInternal)
+	b.fixed64(7, uint64(span.Start.UnixNano()))
+	b.fixed64(8, uint64(span.End.UnixNano()))
+	for _, attr := range span.Attributes {
+		b.message(9, protoKeyValue(attr))
+	}
+	for _, event := range span.Events {
+		e := new(protoBuffer)
+		e.fixed64(1, uint64(event.Time.UnixNano()))
+		e.string(2, event.Name)
+		for _, attr := range event.Attributes {
+			e.message(3, protoKeyValue(attr))
+		}
+		b.message(11, e)
+	}
+	status := new(protoBuffer)
+	status.string(2, span.Status.Message)
+	status.varint(3, uint64(span.Status.Code))
+	b.message(15, status)
+	return b
+}
+
+func protoKeyValue(attr KeyValue) *protoBuffer {
+	// AnyValue is a oneof, so the field is written even when it is the zero
+	// value, otherwise the value would be missing instead of zero.
+	value := new(protoBuffer)
+	switch v := attr.Value.(type) {
+	case bool:
+		var n uint64
+		if v {
+			n = 1
+		}
+		value.tag(2, wireVarint)
+		value.uvarint(n)
+	case int:
+		value.tag(3, wireVarint)
+		value.uvarint(uint64(int64(v)))
+	case float64:
+		value.tag(4, wireFixed64)
+		value.uint64(math.Float64bits(v))
+	case string:
+		value.tag(1, wireBytes)
+		value.uvarint(uint64(len(v)))
+		value.buf = append(value.buf, v...)
+	}
+
+	kv := new(protoBuffer)
+	kv.string(1, attr.Key)
+	kv.message(2, value)
+	return kv
+}
+
+// protoBuffer encodes fields using the protobuf wire format. Fields with a
+// zero value are omitted, as they are by a protobuf library.
+type protoBuffer struct {
+	buf []byte
+}
+
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+)
+
+func (b *protoBuffer) tag(field int, wireType int) {
+	b.uvarint(uint64(field)<<3 | uint64(wireType))
+}
+
+func (b *protoBuffer) uvarint(v uint64) {
+	var scratch [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(scratch[:], v)
+	b.buf = append(b.buf, scratch[:n]...)
+}
+
+func (b *protoBuffer) uint64(v uint64) {
+	var scratch [8]byte
+	binary.LittleEndian.PutUint64(scratch[:], v)
+	b.buf = append(b.buf, scratch[:]...)
+}
+
+func (b *protoBuffer) varint(field int, v uint64) {
+	if v == 0 {
+		return
+	}
+	b.tag(field, wireVarint)
+	b.uvarint(v)
+}
+
+func (b *protoBuffer) fixed64(field int, v uint64) {
+	if v == 0 {
+		return
+	}
+	b.tag(field, wireFixed64)
+	b.uint64(v)
+}
+
+func (b *protoBuffer) bytes(field int, v []byte) {
+	if len(v) == 0 {
+		return
+	}
+	b.tag(field, wireBytes)
+	b.uvarint(uint64(len(v)))
+	b.buf = append(b.buf, v...)
+}
+
+func (b *protoBuffer) string(field int, v string) {
+	b.bytes(field, []byte(v))
+}
+
+// message encodes an embedded message. Unlike other fields an empty message
+// is not omitted, because the presence of the message may be significant.
+func (b *protoBuffer) message(field int, m *protoBuffer) {
+	b.tag(field, wireBytes)
+	b.uvarint(uint64(len(m.buf)))
+	b.buf = append(b.buf, m.buf...)
+}
@@ -0,0 +1,122 @@
+package otlp
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// Protocols supported by Export.
+const (
+	ProtocolProtobuf = "http/protobuf"
+	ProtocolJSON     = "http/json"
+)
+
+// ExportConfig used by Export.
+type ExportConfig struct {
+	// Endpoint is the URL of the OTLP receiver. If the URL has no path,
+	// the default path /v1/traces is used.
+	Endpoint string
+	// Protocol is one of ProtocolProtobuf or ProtocolJSON. Defaults to
+	// ProtocolProtobuf.
+	Protocol string
+	// Headers are sent with the request. Headers from
+	// $OTEL_EXPORTER_OTLP_HEADERS are also sent.
+	Headers map[string]string
+}
+
+// Export sends trace to an OTLP receiver over HTTP.
+func Export(ctx context.Context, trace *Trace, cfg ExportConfig) error {
+	endpoint, err := tracesURL(cfg.Endpoint)
+	if err != nil {
+		return err
+	}
+
+	var body []byte
+	contentType := "application/x-protobuf"
+	switch cfg.Protocol {
+	case ProtocolJSON:
+		contentType = "application/json"
+		if body, err = trace.MarshalJSON(); err != nil {
+			return err
+		}
+	case ProtocolProtobuf, "":
+		body = trace.MarshalProto()
+	default:
+		return fmt.Errorf("unsupported OTLP protocol %v", cfg.Protocol)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	for key, value := range parseHeaders(os.Getenv("OTEL_EXPORTER_OTLP_HEADERS")) {
+		req.Header.Set(key, value)
+	}
+	for key, value := range cfg.Headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() // nolint: errcheck
+	msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("OTLP receiver responded with %v: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
+
+func tracesURL(endpoint string) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", fmt.Errorf("invalid OTLP endpoint: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("invalid OTLP endpoint %v: scheme must be http or https", endpoint)
+	}
+	if u.Path == "" || u.Path == "/" {
+		u.Path = "/v1/traces"
+	}
+	return u.String(), nil
+}
+
+// parseHeaders parses a comma separated list of key=value pairs, using the
+// format of $OTEL_EXPORTER_OTLP_HEADERS.
+func parseHeaders(raw string) map[string]string {
+	headers := make(map[string]string)
+	for _, pair := range strings.Split(raw, ",") {
+		idx := strings.Index(pair, "=")
+		if idx < 1 {
+			continue
+		}
+		key := strings.TrimSpace(pair[:idx])
+		value, err := url.QueryUnescape(strings.TrimSpace(pair[idx+1:]))
+		if err != nil {
+			continue
+		}
+		headers[key] = value
+	}
+	return headers
+}
+
+// WriteFile writes trace to out as a single line of OTLP JSON, the format
+// used by the file exporter of the OpenTelemetry Collector. The file can be
+// imported later with the otlpjsonfile receiver.
+func WriteFile(out io.Writer, trace *Trace) error {
+	raw, err := trace.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	_, err = out.Write(append(raw, '\n'))
+	return err
+}
@@ -0,0 +1,167 @@
+package otlp
+
+import (
+	"context"
+	"encoding/binary"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/env"
+)
+
+func TestExport(t *testing.T) {
+	env.Patch(t, "OTEL_EXPORTER_OTLP_HEADERS", "Authorization=Bearer%20token,x-team=ci")
+	trace := &Trace{ServiceName: "gotestsum", Spans: []Span{{Name: "gotestsum"}}}
+
+	type request struct {
+		path, contentType, auth, team string
+		body                          []byte
+	}
+	var got request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		got = request{
+			path:        r.URL.Path,
+			contentType: r.Header.Get("Content-Type"),
+			auth:        r.Header.Get("Authorization"),
+			team:        r.Header.Get("x-team"),
+			body:        body,
+		}
+	}))
+	defer srv.Close()
+
+	t.Run("protobuf", func(t *testing.T) {
+		err := Export(context.Background(), trace, ExportConfig{Endpoint: srv.URL})
+		assert.NilError(t, err)
+		assert.Equal(t, got.path, "/v1/traces")
+		assert.Equal(t, got.contentType, "application/x-protobuf")
+		assert.Equal(t, got.auth, "Bearer token")
+		assert.Equal(t, got.team, "ci")
+		assert.DeepEqual(t, got.body, trace.MarshalProto())
+	})
+
+	t.Run("json with custom path", func(t *testing.T) {
+		err := Export(context.Background(), trace, ExportConfig{
+			Endpoint: srv.URL + "/custom/traces",
+			Protocol: ProtocolJSON,
+		})
+		assert.NilError(t, err)
+		assert.Equal(t, got.path, "/custom/traces")
+		assert.Equal(t, got.contentType, "application/json")
+		expected, err := trace.MarshalJSON()
+		assert.NilError(t, err)
+		assert.Equal(t, string(got.body), string(expected))
+	})
+}
+
+func TestExport_ErrorResponse(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "bad payload", http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	err := Export(context.Background(), &Trace{}, ExportConfig{Endpoint: srv.URL})
+	assert.Error(t, err, "OTLP receiver responded with 400 Bad Request: bad payload")
+
+	err = Export(context.Background(), &Trace{}, ExportConfig{Endpoint: "localhost:4318"})
+	assert.ErrorContains(t, err, "scheme must be http or https")
+}
+
+// field is a decoded protobuf field.
+type field struct {
+	num   int
+	value uint64
+	raw   []byte
+}
+
+func decodeFields(t *testing.T, b []byte) map[int][]field {
+	t.Helper()
+	fields := make(map[int][]field)
+	for len(b) > 0 {
+		tag, n := binary.Uvarint(b)
+		assert.Assert(t, n > 0)
+		b = b[n:]
+		f := field{num: int(tag >> 3)}
+		switch tag & 7 {
+		case wireVarint:
+			f.value, n = binary.Uvarint(b)
+			assert.Assert(t, n > 0)
+			b = b[n:]
+		case wireFixed64:
+			f.value = binary.LittleEndian.Uint64(b)
+			b = b[8:]
+		case wireBytes:
+			size, n := binary.Uvarint(b)
+			assert.Assert(t, n > 0)
+			f.raw = b[n : n+int(size)]
+			b = b[n+int(size):]
+		default:
+			t.Fatalf("unexpected wire type %d", tag&7)
+		}
+		fields[f.num] = append(fields[f.num], f)
+	}
+	return fields
+}
+
+func TestMarshalProto(t *testing.T) {
+	start := time.Unix(10, 5)
+	trace := &Trace{
+		ServiceName: "svc",
+		Version:     "v1",
+		Spans: []Span{{
+			TraceID:      [16]byte{1},
+			SpanID:       [8]byte{2},
+			ParentSpanID: [8]byte{3},
+			Name:         "TestOne",
+			Start:        start,
+			End:          start.Add(time.Second),
+			Attributes: []KeyValue{
+				{Key: "count", Value: 0},
+				{Key: "ok", Value: true},
+			},
+			Events: []Event{{Name: "test.failure", Time: start}},
+			Status: Status{Code: StatusError, Message: "test failed"},
+		}},
+	}
+
+	req := decodeFields(t, trace.MarshalProto())
+	resourceSpans := decodeFields(t, req[1][0].raw)
+
+	resource := decodeFields(t, resourceSpans[1][0].raw)
+	serviceName := decodeFields(t, resource[1][0].raw)
+	assert.Equal(t, string(serviceName[1][0].raw), "service.name")
+	assert.Equal(t, string(decodeFields(t, serviceName[2][0].raw)[1][0].raw), "svc")
+
+	scopeSpans := decodeFields(t, resourceSpans[2][0].raw)
+	scope := decodeFields(t, scopeSpans[1][0].raw)
+	assert.Equal(t, string(scope[1][0].raw), "gotestsum")
+	assert.Equal(t, string(scope[2][0].raw), "v1")
+
+	span := decodeFields(t, scopeSpans[2][0].raw)
+	assert.DeepEqual(t, span[1][0].raw, trace.Spans[0].TraceID[:])
+	assert.DeepEqual(t, span[2][0].raw, trace.Spans[0].SpanID[:])
+	assert.DeepEqual(t, span[4][0].raw, trace.Spans[0].ParentSpanID[:])
+	assert.Equal(t, string(span[5][0].raw), "TestOne")
+	assert.Equal(t, span[6][0].value, uint64(spanKindInternal))
+	assert.Equal(t, span[7][0].value, uint64(start.UnixNano()))
+	assert.Equal(t, span[8][0].value, uint64(start.Add(time.Second).UnixNano()))
+
+	assert.Equal(t, len(span[9]), 2)
+	count := decodeFields(t, span[9][0].raw)
+	countValue := decodeFields(t, count[2][0].raw)
+	assert.Equal(t, len(countValue[3]), 1, "zero int value must be encoded")
+	assert.Equal(t, countValue[3][0].value, uint64(0))
+	ok := decodeFields(t, span[9][1].raw)
+	assert.Equal(t, decodeFields(t, ok[2][0].raw)[2][0].value, uint64(1))
+
+	event := decodeFields(t, span[11][0].raw)
+	assert.Equal(t, string(event[2][0].raw), "test.failure")
+
+	status := decodeFields(t, span[15][0].raw)
+	assert.Equal(t, string(status[2][0].raw), "test failed")
+	assert.Equal(t, status[3][0].value, uint64(StatusError))
+}
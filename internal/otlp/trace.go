@@ -0,0 +1,356 @@
+/*
+Package otlp exports a testjson.Execution as an OpenTelemetry trace, using the
+OTLP protocol.
+
+The trace has a root span for the gotestsum invocation, a child span for each
+package, and a span for each TestCase. Subtests are children of the span of
+their parent test.
+*/
+package otlp
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"gotest.tools/gotestsum/testjson"
+)
+
+// Config used to create a trace.
+type Config struct {
+	// ServiceName is used as the service.name resource attribute. Defaults to
+	// the value of $OTEL_SERVICE_NAME, or gotestsum.
+	ServiceName string
+	// Version is the version of gotestsum, used as the instrumentation scope
+	// version.
+	Version string
+	// TraceParent is a W3C traceparent header value. When set the root span is
+	// created as a child of the parent span, in the same trace. Defaults to the
+	// value of $TRACEPARENT.
+	TraceParent string
+	// NewRoot starts a new trace, ignoring TraceParent and $TRACEPARENT.
+	NewRoot bool
+	// Attributes are added to the root span.
+	Attributes []KeyValue
+
+	// rand is used to generate IDs. Tests set it to get consistent IDs.
+	rand io.Reader
+	// This is used for tests to have a consistent end time for the root span.
+	customEnd time.Time
+}
+
+// Trace is the set of spans created from an Execution.
+type Trace struct {
+	ServiceName string
+	Version     string
+	Spans       []Span
+}
+
+// Span is a named operation with a start and end time.
+type Span struct {
+	TraceID      [16]byte
+	SpanID       [8]byte
+	ParentSpanID [8]byte
+	Name         string
+	Start        time.Time
+	End          time.Time
+	Attributes   []KeyValue
+	Events       []Event
+	Status       Status
+}
+
+// Event is a named point in time on a Span.
+type Event struct {
+	Name       string
+	Time       time.Time
+	Attributes []KeyValue
+}
+
+// StatusCode of a Span.
+type StatusCode int
+
+const (
+	StatusUnset StatusCode = 0
+	StatusOK    StatusCode = 1
+	StatusError StatusCode = 2
+)
+
+// Status of a Span.
+type Status struct {
+	Code    StatusCode
+	Message string
+}
+
+// KeyValue is an attribute of a Span or Event. Value must be a string, bool,
+// int, or float64.
+type KeyValue struct {
+	Key   string
+	Value interface{}
+}
+
+// NewTrace creates a Trace from exec.
+func NewTrace(exec *testjson.Execution, cfg Config) (*Trace, error) {
+	if cfg.ServiceName == "" {
+		cfg.ServiceName = os.Getenv("OTEL_SERVICE_NAME")
+	}
+	if cfg.ServiceName == "" {
+		cfg.ServiceName = "gotestsum"
+	}
+	if cfg.TraceParent == "" {
+		cfg.TraceParent = os.Getenv("TRACEPARENT")
+	}
+	if cfg.NewRoot {
+		cfg.TraceParent = ""
+	}
+	if cfg.rand == nil {
+		cfg.rand = rand.Reader
+	}
+
+	b := &builder{cfg: cfg, exec: exec}
+	root := Span{Name: "gotestsum"}
+	if cfg.TraceParent != "" {
+		var err error
+		root.TraceID, root.ParentSpanID, err = parseTraceParent(cfg.TraceParent)
+		if err != nil {
+			return nil, err
+		}
+	} else if err := b.newID(root.TraceID[:]); err != nil {
+		return nil, err
+	}
+	if err := b.newID(root.SpanID[:]); err != nil {
+		return nil, err
+	}
+	root.Start = startTime(exec)
+	b.spans = append(b.spans, root)
+
+	for _, name := range exec.Packages() {
+		if err := b.addPackage(name, root); err != nil {
+			return nil, err
+		}
+	}
+	b.endRoot()
+	return &Trace{ServiceName: cfg.ServiceName, Version: cfg.Version, Spans: b.spans}, nil
+}
+
+type builder struct {
+	cfg   Config
+	exec  *testjson.Execution
+	spans []Span
+}
+
+func (b *builder) newID(id []byte) error {
+	if _, err := io.ReadFull(b.cfg.rand, id); err != nil {
+		return fmt.Errorf("failed to generate span ID: %w", err)
+	}
+	return nil
+}
+
+func (b *builder) newSpan(name string, parent Span) (Span, error) {
+	span := Span{Name: name, TraceID: parent.TraceID, ParentSpanID: parent.SpanID}
+	return span, b.newID(span.SpanID[:])
+}
+
+func (b *builder) addPackage(name string, root Span) error {
+	pkg := b.exec.Package(name)
+	span, err := b.newSpan(name, root)
+	if err != nil {
+		return err
+	}
+
+	results := make(map[int]testjson.Action, len(pkg.Failed)+len(pkg.Skipped))
+	for _, tc := range pkg.Failed {
+		results[tc.ID] = testjson.ActionFail
+	}
+	for _, tc := range pkg.Skipped {
+		results[tc.ID] = testjson.ActionSkip
+	}
+
+	tcs := pkg.TestCases()
+	sort.Slice(tcs, func(i, j int) bool {
+		return tcs[i].ID < tcs[j].ID
+	})
+
+	span.Start = root.Start
+	if len(tcs) > 0 {
+		span.Start = tcs[0].Time
+	}
+	span.End = span.Start.Add(pkg.Elapsed())
+	span.Attributes = []KeyValue{
+		{Key: "test.package", Value: name},
+		{Key: "test.result", Value: string(pkg.Result())},
+		{Key: "test.total", Value: pkg.Total},
+		{Key: "test.failed", Value: len(pkg.Failed)},
+		{Key: "test.skipped", Value: len(pkg.Skipped)},
+	}
+	span.Status = statusFromResult(pkg.Result())
+	if pkg.TestMainFailed() {
+		// the failure is from the package, not any one test
+		tc := testjson.TestCase{Package: name}
+		span.Events = append(span.Events, failureEvent(b.exec, tc, span.End))
+	}
+
+	index := len(b.spans)
+	b.spans = append(b.spans, span)
+
+	type runName struct {
+		runID int
+		name  string
+	}
+	parents := make(map[runName]Span)
+	for _, tc := range tcs {
+		parent := b.spans[index]
+		if p, ok := parents[runName{tc.RunID, tc.Test.Parent()}]; ok {
+			parent = p
+		}
+		tcSpan, err := b.newTestCaseSpan(tc, results, parent)
+		if err != nil {
+			return err
+		}
+		parents[runName{tc.RunID, tc.Test.Name()}] = tcSpan
+		b.spans = append(b.spans, tcSpan)
+
+		if tcSpan.End.After(b.spans[index].End) {
+			b.spans[index].End = tcSpan.End
+		}
+	}
+	return nil
+}
+
+// newTestCaseSpan creates the span for tc. results maps the ID of each failed
+// and skipped test case in the package to its result, any other test passed.
+func (b *builder) newTestCaseSpan(
+	tc testjson.TestCase,
+	results map[int]testjson.Action,
+	parent Span,
+) (Span, error) {
+	span, err := b.newSpan(tc.Test.Name(), parent)
+	if err != nil {
+		return span, err
+	}
+	span.Start = tc.Time
+	span.End = tc.Time.Add(tc.Elapsed)
+	if tc.Elapsed < 0 {
+		// the test never finished, so it ran until the end of the run
+		span.End = b.runEnd()
+	}
+
+	result, ok := results[tc.ID]
+	if !ok {
+		result = testjson.ActionPass
+	}
+	span.Attributes = []KeyValue{
+		{Key: "test.package", Value: tc.Package},
+		{Key: "test.name", Value: tc.Test.Name()},
+		{Key: "test.result", Value: string(result)},
+		{Key: "test.run_id", Value: tc.RunID},
+		{Key: "test.elapsed", Value: tc.Elapsed.Seconds()},
+	}
+	span.Status = statusFromResult(result)
+	if result == testjson.ActionFail {
+		span.Events = append(span.Events, failureEvent(b.exec, tc, span.End))
+	}
+	return span, nil
+}
+
+func statusFromResult(result testjson.Action) Status {
+	switch result {
+	case testjson.ActionFail:
+		return Status{Code: StatusError, Message: "test failed"}
+	case testjson.ActionPass:
+		return Status{Code: StatusOK}
+	default:
+		return Status{}
+	}
+}
+
+func failureEvent(exec *testjson.Execution, tc testjson.TestCase, t time.Time) Event {
+	return Event{
+		Name: "test.failure",
+		Time: t,
+		Attributes: []KeyValue{
+			{Key: "test.output", Value: strings.Join(exec.OutputLines(tc), "")},
+		},
+	}
+}
+
+// startTime returns the time the execution started, or the time of the first
+// test when the input was read from a file written by an earlier run.
+func startTime(exec *testjson.Execution) time.Time {
+	start := exec.Started()
+	for _, name := range exec.Packages() {
+		for _, tc := range exec.Package(name).TestCases() {
+			if !tc.Time.IsZero() && tc.Time.Before(start) {
+				start = tc.Time
+			}
+		}
+	}
+	return start
+}
+
+// runEnd returns the time the execution ended.
+func (b *builder) runEnd() time.Time {
+	if !b.cfg.customEnd.IsZero() {
+		return b.cfg.customEnd
+	}
+	return b.exec.Started().Add(b.exec.Elapsed())
+}
+
+// endRoot sets the end time of the root span so that it includes all other
+// spans, and sets the status from the result of the execution.
+func (b *builder) endRoot() {
+	root := &b.spans[0]
+	root.End = b.runEnd()
+	for _, span := range b.spans[1:] {
+		if span.End.After(root.End) {
+			root.End = span.End
+		}
+	}
+
+	failed := len(b.exec.Failed())
+	errors := b.exec.ErrorCount()
+	root.Attributes = append([]KeyValue{
+		{Key: "test.total", Value: b.exec.Total()},
+		{Key: "test.failed", Value: failed},
+		{Key: "test.skipped", Value: len(b.exec.Skipped())},
+		{Key: "test.errors", Value: errors},
+	}, b.cfg.Attributes...)
+	root.Status = Status{Code: StatusOK}
+	if failed > 0 || errors > 0 {
+		root.Status = Status{Code: StatusError, Message: "tests failed"}
+	}
+	for _, msg := range b.exec.Errors() {
+		root.Events = append(root.Events, Event{
+			Name:       "test.error",
+			Time:       root.End,
+			Attributes: []KeyValue{{Key: "test.output", Value: msg}},
+		})
+	}
+}
+
+// ErrInvalidTraceParent is returned by NewTrace when the traceparent is not a
+// valid W3C traceparent header value.
+var ErrInvalidTraceParent = errors.New("invalid traceparent")
+
+// parseTraceParent parses the trace ID and parent span ID from a W3C
+// traceparent header value (ex: 00-<trace-id>-<span-id>-01).
+func parseTraceParent(value string) ([16]byte, [8]byte, error) {
+	var traceID [16]byte
+	var spanID [8]byte
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 || len(parts[1]) != 32 || len(parts[2]) != 16 {
+		return traceID, spanID, fmt.Errorf("%w %q", ErrInvalidTraceParent, value)
+	}
+	if _, err := hex.Decode(traceID[:], []byte(parts[1])); err != nil {
+		return traceID, spanID, fmt.Errorf("%w %q: %v", ErrInvalidTraceParent, value, err)
+	}
+	if _, err := hex.Decode(spanID[:], []byte(parts[2])); err != nil {
+		return traceID, spanID, fmt.Errorf("%w %q: %v", ErrInvalidTraceParent, value, err)
+	}
+	return traceID, spanID, nil
+}
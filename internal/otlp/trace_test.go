@@ -0,0 +1,158 @@
+package otlp
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/gotestsum/testjson"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/env"
+	"gotest.tools/v3/golden"
+)
+
+// counter is an io.Reader which returns consistent IDs.
+type counter struct {
+	n byte
+}
+
+func (c *counter) Read(p []byte) (int, error) {
+	c.n++
+	for i := range p {
+		p[i] = c.n
+	}
+	return len(p), nil
+}
+
+const firstRun = `{"Time":"2022-06-19T13:44:44.100Z","Action":"run","Package":"example.com/pkg","Test":"TestPass"}
+{"Time":"2022-06-19T13:44:44.200Z","Action":"pass","Package":"example.com/pkg","Test":"TestPass","Elapsed":0.1}
+{"Time":"2022-06-19T13:44:44.200Z","Action":"run","Package":"example.com/pkg","Test":"TestParent"}
+{"Time":"2022-06-19T13:44:44.210Z","Action":"run","Package":"example.com/pkg","Test":"TestParent/sub"}
+{"Time":"2022-06-19T13:44:44.220Z","Action":"output","Package":"example.com/pkg","Test":"TestParent/sub","Output":"    parent_test.go:12: it broke\n"}
+{"Time":"2022-06-19T13:44:44.230Z","Action":"fail","Package":"example.com/pkg","Test":"TestParent/sub","Elapsed":0.02}
+{"Time":"2022-06-19T13:44:44.240Z","Action":"fail","Package":"example.com/pkg","Test":"TestParent","Elapsed":0.04}
+{"Time":"2022-06-19T13:44:44.250Z","Action":"run","Package":"example.com/pkg","Test":"TestSkip"}
+{"Time":"2022-06-19T13:44:44.250Z","Action":"skip","Package":"example.com/pkg","Test":"TestSkip","Elapsed":0}
+{"Time":"2022-06-19T13:44:44.300Z","Action":"fail","Package":"example.com/pkg","Elapsed":0.3}
+{"Time":"2022-06-19T13:44:44.300Z","Action":"output","Package":"example.com/badmain","Output":"main exited\n"}
+{"Time":"2022-06-19T13:44:44.310Z","Action":"fail","Package":"example.com/badmain","Elapsed":0.01}
+`
+
+const rerun = `{"Time":"2022-06-19T13:44:45.000Z","Action":"run","Package":"example.com/pkg","Test":"TestParent"}
+{"Time":"2022-06-19T13:44:45.010Z","Action":"run","Package":"example.com/pkg","Test":"TestParent/sub"}
+{"Time":"2022-06-19T13:44:45.020Z","Action":"pass","Package":"example.com/pkg","Test":"TestParent/sub","Elapsed":0.01}
+{"Time":"2022-06-19T13:44:45.030Z","Action":"pass","Package":"example.com/pkg","Test":"TestParent","Elapsed":0.03}
+{"Time":"2022-06-19T13:44:45.040Z","Action":"pass","Package":"example.com/pkg","Elapsed":0.04}
+`
+
+func newExecution(t *testing.T) *testjson.Execution {
+	t.Helper()
+	exec, err := testjson.ScanTestOutput(testjson.ScanConfig{
+		Stdout: strings.NewReader(firstRun),
+	})
+	assert.NilError(t, err)
+	_, err = testjson.ScanTestOutput(testjson.ScanConfig{
+		Stdout:    strings.NewReader(rerun),
+		Execution: exec,
+		RunID:     1,
+	})
+	assert.NilError(t, err)
+	return exec
+}
+
+func newConfig() Config {
+	return Config{
+		ServiceName: "gotestsum",
+		Version:     "v1.2.3",
+		rand:        &counter{},
+		customEnd:   time.Date(2022, 6, 19, 13, 44, 46, 0, time.UTC),
+	}
+}
+
+func TestNewTrace(t *testing.T) {
+	env.Patch(t, "TRACEPARENT", "")
+	trace, err := NewTrace(newExecution(t), newConfig())
+	assert.NilError(t, err)
+
+	raw, err := json.Marshal(trace)
+	assert.NilError(t, err)
+	out := new(bytes.Buffer)
+	assert.NilError(t, json.Indent(out, raw, "", "  "))
+	golden.Assert(t, out.String(), "trace.golden.json")
+}
+
+func TestNewTrace_SubtestsAndReruns(t *testing.T) {
+	env.Patch(t, "TRACEPARENT", "")
+	trace, err := NewTrace(newExecution(t), newConfig())
+	assert.NilError(t, err)
+
+	byName := map[string][]Span{}
+	for _, span := range trace.Spans {
+		byName[span.Name] = append(byName[span.Name], span)
+	}
+	root := byName["gotestsum"][0]
+	pkg := byName["example.com/pkg"][0]
+	assert.Equal(t, pkg.ParentSpanID, root.SpanID)
+
+	parents := byName["TestParent"]
+	subs := byName["TestParent/sub"]
+	assert.Equal(t, len(parents), 2)
+	assert.Equal(t, len(subs), 2)
+	for i := range parents {
+		assert.Equal(t, parents[i].ParentSpanID, pkg.SpanID)
+		assert.Equal(t, subs[i].ParentSpanID, parents[i].SpanID)
+	}
+	assert.Equal(t, subs[0].Status.Code, StatusError)
+	assert.Equal(t, subs[1].Status.Code, StatusOK)
+	assert.DeepEqual(t, subs[1].Attributes[3], KeyValue{Key: "test.run_id", Value: 1})
+}
+
+func TestNewTrace_WithTraceParent(t *testing.T) {
+	cfg := newConfig()
+	cfg.TraceParent = "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"
+	trace, err := NewTrace(newExecution(t), cfg)
+	assert.NilError(t, err)
+
+	root := trace.Spans[0]
+	assert.Equal(t, root.Name, "gotestsum")
+	assert.DeepEqual(t, root.TraceID, [16]byte{
+		0x0a, 0xf7, 0x65, 0x19, 0x16, 0xcd, 0x43, 0xdd,
+		0x84, 0x48, 0xeb, 0x21, 0x1c, 0x80, 0x31, 0x9c})
+	assert.DeepEqual(t, root.ParentSpanID, [8]byte{0xb7, 0xad, 0x6b, 0x71, 0x69, 0x20, 0x33, 0x31})
+	for _, span := range trace.Spans {
+		assert.Equal(t, span.TraceID, root.TraceID)
+	}
+
+	cfg.TraceParent = "bogus"
+	_, err = NewTrace(newExecution(t), cfg)
+	assert.ErrorIs(t, err, ErrInvalidTraceParent)
+	assert.ErrorContains(t, err, "invalid traceparent")
+
+	cfg.NewRoot = true
+	trace, err = NewTrace(newExecution(t), cfg)
+	assert.NilError(t, err)
+	assert.Equal(t, trace.Spans[0].ParentSpanID, [8]byte{})
+}
+
+func TestNewTrace_IncompleteTest(t *testing.T) {
+	env.Patch(t, "TRACEPARENT", "")
+	source := `{"Time":"2022-06-19T13:44:44.100Z","Action":"run","Package":"example.com/pkg","Test":"TestExits"}
+{"Time":"2022-06-19T13:44:44.200Z","Action":"output","Package":"example.com/pkg","Output":"FAIL\texample.com/pkg\t0.1s\n"}
+{"Time":"2022-06-19T13:44:44.200Z","Action":"fail","Package":"example.com/pkg","Elapsed":0.1}
+`
+	exec, err := testjson.ScanTestOutput(testjson.ScanConfig{
+		Stdout: strings.NewReader(source),
+	})
+	assert.NilError(t, err)
+
+	cfg := newConfig()
+	trace, err := NewTrace(exec, cfg)
+	assert.NilError(t, err)
+
+	span := trace.Spans[len(trace.Spans)-1]
+	assert.Equal(t, span.Name, "TestExits")
+	assert.Equal(t, span.Status.Code, StatusError)
+	assert.Equal(t, span.End, cfg.customEnd)
+}
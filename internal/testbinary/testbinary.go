@@ -0,0 +1,198 @@
+// Package testbinary compiles the tests for a package with 'go test -c', and
+// runs the test binary with the output read as test2json output.
+package testbinary
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"gotest.tools/gotestsum/internal/log"
+	"gotest.tools/gotestsum/testjson"
+)
+
+// Binary is a test binary compiled by 'go test -c'.
+type Binary struct {
+	// Path to the test binary.
+	Path string
+	// ImportPath of the package.
+	ImportPath string
+	// Dir is the directory of the package. The test binary is run from this
+	// directory, the same as 'go test'.
+	Dir string
+}
+
+// BuildConfig is the package and flags used to compile a test binary.
+type BuildConfig struct {
+	// Package to compile. Package must match exactly one package.
+	Package string
+	// BuildFlags are passed to 'go list' and 'go test -c', ex: -race or -tags.
+	BuildFlags []string
+	// Dir is the directory where the test binary is written.
+	Dir string
+	// Output receives the output of the go commands, ex: compile errors.
+	Output io.Writer
+}
+
+// Build compiles the tests for the package with 'go test -c'.
+func Build(cfg BuildConfig) (Binary, error) {
+	importPath, pkgDir, err := listPackage(cfg)
+	if err != nil {
+		return Binary{}, err
+	}
+
+	name := "gotestsum.test"
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	binary := Binary{
+		Path:       filepath.Join(cfg.Dir, name),
+		ImportPath: importPath,
+		Dir:        pkgDir,
+	}
+
+	args := append([]string{"test", "-c", "-o", binary.Path}, cfg.BuildFlags...)
+	args = append(args, cfg.Package)
+	cmd := exec.Command("go", args...)
+	cmd.Stdout = cfg.Output
+	cmd.Stderr = cfg.Output
+	log.Debugf("exec: %s", cmd.Args)
+	if err := cmd.Run(); err != nil {
+		return Binary{}, fmt.Errorf("failed to compile the tests for %v: %v", cfg.Package, err)
+	}
+	if _, err := os.Stat(binary.Path); err != nil {
+		return Binary{}, fmt.Errorf("package %v has no test files", cfg.Package)
+	}
+	return binary, nil
+}
+
+// listPackage returns the import path and the directory of the package.
+func listPackage(cfg BuildConfig) (string, string, error) {
+	args := append([]string{"list", "-f", "{{.ImportPath}}\t{{.Dir}}"}, cfg.BuildFlags...)
+	args = append(args, cfg.Package)
+	cmd := exec.Command("go", args...)
+	cmd.Stderr = cfg.Output
+	log.Debugf("exec: %s", cmd.Args)
+	out, err := cmd.Output()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to find package %v: %v", cfg.Package, err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	if len(lines) != 1 {
+		return "", "", fmt.Errorf("%v matched %d packages, only one package is allowed",
+			cfg.Package, len(lines))
+	}
+	fields := strings.SplitN(lines[0], "\t", 2)
+	if len(fields) != 2 {
+		return "", "", fmt.Errorf("unexpected output from go list: %v", lines[0])
+	}
+	return fields[0], fields[1], nil
+}
+
+// List returns the names of the tests, benchmarks, fuzz targets, and examples
+// in the test binary which match the regular expression, in the order they
+// are defined.
+func (b Binary) List(pattern string) ([]string, error) {
+	cmd := exec.Command(b.Path, "-test.list="+pattern)
+	cmd.Dir = b.Dir
+	log.Debugf("exec: %s", cmd.Args)
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the tests in %v: %v", b.ImportPath, err)
+	}
+	var names []string
+	scan := bufio.NewScanner(bytes.NewReader(out))
+	for scan.Scan() {
+		if name := strings.TrimSpace(scan.Text()); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names, scan.Err()
+}
+
+// Run the test binary once with args, and scan the output. The output of the
+// test binary is converted by 'go tool test2json', and each TestEvent is sent
+// to handler. A test binary which exits non-zero because a test failed is not
+// an error, the failure is in the returned Execution.
+//
+// The test binary is run directly, not by test2json, so that when ctx is
+// cancelled the test binary is killed, and test2json exits when it reads the
+// end of the output. Run returns the error from ctx when the run was
+// cancelled.
+func (b Binary) Run(ctx context.Context, handler testjson.EventHandler, args ...string) (*testjson.Execution, error) {
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close() // nolint: errcheck
+	defer writer.Close() // nolint: errcheck
+
+	convert := exec.Command("go", "tool", "test2json", "-t", "-p", b.ImportPath)
+	convert.Stdin = reader
+	stdout, err := convert.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	stderr, err := convert.StderrPipe()
+	if err != nil {
+		return nil, err
+	}
+	log.Debugf("exec: %s", convert.Args)
+	if err := convert.Start(); err != nil {
+		return nil, fmt.Errorf("failed to run %s: %w", strings.Join(convert.Args, " "), err)
+	}
+
+	test := exec.CommandContext(ctx, b.Path, append([]string{"-test.v=test2json"}, args...)...)
+	test.Dir = b.Dir
+	test.Stdout = writer
+	test.Stderr = writer
+	log.Debugf("exec: %s", test.Args)
+	testStartErr := test.Start()
+	// test2json reads until the test binary closes the pipe, so the pipe must
+	// only be open in the test binary.
+	_ = writer.Close()
+	_ = reader.Close()
+
+	execution, err := testjson.ScanTestOutput(testjson.ScanConfig{
+		Stdout:  stdout,
+		Stderr:  stderr,
+		Handler: handler,
+		// the output is no longer read when the scan fails, so the processes
+		// may never exit.
+		Stop: func() {
+			_ = convert.Process.Kill()
+			if testStartErr == nil {
+				_ = test.Process.Kill()
+			}
+		},
+	})
+	convertErr := convert.Wait()
+	var testErr error
+	if testStartErr == nil {
+		testErr = test.Wait()
+	}
+	switch {
+	case testStartErr != nil:
+		return nil, fmt.Errorf("failed to run %s: %w", strings.Join(test.Args, " "), testStartErr)
+	case err != nil:
+		return nil, fmt.Errorf("failed to scan testjson: %w", err)
+	case convertErr != nil:
+		return nil, fmt.Errorf("failed to run %s: %w", strings.Join(convert.Args, " "), convertErr)
+	case ctx.Err() != nil:
+		return execution, ctx.Err()
+	}
+
+	var exitErr *exec.ExitError
+	if testErr != nil && !errors.As(testErr, &exitErr) {
+		return nil, testErr
+	}
+	return execution, nil
+}
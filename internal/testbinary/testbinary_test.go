@@ -0,0 +1,117 @@
+package testbinary
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/gotestsum/testjson"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+)
+
+func TestBinary_Run_Cancelled(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for short run")
+	}
+	if strings.HasPrefix(runtime.Version(), "go1.1") {
+		t.Skip("-test.v=test2json requires go1.20+")
+	}
+
+	dir := fs.NewDir(t, "testbinary")
+	binary, err := Build(BuildConfig{
+		Package: "./testdata/blocking",
+		Dir:     dir.Path(),
+		Output:  new(bytes.Buffer),
+	})
+	assert.NilError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	handler := &cancelOnRun{cancel: cancel}
+
+	type result struct {
+		execution *testjson.Execution
+		err       error
+	}
+	done := make(chan result, 1)
+	go func() {
+		execution, err := binary.Run(ctx, handler, "-test.run=TestBlocks")
+		done <- result{execution: execution, err: err}
+	}()
+
+	select {
+	case res := <-done:
+		assert.ErrorIs(t, res.err, context.Canceled)
+		assert.Assert(t, res.execution != nil)
+		assert.Assert(t, handler.started, "the test did not start before the run was cancelled")
+	case <-time.After(30 * time.Second):
+		t.Fatal("Run did not return after the context was cancelled")
+	}
+}
+
+func TestBinary_Run_HandlerError(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for short run")
+	}
+	if strings.HasPrefix(runtime.Version(), "go1.1") {
+		t.Skip("-test.v=test2json requires go1.20+")
+	}
+
+	dir := fs.NewDir(t, "testbinary")
+	binary, err := Build(BuildConfig{
+		Package: "./testdata/blocking",
+		Dir:     dir.Path(),
+		Output:  new(bytes.Buffer),
+	})
+	assert.NilError(t, err)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := binary.Run(context.Background(), errorOnRun{}, "-test.run=TestBlocks")
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		assert.ErrorContains(t, err, "failed to scan testjson: handler failed")
+	case <-time.After(30 * time.Second):
+		t.Fatal("Run did not return after the handler failed")
+	}
+}
+
+// errorOnRun returns an error when the first test starts.
+type errorOnRun struct{}
+
+func (errorOnRun) Event(event testjson.TestEvent, _ *testjson.Execution) error {
+	if event.Action == testjson.ActionRun {
+		return errors.New("handler failed")
+	}
+	return nil
+}
+
+func (errorOnRun) Err(string) error {
+	return nil
+}
+
+// cancelOnRun cancels the run when the first test starts.
+type cancelOnRun struct {
+	cancel  func()
+	started bool
+}
+
+func (h *cancelOnRun) Event(event testjson.TestEvent, _ *testjson.Execution) error {
+	if event.Action == testjson.ActionRun {
+		h.started = true
+		h.cancel()
+	}
+	return nil
+}
+
+func (h *cancelOnRun) Err(string) error {
+	return nil
+}
@@ -0,0 +1,10 @@
+package blocking
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBlocks(t *testing.T) {
+	time.Sleep(time.Minute)
+}
@@ -5,8 +5,11 @@ import (
 	"os"
 
 	"gotest.tools/gotestsum/cmd"
+	"gotest.tools/gotestsum/cmd/tool/bisect"
 	"gotest.tools/gotestsum/cmd/tool/matrix"
 	"gotest.tools/gotestsum/cmd/tool/slowest"
+	"gotest.tools/gotestsum/cmd/tool/stress"
+	"gotest.tools/gotestsum/cmd/tool/timeline"
 	"gotest.tools/gotestsum/internal/log"
 )
 
@@ -57,6 +60,9 @@ func toolRun(name string, args []string) error {
 Commands:
     %[1]s slowest      find or skip the slowest tests
     %[1]s ci-matrix    use previous test runtime to place packages into optimal buckets
+    %[1]s timeline     write a timeline of a test run, and report on test parallelism
+    %[1]s stress       run a test repeatedly to reproduce a flaky failure
+    %[1]s bisect-order find the tests that make another test fail when they run first
 
 Use '%[1]s COMMAND --help' for command specific help.
 `, name)
@@ -71,6 +77,12 @@ Use '%[1]s COMMAND --help' for command specific help.
 		return slowest.Run(name+" "+next, rest)
 	case "ci-matrix":
 		return matrix.Run(name+" "+next, rest)
+	case "timeline":
+		return timeline.Run(name+" "+next, rest)
+	case "stress":
+		return stress.Run(name+" "+next, rest)
+	case "bisect-order":
+		return bisect.Run(name+" "+next, rest)
 	default:
 		fmt.Fprintln(os.Stderr, usage(name))
 		return fmt.Errorf("invalid command: %v %v", name, next)
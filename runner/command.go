@@ -0,0 +1,246 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"sync/atomic"
+	"syscall"
+
+	"gotest.tools/gotestsum/internal/log"
+)
+
+// Command returns the command used to run all the tests.
+func (c Config) Command() []string {
+	return c.command(Selection{})
+}
+
+func (c Config) command(sel Selection) []string {
+	var runFlag string
+	if sel.Run != "" {
+		runFlag = "-test.run=" + sel.Run
+	}
+
+	if c.RawCommand {
+		var result []string
+		result = append(result, c.Args...)
+		if runFlag != "" {
+			result = append(result, runFlag)
+		}
+		if sel.Package != "" {
+			result = append(result, sel.Package)
+		}
+		return result
+	}
+
+	args := c.Args
+	result := []string{"go", "test"}
+
+	if len(args) == 0 {
+		result = append(result, "-json")
+		if runFlag != "" {
+			result = append(result, runFlag)
+		}
+		return append(result, c.packageList(sel, "./...")...)
+	}
+
+	if boolArgIndex("json", args) < 0 {
+		result = append(result, "-json")
+	}
+
+	if runFlag != "" {
+		// Remove any existing run arg, it needs to be replaced with our new one
+		// and duplicate args are not allowed by 'go test'.
+		runIndex, runIndexEnd := argIndex("run", args)
+		if runIndex >= 0 && runIndexEnd < len(args) {
+			args = append(args[:runIndex:runIndex], args[runIndexEnd+1:]...)
+		}
+		result = append(result, runFlag)
+	}
+
+	pkgArgIndex := findPkgArgPosition(args)
+	result = append(result, args[:pkgArgIndex]...)
+	result = append(result, c.packageList(sel)...)
+	result = append(result, args[pkgArgIndex:]...)
+	return result
+}
+
+func (c Config) packageList(sel Selection, defPkgList ...string) []string {
+	switch {
+	case sel.Package != "":
+		return []string{sel.Package}
+	case len(c.Packages) > 0:
+		return c.Packages
+	case os.Getenv("TEST_DIRECTORY") != "":
+		return []string{os.Getenv("TEST_DIRECTORY")}
+	default:
+		return defPkgList
+	}
+}
+
+func boolArgIndex(flag string, args []string) int {
+	for i, arg := range args {
+		if arg == "-"+flag || arg == "--"+flag {
+			return i
+		}
+	}
+	return -1
+}
+
+func argIndex(flag string, args []string) (start, end int) {
+	for i, arg := range args {
+		if arg == "-"+flag || arg == "--"+flag {
+			return i, i + 1
+		}
+		if strings.HasPrefix(arg, "-"+flag+"=") || strings.HasPrefix(arg, "--"+flag+"=") {
+			return i, i
+		}
+	}
+	return -1, -1
+}
+
+// The package list is before the -args flag, or at the end of the args list
+// if the -args flag is not in args.
+// The -args flag is a 'go test' flag that indicates that all subsequent
+// args should be passed to the test binary. It requires that the list of
+// packages comes before -args, so we re-use it as a placeholder in the case
+// where some args must be passed to the test binary.
+func findPkgArgPosition(args []string) int {
+	if i := boolArgIndex("args", args); i >= 0 {
+		return i
+	}
+	return len(args)
+}
+
+type proc struct {
+	cmd    waiter
+	stdout io.Reader
+	stderr io.Reader
+	// signal is atomically set to the signal value when a signal is received
+	// by newSignalHandler.
+	signal int32
+}
+
+type waiter interface {
+	Wait() error
+}
+
+// startGoTestFn is a shim for testing
+var startGoTestFn = startGoTest
+
+func startGoTest(ctx context.Context, config Config, args []string) (*proc, error) {
+	if len(args) == 0 {
+		return nil, errors.New("missing command to run")
+	}
+
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stdin = config.Stdin
+	cmd.Dir = config.Dir
+
+	p := proc{cmd: cmd}
+	log.Debugf("exec: %s", cmd.Args)
+	var err error
+	p.stdout, err = cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	p.stderr, err = cmd.StderrPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to run %s: %w", strings.Join(cmd.Args, " "), err)
+	}
+	log.Debugf("go test pid: %d", cmd.Process.Pid)
+
+	ctx, cancel := context.WithCancel(ctx)
+	newSignalHandler(ctx, cmd.Process.Pid, &p)
+	p.cmd = &cancelWaiter{cancel: cancel, wrapped: p.cmd}
+	return &p, nil
+}
+
+// ExitCodeWithDefault returns the ExitStatus of a process from the error returned by
+// exec.Run(). If the exit status is not available an error is returned.
+func ExitCodeWithDefault(err error) int {
+	if err == nil {
+		return 0
+	}
+	if exiterr, ok := err.(ExitCoder); ok {
+		if code := exiterr.ExitCode(); code != -1 {
+			return code
+		}
+	}
+	return 127
+}
+
+// ExitCoder is implemented by errors that are the result of a process exiting
+// with a non-zero exit code.
+type ExitCoder interface {
+	ExitCode() int
+}
+
+// IsExitCoder returns true if err implements ExitCoder.
+func IsExitCoder(err error) bool {
+	_, ok := err.(ExitCoder)
+	return ok
+}
+
+type exitError struct {
+	num int
+}
+
+func (e exitError) Error() string {
+	return fmt.Sprintf("exit code %d", e.num)
+}
+
+func (e exitError) ExitCode() int {
+	return e.num
+}
+
+// signalExitCode is the base value added to a signal number to produce the
+// exit code value. This matches the behaviour of bash.
+const signalExitCode = 128
+
+func newSignalHandler(ctx context.Context, pid int, p *proc) {
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, os.Interrupt)
+
+	go func() {
+		defer signal.Stop(c)
+
+		select {
+		case <-ctx.Done():
+			return
+		case s := <-c:
+			atomic.StoreInt32(&p.signal, int32(s.(syscall.Signal)))
+
+			proc, err := os.FindProcess(pid)
+			if err != nil {
+				log.Errorf("failed to find pid of 'go test': %v", err)
+				return
+			}
+			if err := proc.Signal(s); err != nil {
+				log.Errorf("failed to interrupt 'go test': %v", err)
+				return
+			}
+		}
+	}()
+}
+
+// cancelWaiter wraps a waiter to cancel the context after the wrapped
+// Wait exits.
+type cancelWaiter struct {
+	cancel  func()
+	wrapped waiter
+}
+
+func (w *cancelWaiter) Wait() error {
+	err := w.wrapped.Wait()
+	w.cancel()
+	return err
+}
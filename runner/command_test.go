@@ -0,0 +1,229 @@
+package runner
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/env"
+)
+
+func TestConfig_command(t *testing.T) {
+	type testCase struct {
+		config    Config
+		selection Selection
+		env       []string
+		expected  []string
+	}
+
+	run := func(t *testing.T, name string, tc testCase) {
+		t.Helper()
+		runCase(t, name, func(t *testing.T) {
+			defer env.PatchAll(t, env.ToMap(tc.env))()
+			actual := tc.config.command(tc.selection)
+			assert.DeepEqual(t, actual, tc.expected)
+		})
+	}
+
+	run(t, "raw command", testCase{
+		config: Config{
+			RawCommand: true,
+			Args:       []string{"./script", "-test.timeout=20m"},
+		},
+		expected: []string{"./script", "-test.timeout=20m"},
+	})
+	run(t, "no args", testCase{
+		config:   Config{},
+		expected: []string{"go", "test", "-json", "./..."},
+	})
+	run(t, "no args, with rerunPackageList arg", testCase{
+		config: Config{
+			Packages: []string{"./pkg"},
+		},
+		expected: []string{"go", "test", "-json", "./pkg"},
+	})
+	run(t, "TEST_DIRECTORY env var no args", testCase{
+		config:   Config{},
+		env:      []string{"TEST_DIRECTORY=testdir"},
+		expected: []string{"go", "test", "-json", "testdir"},
+	})
+	run(t, "TEST_DIRECTORY env var with args", testCase{
+		config: Config{
+			Args: []string{"-tags=integration"},
+		},
+		env:      []string{"TEST_DIRECTORY=testdir"},
+		expected: []string{"go", "test", "-json", "-tags=integration", "testdir"},
+	})
+	run(t, "no -json arg", testCase{
+		config: Config{
+			Args: []string{"-timeout=2m", "./pkg"},
+		},
+		expected: []string{"go", "test", "-json", "-timeout=2m", "./pkg"},
+	})
+	run(t, "with -json arg", testCase{
+		config: Config{
+			Args: []string{"-json", "-timeout=2m", "./pkg"},
+		},
+		expected: []string{"go", "test", "-json", "-timeout=2m", "./pkg"},
+	})
+	run(t, "raw command, with selection", testCase{
+		config: Config{
+			RawCommand: true,
+			Args:       []string{"./script", "-test.timeout=20m"},
+		},
+		selection: Selection{
+			Run:     "TestOne|TestTwo",
+			Package: "./fails",
+		},
+		expected: []string{"./script", "-test.timeout=20m", "-test.run=TestOne|TestTwo", "./fails"},
+	})
+	run(t, "no args, with selection", testCase{
+		config: Config{},
+		selection: Selection{
+			Run:     "TestOne|TestTwo",
+			Package: "./fails",
+		},
+		expected: []string{"go", "test", "-json", "-test.run=TestOne|TestTwo", "./fails"},
+	})
+	run(t, "TEST_DIRECTORY env var, no args, with selection", testCase{
+		config: Config{},
+		selection: Selection{
+			Run:     "TestOne|TestTwo",
+			Package: "./fails",
+		},
+		env: []string{"TEST_DIRECTORY=testdir"},
+		// TEST_DIRECTORY should be overridden by the selection
+		expected: []string{"go", "test", "-json", "-test.run=TestOne|TestTwo", "./fails"},
+	})
+	run(t, "TEST_DIRECTORY env var, with args, with selection", testCase{
+		config: Config{
+			Args: []string{"-tags=integration"},
+		},
+		selection: Selection{
+			Run:     "TestOne|TestTwo",
+			Package: "./fails",
+		},
+		env:      []string{"TEST_DIRECTORY=testdir"},
+		expected: []string{"go", "test", "-json", "-test.run=TestOne|TestTwo", "-tags=integration", "./fails"},
+	})
+	run(t, "no -json arg, with selection", testCase{
+		config: Config{
+			Args:     []string{"-timeout=2m"},
+			Packages: []string{"./pkg"},
+		},
+		selection: Selection{
+			Run:     "TestOne|TestTwo",
+			Package: "./fails",
+		},
+		expected: []string{"go", "test", "-json", "-test.run=TestOne|TestTwo", "-timeout=2m", "./fails"},
+	})
+	run(t, "with -json arg, with selection", testCase{
+		config: Config{
+			Args:     []string{"-json", "-timeout=2m"},
+			Packages: []string{"./pkg"},
+		},
+		selection: Selection{
+			Run:     "TestOne|TestTwo",
+			Package: "./fails",
+		},
+		expected: []string{"go", "test", "-test.run=TestOne|TestTwo", "-json", "-timeout=2m", "./fails"},
+	})
+	run(t, "with args, with reunFailsPackageList args, with selection", testCase{
+		config: Config{
+			Args:     []string{"-timeout=2m"},
+			Packages: []string{"./pkg1", "./pkg2", "./pkg3"},
+		},
+		selection: Selection{
+			Run:     "TestOne|TestTwo",
+			Package: "./fails",
+		},
+		expected: []string{"go", "test", "-json", "-test.run=TestOne|TestTwo", "-timeout=2m", "./fails"},
+	})
+	run(t, "with args, with reunFailsPackageList", testCase{
+		config: Config{
+			Args:     []string{"-timeout=2m"},
+			Packages: []string{"./pkg1", "./pkg2", "./pkg3"},
+		},
+		expected: []string{"go", "test", "-json", "-timeout=2m", "./pkg1", "./pkg2", "./pkg3"},
+	})
+	run(t, "reunFailsPackageList args, with selection ", testCase{
+		config: Config{
+			Packages: []string{"./pkg1", "./pkg2", "./pkg3"},
+		},
+		selection: Selection{
+			Run:     "TestOne|TestTwo",
+			Package: "./fails",
+		},
+		expected: []string{"go", "test", "-json", "-test.run=TestOne|TestTwo", "./fails"},
+	})
+	run(t, "reunFailsPackageList args, with selection, with -args ", testCase{
+		config: Config{
+			Args:     []string{"before", "-args", "after"},
+			Packages: []string{"./pkg1"},
+		},
+		selection: Selection{
+			Run:     "TestOne|TestTwo",
+			Package: "./fails",
+		},
+		expected: []string{"go", "test", "-json", "-test.run=TestOne|TestTwo", "before", "./fails", "-args", "after"},
+	})
+	run(t, "reunFailsPackageList args, with selection, with -args at end", testCase{
+		config: Config{
+			Args:     []string{"before", "-args"},
+			Packages: []string{"./pkg1"},
+		},
+		selection: Selection{
+			Run:     "TestOne|TestTwo",
+			Package: "./fails",
+		},
+		expected: []string{"go", "test", "-json", "-test.run=TestOne|TestTwo", "before", "./fails", "-args"},
+	})
+	run(t, "reunFailsPackageList args, with -args at start", testCase{
+		config: Config{
+			Args:     []string{"-args", "after"},
+			Packages: []string{"./pkg1"},
+		},
+		expected: []string{"go", "test", "-json", "./pkg1", "-args", "after"},
+	})
+	run(t, "-run arg at start, with selection ", testCase{
+		config: Config{
+			Args:     []string{"-run=TestFoo", "-args"},
+			Packages: []string{"./pkg"},
+		},
+		selection: Selection{
+			Run:     "TestOne|TestTwo",
+			Package: "./fails",
+		},
+		expected: []string{"go", "test", "-json", "-test.run=TestOne|TestTwo", "./fails", "-args"},
+	})
+	run(t, "-run arg in middle, with selection ", testCase{
+		config: Config{
+			Args:     []string{"-count", "1", "--run", "TestFoo", "-args"},
+			Packages: []string{"./pkg"},
+		},
+		selection: Selection{
+			Run:     "TestOne|TestTwo",
+			Package: "./fails",
+		},
+		expected: []string{"go", "test", "-json", "-test.run=TestOne|TestTwo", "-count", "1", "./fails", "-args"},
+	})
+	run(t, "-run arg at end with missing value, with selection ", testCase{
+		config: Config{
+			Args:     []string{"-count", "1", "-run"},
+			Packages: []string{"./pkg"},
+		},
+		selection: Selection{
+			Run:     "TestOne|TestTwo",
+			Package: "./fails",
+		},
+		expected: []string{"go", "test", "-json", "-test.run=TestOne|TestTwo", "-count", "1", "-run", "./fails"},
+	})
+}
+
+func runCase(t *testing.T, name string, fn func(t *testing.T)) {
+	t.Helper()
+	t.Run(name, func(t *testing.T) {
+		t.Helper()
+		t.Log("case:", name)
+		fn(t)
+	})
+}
@@ -0,0 +1,76 @@
+package runner
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+
+	"gotest.tools/gotestsum/testjson"
+)
+
+// handler sends each event to the handlers from Config, prints the event
+// with the formatter, and ends the run when max failures is reached.
+type handler struct {
+	handlers  []testjson.EventHandler
+	formatter testjson.EventFormatter
+	err       *bufio.Writer
+	maxFails  int
+}
+
+func newHandler(config Config) *handler {
+	return &handler{
+		handlers:  config.Handlers,
+		formatter: config.Formatter,
+		err:       bufio.NewWriter(config.Stderr),
+		maxFails:  config.MaxFails,
+	}
+}
+
+func (h *handler) Err(text string) error {
+	h.writeErr(text)
+	for _, handler := range h.handlers {
+		if err := handler.Err(text); err != nil {
+			return err
+		}
+	}
+	// do not stop scanning if the stderr write fails
+	return nil
+}
+
+// writeErr writes text to stderr, and to the formatter if it formats stderr.
+// nolint:errcheck
+func (h *handler) writeErr(text string) {
+	h.err.WriteString(text)
+	h.err.WriteRune('\n')
+	h.err.Flush()
+	if f, ok := h.formatter.(testjson.ErrFormatter); ok {
+		f.FormatErr(text)
+	}
+}
+
+func (h *handler) Event(event testjson.TestEvent, execution *testjson.Execution) error {
+	// go1.24+ sends the build output as events, print it like the stderr of
+	// older versions
+	if event.Action == testjson.ActionBuildOutput {
+		h.writeErr(strings.TrimSuffix(event.Output, "\n"))
+	}
+
+	for _, handler := range h.handlers {
+		if err := handler.Event(event, execution); err != nil {
+			return err
+		}
+	}
+
+	if h.formatter != nil {
+		if err := h.formatter.Format(event, execution); err != nil {
+			return fmt.Errorf("failed to format event: %w", err)
+		}
+	}
+
+	if h.maxFails > 0 && len(execution.Failed()) >= h.maxFails {
+		return fmt.Errorf("ending test run because max failures was reached")
+	}
+	return nil
+}
+
+var _ testjson.EventHandler = &handler{}
@@ -0,0 +1,118 @@
+package runner
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	"gotest.tools/gotestsum/testjson"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/golden"
+)
+
+func TestHandler_Event_WithMissingActionFail(t *testing.T) {
+	out := new(bytes.Buffer)
+	format := testjson.NewEventFormatter(out, "testname", testjson.FormatOptions{})
+
+	source := golden.Get(t, "../../testjson/testdata/input/go-test-json-missing-test-fail.out")
+	cfg := testjson.ScanConfig{
+		Stdout:  bytes.NewReader(source),
+		Handler: &handler{formatter: format},
+	}
+	_, err := testjson.ScanTestOutput(cfg)
+	assert.NilError(t, err)
+
+	// confirm the artificial event was sent to the handler by checking the output
+	// of the formatter.
+	golden.Assert(t, out.String(), "event-handler-missing-test-fail-expected")
+}
+
+func TestHandler_Event_MaxFails(t *testing.T) {
+	format := testjson.NewEventFormatter(ioutil.Discard, "testname", testjson.FormatOptions{})
+
+	source := golden.Get(t, "../../testjson/testdata/input/go-test-json.out")
+	cfg := testjson.ScanConfig{
+		Stdout:  bytes.NewReader(source),
+		Handler: &handler{formatter: format, maxFails: 2},
+	}
+
+	_, err := testjson.ScanTestOutput(cfg)
+	assert.Error(t, err, "ending test run because max failures was reached")
+}
+
+func TestHandler_Event_SentToHandlers(t *testing.T) {
+	source := golden.Get(t, "../../testjson/testdata/input/go-test-json.out")
+	first, second := &countingHandler{}, &countingHandler{}
+	cfg := testjson.ScanConfig{
+		Stdout:  bytes.NewReader(source),
+		Stderr:  bytes.NewReader([]byte("build failed\n")),
+		Handler: &handler{handlers: []testjson.EventHandler{first, second}, err: bufio.NewWriter(ioutil.Discard)},
+	}
+
+	exec, err := testjson.ScanTestOutput(cfg)
+	assert.NilError(t, err)
+	assert.Assert(t, first.events > exec.Total())
+	assert.Equal(t, first.events, second.events)
+	assert.Equal(t, first.errs, 1)
+	assert.Equal(t, second.errs, 1)
+}
+
+func TestHandler_Err_WithErrFormatter(t *testing.T) {
+	out := new(bytes.Buffer)
+	errBuf := new(bytes.Buffer)
+	h := &handler{
+		formatter: testjson.NewEventFormatter(out, "quickfix", testjson.FormatOptions{}),
+		err:       bufio.NewWriter(errBuf),
+	}
+
+	assert.NilError(t, h.Err("# example.com/pkg"))
+	assert.NilError(t, h.Err("pkg/file.go:3:9: undefined: foo"))
+
+	assert.Equal(t, errBuf.String(), "# example.com/pkg\npkg/file.go:3:9: undefined: foo\n")
+	assert.Equal(t, out.String(), "pkg/file.go:3:9: undefined: foo\n")
+}
+
+type countingHandler struct {
+	events int
+	errs   int
+}
+
+func (h *countingHandler) Event(testjson.TestEvent, *testjson.Execution) error {
+	h.events++
+	return nil
+}
+
+func (h *countingHandler) Err(string) error {
+	h.errs++
+	return nil
+}
+
+func TestHandler_Event_BuildOutputWrittenToStderr(t *testing.T) {
+	out := new(bytes.Buffer)
+	stderr := new(bytes.Buffer)
+	format := testjson.NewEventFormatter(out, "quickfix", testjson.FormatOptions{})
+
+	source := golden.Get(t, "../../testjson/testdata/input/go-test-json-build-events.out")
+	cfg := testjson.ScanConfig{
+		Stdout:  bytes.NewReader(source),
+		Handler: &handler{formatter: format, err: bufio.NewWriter(stderr)},
+	}
+	_, err := testjson.ScanTestOutput(cfg)
+	assert.NilError(t, err)
+
+	expected := `# example.com/be/badtest_test
+# [example.com/be/badtest_test]
+badtest/badtest_test.go:9:14: fmt.Printf format %d has arg "not a number" of wrong type string
+# example.com/be/broken
+broken/broken.go:4:9: undefined: undefinedThing
+broken/broken.go:8:9: cannot use 1 (untyped int constant) as string value in return statement
+`
+	assert.Equal(t, stderr.String(), expected)
+
+	expected = `badtest/badtest_test.go:9:14: fmt.Printf format %d has arg "not a number" of wrong type string
+broken/broken.go:4:9: undefined: undefinedThing
+broken/broken.go:8:9: cannot use 1 (untyped int constant) as string value in return statement
+`
+	assert.Equal(t, out.String(), expected)
+}
@@ -0,0 +1,110 @@
+package runner
+
+import (
+	"strings"
+
+	"gotest.tools/gotestsum/testjson"
+)
+
+// ReproduceCommand returns the command that runs only the test case tc again,
+// with the same args as the original run. The command sets -count=1 so that
+// the result is not cached, and -shuffle=shuffleSeed when the tests were
+// shuffled. The command does not include -json, so that the output can be
+// read by a person.
+//
+// When tc is the package, because the package failed without a failed test,
+// the command runs all the tests in the package.
+//
+// Any packages in the args are replaced by the package of the test.
+func (c Config) ReproduceCommand(tc testjson.TestCase, shuffleSeed string) []string {
+	sel := Selection{Package: tc.Package}
+	if tc.Test != "" {
+		sel = SelectionForTestCase(tc)
+	}
+	if c.RawCommand {
+		return c.command(sel)
+	}
+
+	args := removePackageArgs(c.Args)
+	args = removeArg(args, "run")
+	args = removeArg(args, "count")
+	args = removeArg(args, "shuffle")
+	if i := boolArgIndex("json", args); i >= 0 {
+		args = append(args[:i:i], args[i+1:]...)
+	}
+
+	result := []string{"go", "test"}
+	if sel.Run != "" {
+		result = append(result, "-run="+sel.Run)
+	}
+	result = append(result, "-count=1")
+	if shuffleSeed != "" {
+		result = append(result, "-shuffle="+shuffleSeed)
+	}
+	pkgArgIndex := findPkgArgPosition(args)
+	result = append(result, args[:pkgArgIndex]...)
+	result = append(result, sel.Package)
+	result = append(result, args[pkgArgIndex:]...)
+	return result
+}
+
+// removeArg returns args without the flag, and the value of the flag.
+func removeArg(args []string, flag string) []string {
+	start, end := argIndex(flag, args)
+	if start < 0 || end >= len(args) {
+		return args
+	}
+	return append(args[:start:start], args[end+1:]...)
+}
+
+// goTestBoolFlags are the 'go test' and 'go build' flags which do not accept
+// a value as the next argument. Any other flag without an '=' is followed by
+// its value.
+var goTestBoolFlags = map[string]bool{
+	"a": true, "asan": true, "benchmem": true, "c": true, "cover": true,
+	"failfast": true, "fullpath": true, "i": true, "json": true,
+	"linkshared": true, "modcacherw": true, "msan": true, "n": true,
+	"race": true, "short": true, "trimpath": true, "v": true, "work": true,
+	"x": true,
+}
+
+// removePackageArgs returns args without the packages, which are the
+// positional arguments before -args.
+func removePackageArgs(args []string) []string {
+	end := findPkgArgPosition(args)
+	result := make([]string, 0, len(args))
+	for i := 0; i < end; i++ {
+		arg := args[i]
+		switch {
+		case !strings.HasPrefix(arg, "-"):
+			continue
+		case !strings.Contains(arg, "=") && !goTestBoolFlags[strings.TrimLeft(arg, "-")] && i+1 < end:
+			result = append(result, arg, args[i+1])
+			i++
+			continue
+		}
+		result = append(result, arg)
+	}
+	return append(result, args[end:]...)
+}
+
+// QuoteCommand returns the args as a single line which can be copied into a
+// shell. Args which contain characters that are special to the shell are
+// quoted with single quotes.
+func QuoteCommand(args []string) string {
+	quoted := make([]string, 0, len(args))
+	for _, arg := range args {
+		quoted = append(quoted, quoteArg(arg))
+	}
+	return strings.Join(quoted, " ")
+}
+
+func quoteArg(arg string) string {
+	if arg == "" {
+		return "''"
+	}
+	if !strings.ContainsAny(arg, " \t\n'\"\\$`!*?[](){}<>|&;#~^") {
+		return arg
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
@@ -0,0 +1,80 @@
+package runner
+
+import (
+	"testing"
+
+	"gotest.tools/gotestsum/testjson"
+	"gotest.tools/v3/assert"
+)
+
+func TestConfig_ReproduceCommand(t *testing.T) {
+	type testCase struct {
+		config   Config
+		tc       testjson.TestCase
+		seed     string
+		expected []string
+	}
+
+	run := func(t *testing.T, name string, tc testCase) {
+		t.Helper()
+		runCase(t, name, func(t *testing.T) {
+			actual := tc.config.ReproduceCommand(tc.tc, tc.seed)
+			assert.DeepEqual(t, actual, tc.expected)
+		})
+	}
+
+	failed := testjson.TestCase{Package: "example.com/pkg", Test: "TestOne/sub"}
+	run(t, "no args", testCase{
+		tc:       failed,
+		expected: []string{"go", "test", "-run=^TestOne$/^sub$", "-count=1", "example.com/pkg"},
+	})
+	run(t, "with args", testCase{
+		config: Config{
+			Args: []string{"-json", "-run", "TestOne", "-count=3", "-tags=integration", "-v"},
+		},
+		tc: failed,
+		expected: []string{
+			"go", "test", "-run=^TestOne$/^sub$", "-count=1",
+			"-tags=integration", "-v", "example.com/pkg",
+		},
+	})
+	run(t, "with shuffle seed", testCase{
+		config:   Config{Args: []string{"-shuffle=on"}},
+		tc:       failed,
+		seed:     "1698245487891234",
+		expected: []string{"go", "test", "-run=^TestOne$/^sub$", "-count=1", "-shuffle=1698245487891234", "example.com/pkg"},
+	})
+	run(t, "with -args", testCase{
+		config: Config{Args: []string{"-race", "-args", "-update"}},
+		tc:     failed,
+		expected: []string{
+			"go", "test", "-run=^TestOne$/^sub$", "-count=1",
+			"-race", "example.com/pkg", "-args", "-update",
+		},
+	})
+	run(t, "with packages", testCase{
+		config: Config{
+			Args: []string{"-timeout", "5m", "./...", "-race", "./cmd/...", "-args", "./testdata"},
+		},
+		tc: failed,
+		expected: []string{
+			"go", "test", "-run=^TestOne$/^sub$", "-count=1",
+			"-timeout", "5m", "-race", "example.com/pkg", "-args", "./testdata",
+		},
+	})
+	run(t, "package failed", testCase{
+		tc:       testjson.TestCase{Package: "example.com/pkg"},
+		expected: []string{"go", "test", "-count=1", "example.com/pkg"},
+	})
+	run(t, "raw command", testCase{
+		config:   Config{RawCommand: true, Args: []string{"./script"}},
+		tc:       failed,
+		expected: []string{"./script", "-test.run=^TestOne$/^sub$", "example.com/pkg"},
+	})
+}
+
+func TestQuoteCommand(t *testing.T) {
+	args := []string{"go", "test", "-run=^TestOne$/^sub$", "-tags=a b", "it's", "", "./pkg"}
+	expected := `go test '-run=^TestOne$/^sub$' '-tags=a b' 'it'\''s' '' ./pkg`
+	assert.Equal(t, QuoteCommand(args), expected)
+}
@@ -0,0 +1,133 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gotest.tools/gotestsum/testjson"
+)
+
+// SelectionForTestCase returns a Selection that runs only the test case tc.
+func SelectionForTestCase(tc testjson.TestCase) Selection {
+	return Selection{Run: RunPattern(tc.Test), Package: tc.Package}
+}
+
+type testCaseFilter func([]testjson.TestCase) []testjson.TestCase
+
+func rerunFailsFilter(o RerunFailsConfig) testCaseFilter {
+	if o.RunRootCases {
+		return func(tcs []testjson.TestCase) []testjson.TestCase {
+			var result []testjson.TestCase
+			for _, tc := range tcs {
+				if !tc.Test.IsSubTest() {
+					result = append(result, tc)
+				}
+			}
+			return result
+		}
+	}
+	return testjson.FilterFailedUnique
+}
+
+func rerunFailed(ctx context.Context, config Config, scanConfig testjson.ScanConfig) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	tcFilter := rerunFailsFilter(config.RerunFails)
+
+	rec := newFailureRecorderFromExecution(scanConfig.Execution)
+	for attempts := 0; rec.count() > 0 && attempts < config.RerunFails.MaxAttempts; attempts++ {
+		testjson.PrintSummary(config.Stdout, scanConfig.Execution, testjson.SummarizeNone)
+		config.Stdout.Write([]byte("\n")) // nolint: errcheck
+
+		nextRec := newFailureRecorder(scanConfig.Handler)
+		for _, tc := range tcFilter(rec.failures) {
+			goTestProc, err := startGoTestFn(ctx, config, config.command(SelectionForTestCase(tc)))
+			if err != nil {
+				return err
+			}
+
+			cfg := testjson.ScanConfig{
+				RunID:     attempts + 1,
+				Stdout:    goTestProc.stdout,
+				Stderr:    goTestProc.stderr,
+				Handler:   nextRec,
+				Execution: scanConfig.Execution,
+				Stop:      cancel,
+			}
+			if _, err := testjson.ScanTestOutput(cfg); err != nil {
+				return err
+			}
+			exitErr := goTestProc.cmd.Wait()
+			if exitErr != nil {
+				nextRec.lastErr = exitErr
+			}
+			if err := hasErrors(exitErr, scanConfig.Execution); err != nil {
+				return err
+			}
+		}
+		rec = nextRec
+	}
+	return rec.lastErr
+}
+
+func hasErrors(err error, exec *testjson.Execution) error {
+	switch {
+	case len(exec.Errors()) > 0, len(exec.BuildErrors()) > 0:
+		return fmt.Errorf("rerun aborted because previous run had errors")
+	// Exit code 0 and 1 are expected.
+	case ExitCodeWithDefault(err) > 1:
+		return fmt.Errorf("unexpected go test exit code: %v", err)
+	case exec.HasPanic():
+		return fmt.Errorf("rerun aborted because previous run had a suspected panic and some test may not have run")
+	default:
+		return nil
+	}
+}
+
+type failureRecorder struct {
+	testjson.EventHandler
+	failures []testjson.TestCase
+	lastErr  error
+}
+
+func newFailureRecorder(handler testjson.EventHandler) *failureRecorder {
+	return &failureRecorder{EventHandler: handler}
+}
+
+func newFailureRecorderFromExecution(exec *testjson.Execution) *failureRecorder {
+	return &failureRecorder{failures: exec.Failed()}
+}
+
+func (r *failureRecorder) Event(event testjson.TestEvent, execution *testjson.Execution) error {
+	if !event.PackageEvent() && event.Action == testjson.ActionFail {
+		pkg := execution.Package(event.Package)
+		tc := pkg.LastFailedByName(event.Test)
+		r.failures = append(r.failures, tc)
+	}
+	return r.EventHandler.Event(event, execution)
+}
+
+func (r *failureRecorder) count() int {
+	return len(r.failures)
+}
+
+// RunPattern returns a regular expression for the -test.run flag that matches
+// only the test named test.
+func RunPattern(test testjson.TestName) string {
+	if test.IsSubTest() {
+		parts := strings.Split(string(test), "/")
+		var sb strings.Builder
+		for i, p := range parts {
+			if i > 0 {
+				sb.WriteByte('/')
+			}
+			sb.WriteByte('^')
+			sb.WriteString(regexp.QuoteMeta(p))
+			sb.WriteByte('$')
+		}
+		return sb.String()
+	}
+	return "^" + regexp.QuoteMeta(test.Name()) + "$"
+}
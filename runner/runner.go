@@ -0,0 +1,237 @@
+/*
+Package runner runs 'go test', or a compiled test binary, and collects the
+test2json output into a testjson.Execution. It is the package used by the
+gotestsum command to run tests, and can be used to embed gotestsum in other
+Go programs.
+
+# Example
+
+This example runs the tests for every package in the module with the race
+detector, prints each test using the testname format, and reruns any failed
+tests up to two times.
+
+	exec, err := runner.Run(ctx, runner.Config{
+	    Args:      []string{"-race"},
+	    Formatter: testjson.NewEventFormatter(os.Stdout, "testname", testjson.FormatOptions{}),
+	    RerunFails: runner.RerunFailsConfig{
+	        MaxAttempts:        2,
+	        MaxInitialFailures: 10,
+	    },
+	    Packages: []string{"./..."},
+	})
+	testjson.PrintSummary(os.Stdout, exec, testjson.SummarizeAll)
+*/
+package runner // import "gotest.tools/gotestsum/runner"
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync/atomic"
+
+	"gotest.tools/gotestsum/testjson"
+)
+
+// Config used to run tests.
+type Config struct {
+	// Args are the 'go test' flags and packages. 'go test -json' is prepended
+	// to Args to create the command. If Args is empty, all the packages in
+	// the current directory and its sub-directories are tested.
+	Args []string
+	// RawCommand uses Args as the full command to run, instead of prepending
+	// 'go test -json'. The command must write test2json output to stdout.
+	RawCommand bool
+	// Packages is the list of packages to test. It must be set when RerunFails
+	// is used with Args that contain 'go test' flags, so that the packages can
+	// be placed correctly in the command used to rerun the tests.
+	Packages []string
+	// Dir is the working directory of the command. If Dir is empty, the command
+	// runs in the current directory.
+	Dir string
+	// Selections limits the run to a subset of the tests. A separate command is
+	// run for each Selection, and the results are collected into a single
+	// Execution. RerunFails is not supported with Selections.
+	Selections []Selection
+
+	// Formatter prints each event. If Formatter is nil, events are not printed.
+	Formatter testjson.EventFormatter
+	// Handlers receive every event, and every line of stderr, before the event
+	// is printed by the Formatter.
+	Handlers []testjson.EventHandler
+	// Stdin is the stdin of the command. Defaults to os.Stdin.
+	Stdin io.Reader
+	// Stdout is used to print the progress of reruns. Defaults to os.Stdout.
+	Stdout io.Writer
+	// Stderr receives each line the command writes to stderr. Defaults to
+	// os.Stderr.
+	Stderr io.Writer
+
+	// MaxFails ends the run after this number of tests have failed. If
+	// MaxFails is zero, the run is never ended early.
+	MaxFails int
+	// RerunFails configures running failed tests again.
+	RerunFails RerunFailsConfig
+	// IgnoreNonJSONOutputLines writes any line of stdout that is not a valid
+	// test2json event to Stderr, instead of ending the run with an error.
+	IgnoreNonJSONOutputLines bool
+}
+
+// RerunFailsConfig configures running failed tests again.
+type RerunFailsConfig struct {
+	// MaxAttempts is the maximum number of times to rerun failed tests. If
+	// MaxAttempts is zero, failed tests are not rerun.
+	MaxAttempts int
+	// MaxInitialFailures skips all reruns when the first run has more than this
+	// number of failed tests.
+	MaxInitialFailures int
+	// RunRootCases reruns the entire root test when any of its subtests fail,
+	// instead of only the failed subtest.
+	RunRootCases bool
+}
+
+// Selection is a subset of the tests to run.
+type Selection struct {
+	// Package replaces the list of packages to test, when it is not empty.
+	Package string
+	// Run is a regular expression used as the -test.run flag. It replaces
+	// any -run flag in Args.
+	Run string
+}
+
+var (
+	// ErrRerunFailsWithoutPackages is returned by Config.Validate when Args
+	// are used with RerunFails, but Packages is not set.
+	ErrRerunFailsWithoutPackages = errors.New(
+		"packages must be set when args are used with rerun fails")
+	// ErrRerunFailsWithFailfast is returned by Config.Validate when Args
+	// include -failfast, and RerunFails is set.
+	ErrRerunFailsWithFailfast = errors.New(
+		"-failfast can not be used with rerun fails because not all test cases will run")
+	// ErrRerunFailsWithSelections is returned by Config.Validate when both
+	// Selections and RerunFails are set.
+	ErrRerunFailsWithSelections = errors.New(
+		"selections can not be used with rerun fails")
+)
+
+// Validate returns an error if the Config is not valid.
+func (c Config) Validate() error {
+	if c.RerunFails.MaxAttempts == 0 {
+		return nil
+	}
+	if len(c.Args) > 0 && !c.RawCommand && len(c.Packages) == 0 {
+		return ErrRerunFailsWithoutPackages
+	}
+	if boolArgIndex("failfast", c.Args) > -1 {
+		return ErrRerunFailsWithFailfast
+	}
+	if len(c.Selections) > 0 {
+		return ErrRerunFailsWithSelections
+	}
+	return nil
+}
+
+func (c Config) withDefaults() Config {
+	if c.Stdin == nil {
+		c.Stdin = os.Stdin
+	}
+	if c.Stdout == nil {
+		c.Stdout = os.Stdout
+	}
+	if c.Stderr == nil {
+		c.Stderr = os.Stderr
+	}
+	return c
+}
+
+// Run the tests, rerun any failed tests when RerunFails is configured, and
+// return the Execution with the results. The returned error is the error
+// from the last run of the command. It implements ExitCoder when the command
+// exited with a non-zero exit code, or was interrupted by a signal.
+//
+// The Execution is returned even when there is an error, as long as the
+// command was started.
+func Run(ctx context.Context, config Config) (*testjson.Execution, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	config = config.withDefaults()
+	if len(config.Selections) > 0 {
+		return runSelections(ctx, config)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	goTestProc, err := startGoTestFn(ctx, config, config.command(Selection{}))
+	if err != nil {
+		return nil, err
+	}
+
+	handler := newHandler(config)
+	exec, err := testjson.ScanTestOutput(testjson.ScanConfig{
+		Stdout:                   goTestProc.stdout,
+		Stderr:                   goTestProc.stderr,
+		Handler:                  handler,
+		Stop:                     cancel,
+		IgnoreNonJSONOutputLines: config.IgnoreNonJSONOutputLines,
+	})
+	if err != nil {
+		return exec, err
+	}
+
+	exitErr := goTestProc.cmd.Wait()
+	if signum := atomic.LoadInt32(&goTestProc.signal); signum != 0 {
+		return exec, exitError{num: signalExitCode + int(signum)}
+	}
+	if exitErr == nil || config.RerunFails.MaxAttempts == 0 {
+		return exec, exitErr
+	}
+	if err := hasErrors(exitErr, exec); err != nil {
+		return exec, err
+	}
+
+	failed := len(rerunFailsFilter(config.RerunFails)(exec.Failed()))
+	if failed > config.RerunFails.MaxInitialFailures {
+		return exec, fmt.Errorf(
+			"number of test failures (%d) exceeds maximum (%d) set by --rerun-fails-max-failures",
+			failed, config.RerunFails.MaxInitialFailures)
+	}
+
+	cfg := testjson.ScanConfig{Execution: exec, Handler: handler}
+	return exec, rerunFailed(ctx, config, cfg)
+}
+
+// runSelections runs the command once for each of config.Selections, and
+// collects the results into a single Execution.
+func runSelections(ctx context.Context, config Config) (*testjson.Execution, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	handler := newHandler(config)
+	var exec *testjson.Execution
+	var exitErr error
+	for _, sel := range config.Selections {
+		goTestProc, err := startGoTestFn(ctx, config, config.command(sel))
+		if err != nil {
+			return exec, err
+		}
+
+		exec, err = testjson.ScanTestOutput(testjson.ScanConfig{
+			Stdout:                   goTestProc.stdout,
+			Stderr:                   goTestProc.stderr,
+			Handler:                  handler,
+			Execution:                exec,
+			Stop:                     cancel,
+			IgnoreNonJSONOutputLines: config.IgnoreNonJSONOutputLines,
+		})
+		if err != nil {
+			return exec, err
+		}
+		if err := goTestProc.cmd.Wait(); err != nil {
+			exitErr = err
+		}
+	}
+	return exec, exitErr
+}
@@ -0,0 +1,152 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"gotest.tools/gotestsum/testjson"
+	"gotest.tools/v3/assert"
+)
+
+func TestRun_RerunFails_WithTooManyInitialFailures(t *testing.T) {
+	jsonFailed := `{"Package": "pkg", "Action": "run"}
+{"Package": "pkg", "Test": "TestOne", "Action": "run"}
+{"Package": "pkg", "Test": "TestOne", "Action": "fail"}
+{"Package": "pkg", "Test": "TestTwo", "Action": "run"}
+{"Package": "pkg", "Test": "TestTwo", "Action": "fail"}
+{"Package": "pkg", "Action": "fail"}
+`
+
+	fn := func(args []string) *proc {
+		return &proc{
+			cmd:    fakeWaiter{result: newExitCode("failed", 1)},
+			stdout: strings.NewReader(jsonFailed),
+			stderr: bytes.NewReader(nil),
+		}
+	}
+	reset := patchStartGoTestFn(fn)
+	defer reset()
+
+	out := new(bytes.Buffer)
+	cfg := Config{
+		RawCommand: true,
+		Args:       []string{"./test.test"},
+		Formatter:  testjson.NewEventFormatter(out, "testname", testjson.FormatOptions{}),
+		RerunFails: RerunFailsConfig{MaxAttempts: 3, MaxInitialFailures: 1},
+		Stdout:     out,
+	}
+	_, err := Run(context.Background(), cfg)
+	assert.ErrorContains(t, err, "number of test failures (2) exceeds maximum (1)", out.String())
+}
+
+func TestRun_RerunFails_BuildErrorPreventsRerun(t *testing.T) {
+	jsonFailed := `{"Package": "pkg", "Action": "run"}
+{"Package": "pkg", "Test": "TestOne", "Action": "run"}
+{"Package": "pkg", "Test": "TestOne", "Action": "fail"}
+{"Package": "pkg", "Test": "TestTwo", "Action": "run"}
+{"Package": "pkg", "Test": "TestTwo", "Action": "fail"}
+{"Package": "pkg", "Action": "fail"}
+`
+
+	fn := func(args []string) *proc {
+		return &proc{
+			cmd:    fakeWaiter{result: newExitCode("failed", 1)},
+			stdout: strings.NewReader(jsonFailed),
+			stderr: strings.NewReader("anything here is an error\n"),
+		}
+	}
+	reset := patchStartGoTestFn(fn)
+	defer reset()
+
+	out := new(bytes.Buffer)
+	cfg := Config{
+		RawCommand: true,
+		Args:       []string{"./test.test"},
+		Formatter:  testjson.NewEventFormatter(out, "testname", testjson.FormatOptions{}),
+		RerunFails: RerunFailsConfig{MaxAttempts: 3, MaxInitialFailures: 1},
+		Stdout:     out,
+	}
+	_, err := Run(context.Background(), cfg)
+	assert.ErrorContains(t, err, "rerun aborted because previous run had errors", out.String())
+}
+
+// type checking of os/exec.ExitError is done in a test file so that users
+// installing from source can continue to use versions prior to go1.12.
+var _ ExitCoder = &exec.ExitError{}
+
+func TestRun_RerunFails_PanicPreventsRerun(t *testing.T) {
+	jsonFailed := `{"Package": "pkg", "Action": "run"}
+{"Package": "pkg", "Test": "TestOne", "Action": "run"}
+{"Package": "pkg", "Test": "TestOne", "Action": "output","Output":"panic: something went wrong\n"}
+{"Package": "pkg", "Action": "fail"}
+`
+
+	fn := func(args []string) *proc {
+		return &proc{
+			cmd:    fakeWaiter{result: newExitCode("failed", 1)},
+			stdout: strings.NewReader(jsonFailed),
+			stderr: bytes.NewReader(nil),
+		}
+	}
+	reset := patchStartGoTestFn(fn)
+	defer reset()
+
+	out := new(bytes.Buffer)
+	cfg := Config{
+		RawCommand: true,
+		Args:       []string{"./test.test"},
+		Formatter:  testjson.NewEventFormatter(out, "testname", testjson.FormatOptions{}),
+		RerunFails: RerunFailsConfig{MaxAttempts: 3, MaxInitialFailures: 1},
+		Stdout:     out,
+	}
+	_, err := Run(context.Background(), cfg)
+	assert.ErrorContains(t, err, "rerun aborted because previous run had a suspected panic", out.String())
+}
+
+func TestConfig_Validate(t *testing.T) {
+	rerun := RerunFailsConfig{MaxAttempts: 2}
+	type testCase struct {
+		name     string
+		config   Config
+		expected error
+	}
+	testCases := []testCase{
+		{name: "no reruns", config: Config{Args: []string{"./..."}}},
+		{name: "reruns with packages", config: Config{
+			Args: []string{"-v"}, Packages: []string{"./..."}, RerunFails: rerun,
+		}},
+		{name: "reruns with raw command", config: Config{
+			Args: []string{"./test-all"}, RawCommand: true, RerunFails: rerun,
+		}},
+		{
+			name:     "reruns with args and no packages",
+			config:   Config{Args: []string{"./..."}, RerunFails: rerun},
+			expected: ErrRerunFailsWithoutPackages,
+		},
+		{
+			name: "reruns with failfast",
+			config: Config{
+				Args: []string{"-failfast"}, Packages: []string{"./..."}, RerunFails: rerun,
+			},
+			expected: ErrRerunFailsWithFailfast,
+		},
+		{
+			name:     "reruns with selections",
+			config:   Config{Selections: []Selection{{Run: "TestA"}}, RerunFails: rerun},
+			expected: ErrRerunFailsWithSelections,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.config.Validate()
+			if tc.expected == nil {
+				assert.NilError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, tc.expected)
+		})
+	}
+}
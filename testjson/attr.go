@@ -0,0 +1,129 @@
+package testjson
+
+import (
+	"sort"
+)
+
+// Attr is an attribute of a test, set by the test with testing.T.Attr.
+type Attr struct {
+	Key   string
+	Value string
+}
+
+// String returns the attribute in the format key=value.
+func (a Attr) String() string {
+	return a.Key + "=" + a.Value
+}
+
+// Attr returns the value of the attribute with key. If the attribute was set
+// more than once, the last value is returned. Attr returns false if the test
+// has no attribute with key.
+func (tc TestCase) Attr(key string) (string, bool) {
+	for i := len(tc.Attrs) - 1; i >= 0; i-- {
+		if tc.Attrs[i].Key == key {
+			return tc.Attrs[i].Value, true
+		}
+	}
+	return "", false
+}
+
+// hasAttrs returns true if the test case has all of attrs.
+func (tc TestCase) hasAttrs(attrs []Attr) bool {
+	for _, attr := range attrs {
+		if value, ok := tc.Attr(attr.Key); !ok || value != attr.Value {
+			return false
+		}
+	}
+	return true
+}
+
+// TestCasesWithAttr returns the test cases that passed, failed, or were
+// skipped, and have an attribute with key and value. The test cases are sorted
+// by package, and in the order they started.
+func (e *Execution) TestCasesWithAttr(key, value string) []TestCase {
+	var result []TestCase
+	attrs := []Attr{{Key: key, Value: value}}
+	for _, name := range sortedKeys(e.packages) {
+		pkg := e.packages[name]
+		var tcs []TestCase
+		for _, group := range [][]TestCase{pkg.Passed, pkg.Failed, pkg.Skipped} {
+			tcs = append(tcs, filterByAttrs(group, attrs)...)
+		}
+		sort.Slice(tcs, func(i, j int) bool {
+			return tcs[i].ID < tcs[j].ID
+		})
+		result = append(result, tcs...)
+	}
+	return result
+}
+
+// AttrValues returns the sorted values of the attribute with key, from all the
+// test cases that passed, failed, or were skipped.
+func (e *Execution) AttrValues(key string) []string {
+	seen := make(map[string]bool)
+	for _, pkg := range e.packages {
+		for _, group := range [][]TestCase{pkg.Passed, pkg.Failed, pkg.Skipped} {
+			for _, tc := range group {
+				if value, ok := tc.Attr(key); ok {
+					seen[value] = true
+				}
+			}
+		}
+	}
+	result := make([]string, 0, len(seen))
+	for value := range seen {
+		result = append(result, value)
+	}
+	sort.Strings(result)
+	return result
+}
+
+func filterByAttrs(tcs []TestCase, attrs []Attr) []TestCase {
+	if len(attrs) == 0 {
+		return tcs
+	}
+	var result []TestCase
+	for _, tc := range tcs {
+		if tc.hasAttrs(attrs) {
+			result = append(result, tc)
+		}
+	}
+	return result
+}
+
+type attrGroup struct {
+	name      string
+	testCases []TestCase
+}
+
+// groupByAttr groups the test cases by the value of the attribute with key.
+// Groups are sorted by value, followed by a group for the test cases without
+// the attribute.
+func groupByAttr(tcs []TestCase, key string) []attrGroup {
+	byValue := make(map[string][]TestCase)
+	var missing []TestCase
+	for _, tc := range tcs {
+		value, ok := tc.Attr(key)
+		if !ok {
+			missing = append(missing, tc)
+			continue
+		}
+		byValue[value] = append(byValue[value], tc)
+	}
+
+	values := make([]string, 0, len(byValue))
+	for value := range byValue {
+		values = append(values, value)
+	}
+	sort.Strings(values)
+
+	groups := make([]attrGroup, 0, len(values)+1)
+	for _, value := range values {
+		name := Attr{Key: key, Value: value}.String()
+		groups = append(groups, attrGroup{name: name, testCases: byValue[value]})
+	}
+	if len(missing) > 0 {
+		groups = append(groups, attrGroup{name: "no " + key, testCases: missing})
+	}
+	return groups
+}
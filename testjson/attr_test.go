@@ -0,0 +1,84 @@
+package testjson
+
+import (
+	"bytes"
+	"testing"
+
+	"gotest.tools/gotestsum/internal/text"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/golden"
+)
+
+func TestScanTestOutput_WithAttrs(t *testing.T) {
+	exec, err := ScanTestOutput(ScanConfig{
+		Stdout: bytes.NewReader(golden.Get(t, "input/go-test-json-attr.out")),
+	})
+	assert.NilError(t, err)
+
+	pkg := exec.Package("example.com/attr")
+	owned := pkg.LastFailedByName("TestOwned")
+	assert.DeepEqual(t, owned.Attrs, []Attr{
+		{Key: "owner", Value: "team-a"},
+		{Key: "ticket", Value: "ABC-123"},
+	})
+	sub := pkg.LastFailedByName("TestOwned/sub")
+	assert.DeepEqual(t, sub.Attrs, []Attr{{Key: "owner", Value: "team-b"}})
+	assert.Equal(t, len(pkg.Skipped), 1)
+	assert.DeepEqual(t, pkg.Skipped[0].Attrs, []Attr{{Key: "owner", Value: "team-b"}})
+	assert.Equal(t, len(pkg.Passed), 1)
+	assert.Assert(t, pkg.Passed[0].Attrs == nil)
+
+	var names []string
+	for _, tc := range exec.TestCasesWithAttr("owner", "team-b") {
+		names = append(names, tc.Test.Name())
+	}
+	assert.DeepEqual(t, names, []string{"TestOwned/sub", "TestOther"})
+	assert.DeepEqual(t, exec.AttrValues("owner"), []string{"team-a", "team-b"})
+	assert.DeepEqual(t, exec.AttrValues("missing"), []string{})
+}
+
+func TestTestCase_Attr(t *testing.T) {
+	tc := TestCase{Attrs: []Attr{
+		{Key: "owner", Value: "team-a"},
+		{Key: "owner", Value: "team-b"},
+	}}
+	value, ok := tc.Attr("owner")
+	assert.Assert(t, ok)
+	assert.Equal(t, value, "team-b")
+
+	_, ok = tc.Attr("ticket")
+	assert.Assert(t, !ok)
+}
+
+func TestPrintSummaryWithOptions_Attrs(t *testing.T) {
+	exec, err := ScanTestOutput(ScanConfig{
+		Stdout: bytes.NewReader(golden.Get(t, "input/go-test-json-attr.out")),
+	})
+	assert.NilError(t, err)
+
+	testCases := []struct {
+		name     string
+		attrs    SummaryAttrs
+		expected string
+	}{
+		{
+			name:     "filter",
+			attrs:    SummaryAttrs{Filter: []Attr{{Key: "owner", Value: "team-b"}}},
+			expected: "summary/with-attr-filter",
+		},
+		{
+			name:     "group by",
+			attrs:    SummaryAttrs{GroupBy: "owner"},
+			expected: "summary/with-attr-group-by",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			out := new(bytes.Buffer)
+			PrintSummaryWithOptions(out, exec, SummaryOptions{Sections: SummarizeAll, Attrs: tc.attrs})
+
+			actual := text.ProcessLines(t, out, text.OpRemoveSummaryLineElapsedTime)
+			golden.Assert(t, actual, tc.expected)
+		})
+	}
+}
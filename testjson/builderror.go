@@ -0,0 +1,259 @@
+package testjson
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// BuildError is an error from the compiler, or from vet, for a package that
+// failed to build.
+type BuildError struct {
+	// Package is the import path of the package that failed to build. For the
+	// external test package (package foo_test) it is the import path of foo.
+	Package string
+	// File is the path to the file as printed by the go tool, which is usually
+	// relative to the working directory. File is empty for errors that are not
+	// about a single file, ex: import cycle not allowed.
+	File   string
+	Line   int
+	Column int
+	// Message is the error message. Messages that span multiple lines include
+	// the indented lines that follow the first line.
+	Message string
+}
+
+// String returns the error in the format printed by the go tool,
+// ex: ./file.go:12:3: undefined: foo
+func (e BuildError) String() string {
+	switch {
+	case e.File == "":
+		return e.Message
+	case e.Column > 0:
+		return fmt.Sprintf("%s:%d:%d: %s", e.File, e.Line, e.Column, e.Message)
+	default:
+		return fmt.Sprintf("%s:%d: %s", e.File, e.Line, e.Message)
+	}
+}
+
+// matches the location at the start of a compiler or vet error,
+// ex: ./file.go:12:3: undefined: foo
+var buildErrorLine = regexp.MustCompile(`^(\S+\.go):(\d+)(?::(\d+))?: (.*)$`)
+
+// parseBuildErrorLine parses a line which starts with the location of the
+// error. The Package of the error is not set.
+func parseBuildErrorLine(line string) (BuildError, bool) {
+	match := buildErrorLine.FindStringSubmatch(line)
+	if match == nil {
+		return BuildError{}, false
+	}
+	e := BuildError{File: match[1], Message: match[4]}
+	e.Line, _ = strconv.Atoi(match[2])
+	e.Column, _ = strconv.Atoi(match[3])
+	return e, true
+}
+
+// buildErrorParser parses build errors from the lines 'go test' writes to
+// stderr. The errors for a package follow a header line with the name of the
+// package, ex: # example.com/pkg [example.com/pkg.test]
+type buildErrorParser struct {
+	// pkg is the package from the last header, or empty if no header was
+	// found yet.
+	pkg string
+}
+
+// add a line from stderr to the parser. add returns a new error, or
+// appends the line to the last error in errors when it continues the message
+// of that error. add returns false if the line is not part of a build error.
+func (r *buildErrorParser) add(errors []BuildError, line string) ([]BuildError, bool) {
+	if strings.HasPrefix(line, "# ") {
+		r.pkg = buildErrorHeaderPackage(strings.TrimPrefix(line, "# "))
+		return errors, true
+	}
+	if r.pkg == "" || line == "" {
+		return errors, false
+	}
+
+	first, _ := utf8.DecodeRuneInString(line)
+	if unicode.IsSpace(first) {
+		if n := len(errors); n > 0 && errors[n-1].Package == r.pkg {
+			errors[n-1].Message += "\n" + line
+			return errors, true
+		}
+	}
+	if strings.HasPrefix(line, "FAIL") || strings.HasPrefix(line, "go: ") {
+		r.pkg = ""
+		return errors, false
+	}
+
+	e, ok := parseBuildErrorLine(line)
+	if !ok {
+		e = BuildError{Message: line}
+	}
+	e.Package = r.pkg
+	return append(errors, e), true
+}
+
+// buildErrorHeaderPackage returns the package from the header of a build
+// error. The header is the name of the package, which may be followed by the
+// test binary in brackets, ex: example.com/pkg_test [example.com/pkg.test].
+// Errors from vet use the package in brackets, ex: [example.com/pkg_test].
+// Errors in the external test package are attributed to the package under
+// test.
+func buildErrorHeaderPackage(header string) string {
+	pkg := header
+	switch i := strings.Index(header, "["); {
+	case i == 0:
+		pkg = strings.TrimSuffix(header[1:], "]")
+	case i > 0:
+		pkg = strings.TrimSpace(header[:i])
+		if binary := strings.TrimSuffix(header[i+1:], "]"); strings.HasSuffix(binary, ".test") {
+			pkg = strings.TrimSuffix(binary, ".test")
+		}
+	}
+	return strings.TrimSuffix(pkg, "_test")
+}
+
+// BuildErrors returns the errors for all the packages that failed to build.
+// The errors from stderr are in the order they were printed by the go tool,
+// followed by the errors from the build output of each package, ordered by
+// package.
+func (e *Execution) BuildErrors() []BuildError {
+	buildErrors, _ := e.splitErrors()
+	return buildErrors
+}
+
+// parseError parses a line from stderr into a build error, or adds it to the
+// lines which are not part of a build error. The caller must hold errorsLock.
+func (e *Execution) parseError(line string) {
+	var isBuildError bool
+	e.stderrBuildErrors, isBuildError = e.errorParser.add(e.stderrBuildErrors, line)
+	if !isBuildError {
+		e.otherErrors = append(e.otherErrors, line)
+	}
+}
+
+// splitErrors returns the build errors, and the lines which are not part of a
+// build error.
+func (e *Execution) splitErrors() ([]BuildError, []string) {
+	e.errorsLock.RLock()
+	defer e.errorsLock.RUnlock()
+
+	buildErrors := append([]BuildError(nil), e.stderrBuildErrors...)
+	for _, name := range sortedKeys(e.packages) {
+		buildErrors = append(buildErrors, e.packages[name].buildErrors...)
+	}
+	return buildErrors, append([]string(nil), e.otherErrors...)
+}
+
+// parseBuildOutput parses the build errors from the build output of the
+// package pkg.
+func parseBuildOutput(pkg string, output []string) []BuildError {
+	var buildErrors []BuildError
+	parser := buildErrorParser{pkg: pkg}
+	for _, line := range output {
+		line = strings.TrimRight(line, "\n")
+		if strings.HasPrefix(line, "# ") {
+			continue
+		}
+		buildErrors, _ = parser.add(buildErrors, line)
+	}
+	return buildErrors
+}
+
+// PackageBuildErrors returns the errors for the package pkg, or nil if the
+// package did not fail to build.
+func (e *Execution) PackageBuildErrors(pkg string) []BuildError {
+	var result []BuildError
+	for _, err := range e.BuildErrors() {
+		if err.Package == pkg {
+			result = append(result, err)
+		}
+	}
+	return result
+}
+
+// BuildFailedPackages returns the names of the packages that have build
+// errors, in the order of their first error.
+func (e *Execution) BuildFailedPackages() []string {
+	var result []string
+	seen := make(map[string]bool)
+	for _, err := range e.BuildErrors() {
+		if !seen[err.Package] {
+			seen[err.Package] = true
+			result = append(result, err.Package)
+		}
+	}
+	return result
+}
+
+// OtherErrors returns the lines from stderr which are not part of a
+// BuildError.
+func (e *Execution) OtherErrors() []string {
+	_, others := e.splitErrors()
+	return others
+}
+
+// ErrorCount returns the number of build errors, and errors from the other
+// lines of stderr. An error from stderr may include more than one line, so
+// ErrorCount may be less than the number of lines from Errors.
+func (e *Execution) ErrorCount() int {
+	buildErrors, others := e.splitErrors()
+	return len(buildErrors) + countErrors(others)
+}
+
+// isBuildFailedOutput returns true if output is the line printed for a
+// package that failed to build, ex: FAIL	example.com/pkg [build failed]
+func isBuildFailedOutput(output string) bool {
+	output = strings.TrimRight(output, "\n")
+	return strings.HasPrefix(output, "FAIL\t") &&
+		(strings.HasSuffix(output, " [build failed]") ||
+			strings.HasSuffix(output, " [setup failed]"))
+}
+
+// addBuildEvent adds a build-output or build-fail event to the package being
+// built. The build output is parsed for build errors when the build-fail event
+// is received, so that output from a successful build, like the output of
+// -gcflags=-m, is not reported as an error.
+func (e *Execution) addBuildEvent(event TestEvent) {
+	name := buildErrorHeaderPackage(event.ImportPath)
+	pkg, ok := e.packages[name]
+	if !ok {
+		pkg = newPackage()
+		e.packages[name] = pkg
+	}
+	if pkg.buildStarted.IsZero() {
+		pkg.buildStarted = event.Time
+	}
+
+	switch event.Action {
+	case ActionBuildOutput:
+		pkg.buildOutput = append(pkg.buildOutput, event.Output)
+	case ActionBuildFail:
+		pkg.buildFailed = true
+		pkg.buildEnded = event.Time
+		pkg.buildErrors = parseBuildOutput(name, pkg.buildOutput)
+	}
+}
+
+// BuildOutput returns the output from the build of the package. BuildOutput
+// is only available from go1.24+, which includes the build output in the
+// output of 'go test -json'.
+func (p *Package) BuildOutput() []string {
+	return p.buildOutput
+}
+
+// BuildElapsed returns the time from the first build event for the package
+// until the build failed, or the package started to run. BuildElapsed returns
+// 0 if there were no build events for the package, or if the events did not
+// include a time.
+func (p *Package) BuildElapsed() time.Duration {
+	if p.buildStarted.IsZero() || p.buildEnded.IsZero() {
+		return 0
+	}
+	return p.buildEnded.Sub(p.buildStarted)
+}
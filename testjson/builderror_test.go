@@ -0,0 +1,180 @@
+package testjson
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/golden"
+)
+
+func TestScanTestOutput_WithBuildErrors(t *testing.T) {
+	exec, err := ScanTestOutput(ScanConfig{
+		Stdout: bytes.NewReader(golden.Get(t, "input/go-test-json-build-failed.out")),
+		Stderr: bytes.NewReader(golden.Get(t, "input/go-test-json-build-failed.err")),
+	})
+	assert.NilError(t, err)
+
+	expected := []BuildError{
+		{
+			Package: "example.com/be/badtest",
+			File:    "badtest/badtest_test.go",
+			Line:    9,
+			Column:  14,
+			Message: `fmt.Printf format %d has arg "not a number" of wrong type string`,
+		},
+		{
+			Package: "example.com/be/broken",
+			File:    "broken/broken.go",
+			Line:    4,
+			Column:  9,
+			Message: "undefined: undefinedThing",
+		},
+		{
+			Package: "example.com/be/broken",
+			File:    "broken/broken.go",
+			Line:    8,
+			Column:  9,
+			Message: "cannot use 1 (untyped int constant) as string value in return statement",
+		},
+	}
+	assert.DeepEqual(t, exec.BuildErrors(), expected)
+	assert.DeepEqual(t, exec.BuildFailedPackages(),
+		[]string{"example.com/be/badtest", "example.com/be/broken"})
+	assert.DeepEqual(t, exec.PackageBuildErrors("example.com/be/broken"), expected[1:])
+	assert.Equal(t, len(exec.Errors()), 3)
+	assert.Equal(t, len(exec.OtherErrors()), 0)
+
+	assert.Assert(t, exec.Package("example.com/be/broken").BuildFailed())
+	assert.Assert(t, !exec.Package("example.com/be/good").BuildFailed())
+}
+
+func TestBuildErrorParser_MultilineAndOtherErrors(t *testing.T) {
+	exec := newExecution()
+	for _, line := range []string{
+		"go: downloading example.com/dep v1.0.0",
+		"# example.com/pkg [example.com/pkg.test]",
+		"./pkg_test.go:12:2: too many arguments in call to run",
+		"	have (number, number)",
+		"	want (int)",
+		"package example.com/other: import cycle not allowed",
+		"# example.com/pkg",
+		"go: example.com/missing@v1.0.0: missing go.sum entry",
+	} {
+		exec.addError(line)
+	}
+
+	expected := []BuildError{
+		{
+			Package: "example.com/pkg",
+			File:    "./pkg_test.go",
+			Line:    12,
+			Column:  2,
+			Message: "too many arguments in call to run\n\thave (number, number)\n\twant (int)",
+		},
+		{
+			Package: "example.com/pkg",
+			Message: "package example.com/other: import cycle not allowed",
+		},
+	}
+	assert.DeepEqual(t, exec.BuildErrors(), expected)
+	assert.DeepEqual(t, exec.OtherErrors(), []string{
+		"go: downloading example.com/dep v1.0.0",
+		"go: example.com/missing@v1.0.0: missing go.sum entry",
+	})
+	assert.Equal(t, expected[0].String(),
+		"./pkg_test.go:12:2: too many arguments in call to run\n\thave (number, number)\n\twant (int)")
+}
+
+func TestBuildErrorHeaderPackage(t *testing.T) {
+	for header, expected := range map[string]string{
+		"example.com/pkg":                                 "example.com/pkg",
+		"example.com/pkg [example.com/pkg.test]":          "example.com/pkg",
+		"example.com/pkg_test [example.com/pkg.test]":     "example.com/pkg",
+		"[example.com/pkg_test]":                          "example.com/pkg",
+		"example.com/pkg/internal [example.com/pkg.test]": "example.com/pkg",
+	} {
+		assert.Equal(t, buildErrorHeaderPackage(header), expected, header)
+	}
+}
+
+func TestScanTestOutput_WithBuildEvents(t *testing.T) {
+	exec, err := ScanTestOutput(ScanConfig{
+		Stdout: bytes.NewReader(golden.Get(t, "input/go-test-json-build-events.out")),
+	})
+	assert.NilError(t, err)
+
+	expected := []BuildError{
+		{
+			Package: "example.com/be/badtest",
+			File:    "badtest/badtest_test.go",
+			Line:    9,
+			Column:  14,
+			Message: `fmt.Printf format %d has arg "not a number" of wrong type string`,
+		},
+		{
+			Package: "example.com/be/broken",
+			File:    "broken/broken.go",
+			Line:    4,
+			Column:  9,
+			Message: "undefined: undefinedThing",
+		},
+		{
+			Package: "example.com/be/broken",
+			File:    "broken/broken.go",
+			Line:    8,
+			Column:  9,
+			Message: "cannot use 1 (untyped int constant) as string value in return statement",
+		},
+	}
+	assert.DeepEqual(t, exec.BuildErrors(), expected)
+	assert.Equal(t, len(exec.Errors()), 0)
+	assert.Equal(t, exec.ErrorCount(), 3)
+	assert.DeepEqual(t, exec.Packages(),
+		[]string{"example.com/be/badtest", "example.com/be/broken", "example.com/be/good"})
+
+	pkg := exec.Package("example.com/be/broken")
+	assert.Assert(t, pkg.BuildFailed())
+	assert.DeepEqual(t, pkg.BuildOutput(), []string{
+		"# example.com/be/broken\n",
+		"broken/broken.go:4:9: undefined: undefinedThing\n",
+		"broken/broken.go:8:9: cannot use 1 (untyped int constant) as string value in return statement\n",
+	})
+	assert.Assert(t, !exec.Package("example.com/be/good").BuildFailed())
+}
+
+func TestScanTestOutput_WithBuildOutputFromSuccessfulBuild(t *testing.T) {
+	source := `{"ImportPath":"example.com/pkg","Action":"build-output","Output":"# example.com/pkg\n"}
+{"ImportPath":"example.com/pkg","Action":"build-output","Output":"./pkg.go:3:6: can inline Value\n"}
+{"Time":"2024-02-11T10:00:00Z","Action":"start","Package":"example.com/pkg"}
+{"Time":"2024-02-11T10:00:01Z","Action":"output","Package":"example.com/pkg","Output":"ok  \texample.com/pkg\t0.003s\n"}
+{"Time":"2024-02-11T10:00:01Z","Action":"pass","Package":"example.com/pkg","Elapsed":0.003}
+`
+	exec, err := ScanTestOutput(ScanConfig{Stdout: strings.NewReader(source)})
+	assert.NilError(t, err)
+
+	assert.Equal(t, len(exec.BuildErrors()), 0)
+	pkg := exec.Package("example.com/pkg")
+	assert.Assert(t, !pkg.BuildFailed())
+	assert.Equal(t, len(pkg.BuildOutput()), 2)
+	assert.Equal(t, pkg.Result(), ActionPass)
+}
+
+func TestPackage_BuildElapsed(t *testing.T) {
+	source := `{"Time":"2024-02-11T10:00:00Z","ImportPath":"example.com/broken","Action":"build-output","Output":"# example.com/broken\n"}
+{"Time":"2024-02-11T10:00:01Z","ImportPath":"example.com/broken","Action":"build-output","Output":"./broken.go:4:9: undefined: thing\n"}
+{"Time":"2024-02-11T10:00:02.5Z","ImportPath":"example.com/broken","Action":"build-fail"}
+{"ImportPath":"example.com/notime","Action":"build-output","Output":"# example.com/notime\n"}
+{"ImportPath":"example.com/notime","Action":"build-fail"}
+{"Time":"2024-02-11T10:00:03Z","Action":"start","Package":"example.com/ok"}
+{"Time":"2024-02-11T10:00:03Z","Action":"pass","Package":"example.com/ok","Elapsed":0}
+`
+	exec, err := ScanTestOutput(ScanConfig{Stdout: strings.NewReader(source)})
+	assert.NilError(t, err)
+
+	assert.Equal(t, exec.Package("example.com/broken").BuildElapsed(), 2500*time.Millisecond)
+	assert.Equal(t, exec.Package("example.com/notime").BuildElapsed(), time.Duration(0))
+	assert.Equal(t, exec.Package("example.com/ok").BuildElapsed(), time.Duration(0))
+}
@@ -0,0 +1,139 @@
+package testjson
+
+import (
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// FailureCluster is a group of failed tests with the same Signature. Tests
+// with the same signature most likely failed because of the same root cause.
+type FailureCluster struct {
+	// Signature is the output of the failed tests, after the parts that are
+	// expected to be different for each test are removed. See FailureSignature.
+	Signature string
+	// Tests are the failed tests with the signature, in the order they
+	// appear in Execution.Failed. A test which failed more than once, ex: with
+	// -count or --rerun-fails, is only included once.
+	Tests []TestCase
+	// Count is the number of failures with the signature, including the
+	// failures of tests which failed more than once.
+	Count int
+}
+
+var (
+	// matches the location printed by the testing package at the start of a
+	// line of output, ex: "    file_test.go:12: "
+	signatureLocation = regexp.MustCompile(`^\s*[\w.-]+\.go:\d+: `)
+	// matches timestamps, ex: 2024-01-02T15:04:05.999Z or 15:04:05.999
+	signatureTimestamp = regexp.MustCompile(
+		`(\d{4}-\d{2}-\d{2}[T ])?\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:?\d{2})?`)
+	signatureAddress = regexp.MustCompile(`0x[0-9a-fA-F]+`)
+	signatureNumber  = regexp.MustCompile(`\d+`)
+	signatureTempDir = tempDirPattern()
+)
+
+// tempDirPattern matches a path in the temp directory, ex: the directories
+// created by testing.T.TempDir.
+func tempDirPattern() *regexp.Regexp {
+	dirs := []string{"/tmp", "/var/folders", "/private/var/folders"}
+	if tmp := os.TempDir(); tmp != "" {
+		dirs = append(dirs, tmp)
+	}
+	patterns := make([]string, 0, len(dirs))
+	for _, dir := range dirs {
+		patterns = append(patterns, regexp.QuoteMeta(dir))
+	}
+	return regexp.MustCompile(`(` + strings.Join(patterns, "|") + `)[/\\][^\s:'"]*`)
+}
+
+// FailureSignature returns the signature of the output of a failed test. The
+// signature is the output without the framing lines from the testing package,
+// and with the location of each line, timestamps, temp paths, memory
+// addresses, and numbers replaced by placeholders.
+func FailureSignature(lines []string) string {
+	var result []string
+	for _, line := range lines {
+		line = strings.TrimRight(line, "\n")
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "", isFramingLine(line),
+			strings.HasPrefix(trimmed, "--- FAIL: "),
+			trimmed == "FAIL",
+			strings.HasPrefix(trimmed, "exit status "):
+			continue
+		}
+		line = signatureLocation.ReplaceAllString(line, "")
+		line = signatureTimestamp.ReplaceAllString(line, "<time>")
+		line = signatureTempDir.ReplaceAllString(line, "<tmp>")
+		line = signatureAddress.ReplaceAllString(line, "<addr>")
+		line = signatureNumber.ReplaceAllString(line, "N")
+		result = append(result, strings.TrimSpace(line))
+	}
+	return strings.Join(result, "\n")
+}
+
+// FailureClusters groups the failed tests by the signature of their output.
+// Only signatures shared by more than one test are returned, because a
+// failure that is not like any other is already in the list of failed tests.
+// The clusters are sorted by the number of failures, largest first.
+//
+// Root tests which failed because of a failed subtest, and tests with no
+// output, are not included.
+func (e *Execution) FailureClusters() []FailureCluster {
+	index := make(map[string]int)
+	seen := make(map[string]bool)
+	var clusters []FailureCluster
+	for _, tc := range e.failedUniqueInOrder() {
+		signature := FailureSignature(e.OutputLines(tc))
+		if signature == "" {
+			continue
+		}
+		i, ok := index[signature]
+		if !ok {
+			i = len(clusters)
+			index[signature] = i
+			clusters = append(clusters, FailureCluster{Signature: signature})
+		}
+		clusters[i].Count++
+
+		key := signature + "\n" + tc.Package + "\n" + tc.Test.Name()
+		if !seen[key] {
+			seen[key] = true
+			clusters[i].Tests = append(clusters[i].Tests, tc)
+		}
+	}
+
+	result := make([]FailureCluster, 0, len(clusters))
+	for _, cluster := range clusters {
+		if len(cluster.Tests) > 1 {
+			result = append(result, cluster)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Count > result[j].Count
+	})
+	return result
+}
+
+// failedUniqueInOrder returns the tests from FilterFailedUnique in the order
+// they appear in Execution.Failed. FilterFailedUnique sorts the tests by
+// package, which would lose the order of the failures.
+func (e *Execution) failedUniqueInOrder() []TestCase {
+	type key struct {
+		pkg string
+		id  int
+	}
+	unique := make(map[key]bool)
+	for _, tc := range FilterFailedUnique(e.Failed()) {
+		unique[key{pkg: tc.Package, id: tc.ID}] = true
+	}
+	var result []TestCase
+	for _, tc := range e.Failed() {
+		if unique[key{pkg: tc.Package, id: tc.ID}] {
+			result = append(result, tc)
+		}
+	}
+	return result
+}
@@ -0,0 +1,65 @@
+package testjson
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"gotest.tools/gotestsum/internal/text"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/golden"
+)
+
+func TestFailureSignature(t *testing.T) {
+	lines := []string{
+		"=== RUN   TestUsers\n",
+		"    users_test.go:17: service at 127.0.0.1:34355 unreachable at 2026-10-19T10:30:23.656591694Z\n",
+		"    users_test.go:20: fixture /tmp/TestUsers3198796970/001/users.json is missing\n",
+		"    users_test.go:22: handler 0xc000102ea0 returned 500 at 10:30:23.656\n",
+		"--- FAIL: TestUsers (0.00s)\n",
+	}
+	expected := "service at N.N.N.N:N unreachable at <time>\n" +
+		"fixture <tmp> is missing\n" +
+		"handler <addr> returned N at <time>"
+	assert.Equal(t, FailureSignature(lines), expected)
+}
+
+func TestExecution_FailureClusters(t *testing.T) {
+	exec, err := ScanTestOutput(ScanConfig{
+		Stdout: bytes.NewReader(golden.Get(t, "input/go-test-json-clusters.out")),
+	})
+	assert.NilError(t, err)
+
+	clusters := exec.FailureClusters()
+	assert.Equal(t, len(clusters), 2)
+
+	names := func(cluster FailureCluster) []string {
+		var result []string
+		for _, tc := range cluster.Tests {
+			result = append(result, tc.Test.Name())
+		}
+		return result
+	}
+	assert.DeepEqual(t, names(clusters[0]), []string{"TestUsers", "TestOrders/create", "TestInventory"})
+	assert.Equal(t, clusters[0].Count, 3)
+	assert.Equal(t, clusters[0].Signature,
+		"service at N.N.N.N:N unreachable at <time>: dial tcp N.N.N.N:N: connect: connection refused")
+	assert.DeepEqual(t, names(clusters[1]), []string{"TestFixtureUsers", "TestFixtureOrders"})
+	assert.Equal(t, clusters[1].Signature, "fixture <tmp> is missing")
+
+	out := new(bytes.Buffer)
+	PrintSummary(out, exec, SummarizeAll|SummarizeClusters)
+	actual := text.ProcessLines(t, out, text.OpRemoveSummaryLineElapsedTime)
+	golden.Assert(t, actual, "summary/with-failure-clusters")
+}
+
+func TestExecution_FailureClusters_RepeatedTest(t *testing.T) {
+	in := golden.Get(t, "input/go-test-json.out")
+	exec, err := ScanTestOutput(ScanConfig{
+		Stdout: io.MultiReader(bytes.NewReader(in), bytes.NewReader(in), bytes.NewReader(in)),
+	})
+	assert.NilError(t, err)
+	// each test failed the same way 3 times, but failed differently from the
+	// other tests
+	assert.Equal(t, len(exec.FailureClusters()), 0)
+}
@@ -14,7 +14,7 @@ import (
 	"gotest.tools/gotestsum/internal/log"
 )
 
-func dotsFormatV1(out io.Writer) EventFormatter {
+func dotsFormatV1(out io.Writer, opts FormatOptions) EventFormatter {
 	buf := bufio.NewWriter(out)
 	// nolint:errcheck
 	return eventFormatterFunc(func(event TestEvent, exec *Execution) error {
@@ -26,13 +26,13 @@ func dotsFormatV1(out io.Writer) EventFormatter {
 			buf.WriteString("[" + RelativePackagePath(event.Package) + "]")
 			return buf.Flush()
 		}
-		buf.WriteString(fmtDot(event))
+		buf.WriteString(fmtDot(opts.Color, event))
 		return buf.Flush()
 	})
 }
 
-func fmtDot(event TestEvent) string {
-	withColor := colorEvent(event)
+func fmtDot(mode ColorMode, event TestEvent) string {
+	withColor := colorEvent(mode, event)
 	switch event.Action {
 	case ActionPass:
 		return withColor("·")
@@ -79,7 +79,7 @@ func newDotFormatter(out io.Writer, opts FormatOptions) EventFormatter {
 	w, _, err := term.GetSize(int(os.Stdout.Fd()))
 	if err != nil || w == 0 {
 		log.Warnf("Failed to detect terminal width for dots format, error: %v", err)
-		return dotsFormatV1(out)
+		return dotsFormatV1(out, opts)
 	}
 	return &dotFormatter{
 		pkgs:      make(map[string]*dotLine),
@@ -90,6 +90,9 @@ func newDotFormatter(out io.Writer, opts FormatOptions) EventFormatter {
 }
 
 func (d *dotFormatter) Format(event TestEvent, exec *Execution) error {
+	if event.BuildEvent() {
+		return nil
+	}
 	if d.pkgs[event.Package] == nil {
 		d.pkgs[event.Package] = &dotLine{builder: new(strings.Builder)}
 		d.order = append(d.order, event.Package)
@@ -98,7 +101,7 @@ func (d *dotFormatter) Format(event TestEvent, exec *Execution) error {
 	line.lastUpdate = event.Time
 
 	if !event.PackageEvent() {
-		line.update(fmtDot(event))
+		line.update(fmtDot(d.opts.Color, event))
 	}
 	switch event.Action {
 	case ActionOutput, ActionBench:
@@ -29,6 +29,13 @@ const (
 	ActionFail   Action = "fail"
 	ActionOutput Action = "output"
 	ActionSkip   Action = "skip"
+	ActionStart  Action = "start"
+	ActionAttr   Action = "attr"
+
+	// ActionBuildOutput and ActionBuildFail are sent by go1.24+ for the output
+	// of the build of a package, and when the build fails.
+	ActionBuildOutput Action = "build-output"
+	ActionBuildFail   Action = "build-fail"
 )
 
 // IsTerminal returns true if the Action is one of: pass, fail, skip.
@@ -52,6 +59,18 @@ type TestEvent struct {
 	Elapsed float64
 	// Output of test or benchmark
 	Output string
+	// ImportPath of the package being built. ImportPath is only set on
+	// build-output and build-fail events, which do not have a Package.
+	// ex: example.com/pkg_test [example.com/pkg.test]
+	ImportPath string
+	// FailedBuild is the ImportPath of the package that failed to build. It is
+	// set on the fail event of a package that could not be run because of the
+	// build failure.
+	FailedBuild string
+	// Key and Value of an attribute of the test, set on attr events.
+	// See testing.T.Attr.
+	Key   string
+	Value string
 	// raw is the raw JSON bytes of the event
 	raw []byte
 	// RunID from the ScanConfig which produced this test event.
@@ -63,6 +82,12 @@ func (e TestEvent) PackageEvent() bool {
 	return e.Test == ""
 }
 
+// BuildEvent returns true if the event is a build-output or build-fail event.
+// A BuildEvent is also a PackageEvent.
+func (e TestEvent) BuildEvent() bool {
+	return e.Action == ActionBuildOutput || e.Action == ActionBuildFail
+}
+
 // ElapsedFormatted returns Elapsed formatted in the go test format, ex (0.00s).
 func (e TestEvent) ElapsedFormatted() string {
 	return fmt.Sprintf("(%.2fs)", e.Elapsed)
@@ -101,6 +126,18 @@ type Package struct {
 	action Action
 	// cached is true if the package was marked as (cached)
 	cached bool
+	// buildFailed is true if the package was marked as [build failed], or
+	// [setup failed]. The errors are available from Execution.BuildErrors.
+	buildFailed bool
+	// buildOutput is the output from build-output events for the package.
+	buildOutput []string
+	// buildStarted and buildEnded are the times of the first build event for
+	// the package, and of the build-fail or start event that followed it.
+	buildStarted time.Time
+	buildEnded   time.Time
+	// buildErrors are parsed from the lines of buildOutput that were followed
+	// by a build-fail event.
+	buildErrors []BuildError
 	// panicked is true if the package, or one of the tests in the package,
 	// contained output that looked like a panic. This is used to mitigate
 	// github.com/golang/go/issues/45508. This field may be removed in the future
@@ -110,6 +147,17 @@ type Package struct {
 	// tests are run with -shuffle
 	shuffleSeed string
 
+	// raceParser finds race detector reports in the output.
+	raceParser raceParser
+	// pendingRaces are race reports which have not been attributed to a test.
+	pendingRaces []DataRace
+	// races detected in the package.
+	races []DataRace
+	// panicParser finds panics in the output.
+	panicParser panicParser
+	// panics found in the output of the package.
+	panics []Panic
+
 	// testTimeoutPanicInTest stores the name of a test that received the panic
 	// output caused by a test timeout. This is necessary to work around a race
 	// condition in test2json. See https://github.com/golang/go/issues/57305.
@@ -128,6 +176,13 @@ func (p *Package) Elapsed() time.Duration {
 	return p.elapsed
 }
 
+// Coverage returns the code coverage reported by the package
+// (ex: coverage: 91.1% of statements), or an empty string if the package was
+// not run with -cover.
+func (p *Package) Coverage() string {
+	return p.coverage
+}
+
 // TestCases returns all the test cases.
 func (p *Package) TestCases() []TestCase {
 	tc := append([]TestCase{}, p.Passed...)
@@ -151,6 +206,30 @@ func (p *Package) LastFailedByName(name string) TestCase {
 	return TestCase{}
 }
 
+// TestCaseByName returns the most recent TestCase with name, from either the
+// running tests, or the Passed, Failed, or Skipped tests. If no TestCase is
+// found with that name, an empty TestCase is returned.
+//
+// TestCaseByName may be used by formatters to find the TestCase.ID for any
+// TestEvent, including events for a test that has not finished.
+func (p *Package) TestCaseByName(name string) TestCase {
+	if tc, ok := p.running[name]; ok {
+		return tc
+	}
+	var result TestCase
+	for _, tcs := range [][]TestCase{p.Passed, p.Failed, p.Skipped} {
+		for i := len(tcs) - 1; i >= 0; i-- {
+			if tcs[i].Test.Name() == name {
+				if tcs[i].ID > result.ID {
+					result = tcs[i]
+				}
+				break
+			}
+		}
+	}
+	return result
+}
+
 // Output returns the full test output for a test. Unlike OutputLines() it does
 // not return lines from subtests in some cases.
 //
@@ -256,6 +335,18 @@ func (p *Package) TestMainFailed() bool {
 	return p.action == ActionFail && len(p.Failed) == 0
 }
 
+// BuildFailed returns true if the package failed to build, or the setup of
+// the package failed, so no tests were run.
+func (p *Package) BuildFailed() bool {
+	return p.buildFailed
+}
+
+// ShuffleSeed returns the seed used to shuffle the tests in the package, or an
+// empty string if the tests were not run with -shuffle.
+func (p *Package) ShuffleSeed() string {
+	return strings.TrimPrefix(p.shuffleSeed, "-test.shuffle ")
+}
+
 // IsEmpty returns true if this package contains no tests.
 func (p *Package) IsEmpty() bool {
 	return p.Total == 0 && !p.TestMainFailed()
@@ -265,12 +356,23 @@ const neverFinished time.Duration = -1
 
 // end adds any tests that were missing an ActionFail TestEvent to the list of
 // Failed, and returns a slice of artificial TestEvent for the missing ones.
+// The tests are marked as Incomplete, because the test binary exited while
+// they were running.
 //
 // This is done to work around 'go test' not sending the ActionFail TestEvents
-// in some cases, when a test panics.
+// in some cases, when a test panics, or calls os.Exit.
 func (p *Package) end() []TestEvent {
+	running := make([]TestCase, 0, len(p.running))
+	for _, tc := range p.running {
+		running = append(running, tc)
+	}
+	// sort by ID so that the tests are failed in the order they started
+	sort.Slice(running, func(i, j int) bool {
+		return running[i].ID < running[j].ID
+	})
+
 	result := make([]TestEvent, 0, len(p.running))
-	for k, tc := range p.running {
+	for _, tc := range running {
 		if tc.Test.IsSubTest() && rootTestPassed(p, tc) {
 			// mitigate github.com/golang/go/issues/40771 (gotestsum/issues/141)
 			// by skipping missing subtest end events when the root test passed.
@@ -278,6 +380,7 @@ func (p *Package) end() []TestEvent {
 		}
 
 		tc.Elapsed = neverFinished
+		tc.Incomplete = p.newIncomplete(tc)
 		p.Failed = append(p.Failed, tc)
 
 		result = append(result, TestEvent{
@@ -286,7 +389,7 @@ func (p *Package) end() []TestEvent {
 			Test:    tc.Test.Name(),
 			Elapsed: float64(neverFinished),
 		})
-		delete(p.running, k)
+		delete(p.running, tc.Test.Name())
 	}
 	return result
 }
@@ -334,6 +437,13 @@ type TestCase struct {
 	hasSubTestFailed bool
 	// Time when the test was run.
 	Time time.Time
+	// Attrs are the attributes of the test, in the order they were set by
+	// testing.T.Attr. Attributes are not inherited by subtests.
+	Attrs []Attr
+	// Incomplete is set when the test did not finish, because the test binary
+	// exited while the test was running. Incomplete tests are included in
+	// Package.Failed.
+	Incomplete *Incomplete
 }
 
 func newPackage() *Package {
@@ -349,12 +459,24 @@ type Execution struct {
 	started    time.Time
 	packages   map[string]*Package
 	errorsLock sync.RWMutex
-	errors     []string
-	done       bool
-	lastRunID  int
+	// errors are the lines from stderr, including the header lines of build
+	// errors. See BuildErrors and OtherErrors.
+	errors []string
+	// errorParser parses each line of errors as it is added. The lines are
+	// split into stderrBuildErrors, and otherErrors which are not part of a
+	// build error.
+	errorParser       buildErrorParser
+	stderrBuildErrors []BuildError
+	otherErrors       []string
+	done              bool
+	lastRunID         int
 }
 
 func (e *Execution) add(event TestEvent) {
+	if event.BuildEvent() {
+		e.addBuildEvent(event)
+		return
+	}
 	pkg, ok := e.packages[event.Package]
 	if !ok {
 		pkg = newPackage()
@@ -372,13 +494,27 @@ func (p *Package) addEvent(event TestEvent) {
 	case ActionPass, ActionFail:
 		p.action = event.Action
 		p.elapsed = elapsedDuration(event.Elapsed)
+		if event.FailedBuild != "" {
+			p.buildFailed = true
+		}
+		p.attributeRaces(TestCase{})
+		p.endPanic()
+	case ActionStart:
+		if !p.buildStarted.IsZero() && p.buildEnded.IsZero() {
+			p.buildEnded = event.Time
+		}
 	case ActionOutput:
+		p.addRaceOutput(TestCase{Package: event.Package}, event.Output)
+		p.addPanicOutput(TestCase{Package: event.Package, RunID: event.RunID}, event.Output)
 		if coverage, ok := isCoverageOutput(event.Output); ok {
 			p.coverage = coverage
 		}
 		if strings.Contains(event.Output, "\t(cached)") {
 			p.cached = true
 		}
+		if isBuildFailedOutput(event.Output) {
+			p.buildFailed = true
+		}
 		if isShuffleSeedOutput(event.Output) {
 			p.shuffleSeed = strings.TrimRight(event.Output, "\n")
 		}
@@ -422,6 +558,8 @@ func (p *Package) addTestEvent(event TestEvent) {
 
 	switch event.Action {
 	case ActionOutput, ActionBench:
+		p.addRaceOutput(tc, event.Output)
+		p.addPanicOutput(tc, event.Output)
 		if strings.HasPrefix(event.Output, "panic: test timed out") {
 			p.testTimeoutPanicInTest = event.Test
 		}
@@ -435,6 +573,10 @@ func (p *Package) addTestEvent(event TestEvent) {
 		return
 	case ActionPause, ActionCont:
 		return
+	case ActionAttr:
+		tc.Attrs = append(tc.Attrs, Attr{Key: event.Key, Value: event.Value})
+		p.running[event.Test] = tc
+		return
 	}
 
 	// the event.Action must be one of the three "test end" events
@@ -537,8 +679,11 @@ func (e *Execution) Failed() []TestCase {
 
 		// Add package-level failure output if there were no failed tests, or
 		// if the test timeout was reached (because we now have to store that
-		// output on the package).
-		if pkg.TestMainFailed() || pkg.testTimeoutPanicInTest != "" {
+		// output on the package). A package that failed to build is reported
+		// by its build errors instead, when the errors are attributed to it.
+		switch {
+		case pkg.TestMainFailed() && pkg.BuildFailed() && len(e.PackageBuildErrors(name)) > 0:
+		case pkg.TestMainFailed() || pkg.testTimeoutPanicInTest != "":
 			failed = append(failed, TestCase{Package: name})
 		}
 		failed = append(failed, pkg.Failed...)
@@ -612,12 +757,9 @@ func (e *Execution) Total() int {
 }
 
 func (e *Execution) addError(err string) {
-	// Build errors start with a header
-	if strings.HasPrefix(err, "# ") {
-		return
-	}
 	e.errorsLock.Lock()
 	e.errors = append(e.errors, err)
+	e.parseError(err)
 	e.errorsLock.Unlock()
 }
 
@@ -625,7 +767,14 @@ func (e *Execution) addError(err string) {
 func (e *Execution) Errors() []string {
 	e.errorsLock.RLock()
 	defer e.errorsLock.RUnlock()
-	return e.errors
+	var result []string
+	for _, err := range e.errors {
+		// Build errors start with a header
+		if !strings.HasPrefix(err, "# ") {
+			result = append(result, err)
+		}
+	}
+	return result
 }
 
 // HasPanic returns true if at least one package had output that looked like a
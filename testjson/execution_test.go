@@ -33,7 +33,7 @@ func TestExecution_Add_PackageCoverage(t *testing.T) {
 }
 
 var cmpPackage = cmp.Options{
-	cmp.AllowUnexported(Package{}),
+	cmp.AllowUnexported(Package{}, raceParser{}, panicParser{}),
 	cmpopts.EquateEmpty(),
 }
 
@@ -298,3 +298,22 @@ func TestFilterFailedUnique_MultipleNested(t *testing.T) {
 	cmpTestCase := cmp.AllowUnexported(TestCase{})
 	assert.DeepEqual(t, expected, actual, cmpTestCase)
 }
+
+func TestPackage_TestCaseByName(t *testing.T) {
+	p := newPackage()
+	for _, raw := range []string{
+		`{"Action":"run","Package":"example.com/pkg","Test":"TestOne"}`,
+		`{"Action":"fail","Package":"example.com/pkg","Test":"TestOne"}`,
+		`{"Action":"run","Package":"example.com/pkg","Test":"TestTwo"}`,
+		`{"Action":"run","Package":"example.com/pkg","Test":"TestOne"}`,
+		`{"Action":"pass","Package":"example.com/pkg","Test":"TestOne"}`,
+	} {
+		event, err := parseEvent([]byte(raw))
+		assert.NilError(t, err)
+		p.addTestEvent(event)
+	}
+
+	assert.Equal(t, p.TestCaseByName("TestTwo").ID, 2)
+	assert.Equal(t, p.TestCaseByName("TestOne").ID, 3)
+	assert.Equal(t, p.TestCaseByName("TestMissing").ID, 0)
+}
@@ -38,7 +38,8 @@ func standardVerboseFormat(out io.Writer) EventFormatter {
 func standardQuietFormat(out io.Writer) EventFormatter {
 	buf := bufio.NewWriter(out)
 	return eventFormatterFunc(func(event TestEvent, _ *Execution) error {
-		if !event.PackageEvent() {
+		// build output is printed to stderr, like the stderr of 'go test'
+		if !event.PackageEvent() || event.BuildEvent() {
 			return nil
 		}
 		if event.Output == "PASS\n" {
@@ -73,7 +74,7 @@ func standardJSONFormat(out io.Writer) EventFormatter {
 	})
 }
 
-func testNameFormat(out io.Writer) EventFormatter {
+func testNameFormat(out io.Writer, opts FormatOptions) EventFormatter {
 	buf := bufio.NewWriter(out)
 	// nolint:errcheck
 	return eventFormatterFunc(func(event TestEvent, exec *Execution) error {
@@ -81,7 +82,7 @@ func testNameFormat(out io.Writer) EventFormatter {
 			pkgPath := RelativePackagePath(event.Package)
 
 			fmt.Fprintf(buf, "%s %s%s %s\n",
-				colorEvent(event)(strings.ToUpper(string(event.Action))),
+				colorEvent(opts.Color, event)(strings.ToUpper(string(event.Action))),
 				joinPkgToTestName(pkgPath, event.Test),
 				formatRunID(event.RunID),
 				event.ElapsedFormatted())
@@ -98,10 +99,10 @@ func testNameFormat(out io.Writer) EventFormatter {
 				return nil
 			}
 
-			result := colorEvent(event)(strings.ToUpper(string(event.Action)))
+			result := colorEvent(opts.Color, event)(strings.ToUpper(string(event.Action)))
 			pkg := exec.Package(event.Package)
 			if event.Action == ActionSkip || (event.Action == ActionPass && pkg.Total == 0) {
-				result = colorEvent(event)("EMPTY")
+				result = colorEvent(opts.Color, event)("EMPTY")
 			}
 
 			event.Elapsed = 0 // hide elapsed for now, for backwards compat
@@ -199,7 +200,7 @@ func shortFormatPackageEvent(opts FormatOptions, event TestEvent, exec *Executio
 	fmtEvent := func(action string) string {
 		return action + "  " + packageLine(event, exec.Package(event.Package))
 	}
-	withColor := colorEvent(event)
+	withColor := colorEvent(opts.Color, event)
 	switch event.Action {
 	case ActionSkip:
 		if opts.HideEmptyPackages {
@@ -236,6 +237,10 @@ func packageLine(event TestEvent, pkg *Package) string {
 		buf.WriteString(" (" + pkg.coverage + ")")
 	}
 
+	if event.Action == ActionFail && pkg.buildFailed {
+		buf.WriteString(" [build failed]")
+	}
+
 	if event.Action == ActionFail && pkg.shuffleSeed != "" {
 		buf.WriteString(" (" + pkg.shuffleSeed + ")")
 	}
@@ -260,16 +265,35 @@ func pkgNameWithFailuresFormat(out io.Writer, opts FormatOptions) eventFormatter
 	}
 }
 
-func colorEvent(event TestEvent) func(format string, a ...interface{}) string {
+func colorEvent(mode ColorMode, event TestEvent) func(format string, a ...interface{}) string {
+	attr := color.FgWhite
 	switch event.Action {
 	case ActionPass:
-		return color.GreenString
+		attr = color.FgGreen
 	case ActionFail:
-		return color.RedString
+		attr = color.FgRed
 	case ActionSkip:
-		return color.YellowString
+		attr = color.FgYellow
+	}
+	c := newColor(mode, attr)
+	return func(format string, a ...interface{}) string {
+		if len(a) == 0 {
+			return c.Sprint(format)
+		}
+		return c.Sprintf(format, a...)
+	}
+}
+
+// newColor returns a color which uses the color setting from mode.
+func newColor(mode ColorMode, attrs ...color.Attribute) *color.Color {
+	c := color.New(attrs...)
+	switch mode {
+	case ColorAlways:
+		c.EnableColor()
+	case ColorNever:
+		c.DisableColor()
 	}
-	return color.WhiteString
+	return c
 }
 
 // EventFormatter is a function which handles an event and returns a string to
@@ -287,9 +311,32 @@ func (e eventFormatterFunc) Format(event TestEvent, output *Execution) error {
 type FormatOptions struct {
 	HideEmptyPackages    bool
 	UseHiVisibilityIcons bool
+	// Color sets whether the formatter prints colors. The default uses the
+	// global color.NoColor setting.
+	Color ColorMode
 }
 
-// NewEventFormatter returns a formatter for printing events.
+// ColorMode sets whether a formatter prints colors.
+type ColorMode int
+
+const (
+	// ColorAuto uses the global color.NoColor setting.
+	ColorAuto ColorMode = iota
+	// ColorAlways prints colors, even when color.NoColor is true.
+	ColorAlways
+	// ColorNever does not print colors, even when color.NoColor is false.
+	ColorNever
+)
+
+// TemplateFormatPrefix is the prefix of a format that prints each event using
+// a text/template. The rest of the format is the template, or the path to a
+// file which contains the template after TemplateFilePrefix. See
+// ParseTemplate.
+const TemplateFormatPrefix = "template="
+
+// NewEventFormatter returns a formatter for printing events. NewEventFormatter
+// returns nil if the format is not known, or if the template from a format
+// with TemplateFormatPrefix fails to parse.
 func NewEventFormatter(out io.Writer, format string, formatOpts FormatOptions) EventFormatter {
 	switch format {
 	case "none":
@@ -303,16 +350,26 @@ func NewEventFormatter(out io.Writer, format string, formatOpts FormatOptions) E
 	case "standard-quiet":
 		return standardQuietFormat(out)
 	case "dots", "dots-v1":
-		return dotsFormatV1(out)
+		return dotsFormatV1(out, formatOpts)
 	case "dots-v2":
 		return newDotFormatter(out, formatOpts)
 	case "testname", "short-verbose":
-		return testNameFormat(out)
+		return testNameFormat(out, formatOpts)
 	case "pkgname", "short":
 		return pkgNameFormat(out, formatOpts)
 	case "pkgname-and-test-fails", "short-with-failures":
 		return pkgNameWithFailuresFormat(out, formatOpts)
-	default:
-		return nil
+	case "quickfix":
+		return newQuickfixFormat(out)
+	case "github-actions":
+		return newGithubActionsFormat(out, formatOpts)
+	}
+	if strings.HasPrefix(format, TemplateFormatPrefix) {
+		tmpl, err := ParseTemplate(strings.TrimPrefix(format, TemplateFormatPrefix))
+		if err != nil {
+			return nil
+		}
+		return NewTemplateFormatter(out, tmpl, formatOpts)
 	}
+	return nil
 }
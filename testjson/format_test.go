@@ -5,6 +5,7 @@ import (
 	"io"
 	"testing"
 
+	"github.com/fatih/color"
 	"gotest.tools/v3/assert"
 	"gotest.tools/v3/golden"
 )
@@ -87,13 +88,17 @@ func TestFormats_DefaultGoTestJson(t *testing.T) {
 
 	testCases := []testCase{
 		{
-			name:        "testname",
-			format:      testNameFormat,
+			name: "testname",
+			format: func(out io.Writer) EventFormatter {
+				return testNameFormat(out, FormatOptions{})
+			},
 			expectedOut: "format/testname.out",
 		},
 		{
-			name:        "dots-v1",
-			format:      dotsFormatV1,
+			name: "dots-v1",
+			format: func(out io.Writer) EventFormatter {
+				return dotsFormatV1(out, FormatOptions{})
+			},
 			expectedOut: "format/dots-v1.out",
 		},
 		{
@@ -132,6 +137,20 @@ func TestFormats_DefaultGoTestJson(t *testing.T) {
 			format:      standardJSONFormat,
 			expectedOut: "input/go-test-json.out",
 		},
+		{
+			name: "quickfix",
+			format: func(out io.Writer) EventFormatter {
+				return newQuickfixFormat(out)
+			},
+			expectedOut: "format/quickfix.out",
+		},
+		{
+			name: "github-actions",
+			format: func(out io.Writer) EventFormatter {
+				return newGithubActionsFormat(out, FormatOptions{})
+			},
+			expectedOut: "format/github-actions.out",
+		},
 	}
 
 	for _, tc := range testCases {
@@ -172,8 +191,10 @@ func TestFormats_Coverage(t *testing.T) {
 
 	testCases := []testCase{
 		{
-			name:        "testname",
-			format:      testNameFormat,
+			name: "testname",
+			format: func(out io.Writer) EventFormatter {
+				return testNameFormat(out, FormatOptions{})
+			},
 			expectedOut: "format/testname-coverage.out",
 		},
 		{
@@ -240,8 +261,10 @@ func TestFormats_Shuffle(t *testing.T) {
 
 	testCases := []testCase{
 		{
-			name:        "testname",
-			format:      testNameFormat,
+			name: "testname",
+			format: func(out io.Writer) EventFormatter {
+				return testNameFormat(out, FormatOptions{})
+			},
 			expectedOut: "format/testname-shuffle.out",
 		},
 		{
@@ -269,3 +292,29 @@ func TestFormats_Shuffle(t *testing.T) {
 		})
 	}
 }
+
+func TestFormats_ColorMode(t *testing.T) {
+	event := TestEvent{Action: ActionFail, Package: "example.com/pkg", Test: "TestOne"}
+	format := func(mode ColorMode) string {
+		out := new(bytes.Buffer)
+		exec := newExecution()
+		exec.add(event)
+		formatter := NewEventFormatter(out, "testname", FormatOptions{Color: mode})
+		assert.NilError(t, formatter.Format(event, exec))
+		return out.String()
+	}
+
+	orig := color.NoColor
+	defer func() {
+		color.NoColor = orig
+	}()
+
+	color.NoColor = true
+	assert.Equal(t, format(ColorAuto), "FAIL example.com/pkg.TestOne (0.00s)\n")
+	assert.Equal(t, format(ColorAlways), "\x1b[31mFAIL\x1b[0m example.com/pkg.TestOne (0.00s)\n")
+	assert.Assert(t, color.NoColor)
+
+	color.NoColor = false
+	assert.Equal(t, format(ColorNever), "FAIL example.com/pkg.TestOne (0.00s)\n")
+	assert.Assert(t, !color.NoColor)
+}
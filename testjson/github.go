@@ -0,0 +1,111 @@
+package testjson
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// githubActionsFormat prints a line for each package, and the output of each
+// failed test in a collapsed group. Test failures and build errors are also
+// printed as workflow commands, which GitHub Actions shows as annotations on
+// the lines of the files.
+type githubActionsFormat struct {
+	// mu protects out, because stderr lines are formatted concurrently with
+	// events.
+	mu   sync.Mutex
+	out  *bufio.Writer
+	opts FormatOptions
+}
+
+func newGithubActionsFormat(out io.Writer, opts FormatOptions) *githubActionsFormat {
+	return &githubActionsFormat{out: bufio.NewWriter(out), opts: opts}
+}
+
+func (f *githubActionsFormat) Format(event TestEvent, exec *Execution) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if event.PackageEvent() {
+		f.out.WriteString(shortFormatPackageEvent(f.opts, event, exec)) // nolint: errcheck
+		return f.out.Flush()
+	}
+	if event.Action != ActionFail {
+		return nil
+	}
+
+	pkg := exec.Package(event.Package)
+	tc := pkg.LastFailedByName(event.Test)
+	dir := RelativePackagePath(event.Package)
+	fmt.Fprintf(f.out, "::group::%s %s\n",
+		colorEvent(f.opts.Color, event)("FAIL"), joinPkgToTestName(dir, event.Test))
+	pkg.WriteOutputTo(f.out, tc.ID) // nolint: errcheck
+	fmt.Fprintln(f.out, "::endgroup::")
+
+	for _, loc := range quickfixLocations(pkg.OutputLines(tc)) {
+		line, _ := strconv.Atoi(loc.line)
+		writeGithubAnnotation(f.out, githubAnnotation{
+			file:    loc.path(dir),
+			line:    line,
+			title:   event.Test,
+			message: loc.message,
+		})
+	}
+	return f.out.Flush()
+}
+
+// FormatErr prints an annotation for each line from stderr that is a build
+// error with the location of the error.
+func (f *githubActionsFormat) FormatErr(text string) error {
+	e, ok := parseBuildErrorLine(text)
+	if !ok {
+		return nil
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	writeGithubAnnotation(f.out, githubAnnotation{
+		file:    e.File,
+		line:    e.Line,
+		col:     e.Column,
+		title:   "Build failed",
+		message: e.Message,
+	})
+	return f.out.Flush()
+}
+
+type githubAnnotation struct {
+	file    string
+	line    int
+	col     int
+	title   string
+	message string
+}
+
+// writeGithubAnnotation writes an error workflow command to out, see
+// https://docs.github.com/en/actions/using-workflows/workflow-commands-for-github-actions#setting-an-error-message
+func writeGithubAnnotation(out *bufio.Writer, a githubAnnotation) {
+	props := []string{"file=" + escapeGithubProperty(a.file)}
+	if a.line > 0 {
+		props = append(props, "line="+strconv.Itoa(a.line))
+	}
+	if a.col > 0 {
+		props = append(props, "col="+strconv.Itoa(a.col))
+	}
+	if a.title != "" {
+		props = append(props, "title="+escapeGithubProperty(a.title))
+	}
+	fmt.Fprintf(out, "::error %s::%s\n",
+		strings.Join(props, ","), escapeGithubData(a.message))
+}
+
+func escapeGithubData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+func escapeGithubProperty(s string) string {
+	return strings.NewReplacer(
+		"%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C",
+	).Replace(s)
+}
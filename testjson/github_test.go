@@ -0,0 +1,73 @@
+package testjson
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/golden"
+)
+
+func TestGithubActionsFormat_WithBuildErrors(t *testing.T) {
+	out := new(bytes.Buffer)
+	f := newGithubActionsFormat(out, FormatOptions{})
+	_, err := ScanTestOutput(ScanConfig{
+		Stdout:  bytes.NewReader(golden.Get(t, "input/go-test-json-build-failed.out")),
+		Stderr:  bytes.NewReader(golden.Get(t, "input/go-test-json-build-failed.err")),
+		Handler: &errFormatHandler{formatter: f},
+	})
+	assert.NilError(t, err)
+
+	lines := strings.Split(out.String(), "\n")
+	assert.Assert(t, hasLine(lines, "✖  example.com/be/broken [build failed]"), out.String())
+	assert.Assert(t, hasLine(lines,
+		"::error file=broken/broken.go,line=4,col=9,title=Build failed::undefined: undefinedThing"),
+		out.String())
+	assert.Assert(t, hasLine(lines,
+		`::error file=badtest/badtest_test.go,line=9,col=14,title=Build failed::`+
+			`fmt.Printf format %25d has arg "not a number" of wrong type string`),
+		out.String())
+}
+
+func hasLine(lines []string, line string) bool {
+	for _, l := range lines {
+		if l == line {
+			return true
+		}
+	}
+	return false
+}
+
+// errFormatHandler sends events and stderr lines to the formatter. Events and
+// stderr lines are scanned concurrently, so the order of the output is not
+// stable.
+type errFormatHandler struct {
+	formatter interface {
+		EventFormatter
+		ErrFormatter
+	}
+}
+
+func (h *errFormatHandler) Event(event TestEvent, exec *Execution) error {
+	return h.formatter.Format(event, exec)
+}
+
+func (h *errFormatHandler) Err(text string) error {
+	return h.formatter.FormatErr(text)
+}
+
+func TestWriteGithubAnnotation_Escapes(t *testing.T) {
+	out := new(bytes.Buffer)
+	f := newGithubActionsFormat(out, FormatOptions{})
+	writeGithubAnnotation(f.out, githubAnnotation{
+		file:    "dir,name/a:b.go",
+		line:    3,
+		title:   "TestOne",
+		message: "100% wrong\nsecond line",
+	})
+	assert.NilError(t, f.out.Flush())
+
+	expected := "::error file=dir%2Cname/a%3Ab.go,line=3,title=TestOne::100%25 wrong%0Asecond line\n"
+	assert.Equal(t, out.String(), expected)
+}
@@ -0,0 +1,99 @@
+package testjson
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Incomplete describes a test that did not finish, because the test binary
+// exited while the test was running. A test binary may exit early when a test
+// calls os.Exit, when the -timeout is reached, or when a goroutine panics.
+type Incomplete struct {
+	// Reason the test binary exited, ex: exit status 3, or the value of a
+	// panic. If the reason is not known, Reason is incompleteUnknownReason.
+	Reason string
+	// Output is the last lines of output from the test, without the framing
+	// lines printed by the testing package.
+	Output []string
+}
+
+const (
+	// incompleteOutputLines is the maximum number of lines of output saved
+	// for an incomplete test.
+	incompleteOutputLines = 10
+
+	incompleteUnknownReason = "the test binary exited before the test finished"
+)
+
+// matches the line printed by 'go test' when the test binary exits non-zero,
+// ex: exit status 3
+var exitStatusLine = regexp.MustCompile(`^exit status \d+$`)
+
+// incompleteReason returns the reason the test binary exited while tc was
+// running. A panic from the same run of the package is the most likely reason,
+// because a panic in any goroutine exits the test binary.
+func (p *Package) incompleteReason(tc TestCase) string {
+	for i := len(p.panics) - 1; i >= 0; i-- {
+		if p.panics[i].Test.RunID == tc.RunID {
+			return p.panics[i].Message()
+		}
+	}
+	for _, line := range p.output[0] {
+		if line = strings.TrimSpace(line); exitStatusLine.MatchString(line) {
+			return line
+		}
+	}
+	return incompleteUnknownReason
+}
+
+// newIncomplete returns the Incomplete for a test which was still running when
+// the package ended. The output of a panic is not included, because the panic
+// is the Reason, and the stack is available from Panics.
+func (p *Package) newIncomplete(tc TestCase) *Incomplete {
+	var lines []string
+	for _, line := range p.output[tc.ID] {
+		if strings.HasPrefix(line, panicPrefix) {
+			break
+		}
+		if !isFramingLine(line) {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) > incompleteOutputLines {
+		lines = lines[len(lines)-incompleteOutputLines:]
+	}
+	return &Incomplete{Reason: p.incompleteReason(tc), Output: lines}
+}
+
+// Incomplete returns the tests in the package that did not finish. Incomplete
+// tests are also included in Failed.
+func (p *Package) Incomplete() []TestCase {
+	var result []TestCase
+	for _, tc := range p.Failed {
+		if tc.Incomplete != nil {
+			result = append(result, tc)
+		}
+	}
+	return result
+}
+
+// Incomplete returns the tests from all packages that did not finish, because
+// the test binary exited while they were running.
+func (e *Execution) Incomplete() []TestCase {
+	var result []TestCase
+	for _, name := range sortedKeys(e.packages) {
+		result = append(result, e.packages[name].Incomplete()...)
+	}
+	return result
+}
+
+// withoutIncomplete returns the test cases which are not incomplete.
+func withoutIncomplete(tcs []TestCase) []TestCase {
+	result := make([]TestCase, 0, len(tcs))
+	for _, tc := range tcs {
+		if tc.Incomplete == nil {
+			result = append(result, tc)
+		}
+	}
+	return result
+}
@@ -0,0 +1,71 @@
+package testjson
+
+import (
+	"bytes"
+	"testing"
+
+	"gotest.tools/gotestsum/internal/text"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/golden"
+)
+
+func TestScanTestOutput_WithIncompleteTests(t *testing.T) {
+	exec, err := ScanTestOutput(ScanConfig{
+		Stdout: bytes.NewReader(golden.Get(t, "input/go-test-json-exit.out")),
+	})
+	assert.NilError(t, err)
+
+	incomplete := exec.Incomplete()
+	assert.Equal(t, len(incomplete), 2)
+	assert.Equal(t, incomplete[0].Test.Name(), "TestExit")
+	assert.DeepEqual(t, incomplete[0].Incomplete, &Incomplete{
+		Reason: incompleteUnknownReason,
+	})
+	assert.Equal(t, incomplete[1].Test.Name(), "TestExit/sub")
+	assert.DeepEqual(t, incomplete[1].Incomplete, &Incomplete{
+		Reason: incompleteUnknownReason,
+		Output: []string{"    inc_test.go:12: about to exit\n"},
+	})
+
+	// incomplete tests are still failures
+	assert.Equal(t, len(exec.Failed()), 2)
+
+	out := new(bytes.Buffer)
+	PrintSummary(out, exec, SummarizeAll)
+	actual := text.ProcessLines(t, out, text.OpRemoveSummaryLineElapsedTime)
+	golden.Assert(t, actual, "summary/with-incomplete")
+}
+
+func TestPackage_IncompleteReason(t *testing.T) {
+	exec := newExecution()
+	for _, event := range []TestEvent{
+		{Action: ActionRun, Package: "pkg", Test: "TestExit"},
+		{Action: ActionOutput, Package: "pkg", Test: "TestExit", Output: "=== RUN   TestExit\n"},
+		{Action: ActionOutput, Package: "pkg", Output: "exit status 3\n"},
+		{Action: ActionOutput, Package: "pkg", Output: "FAIL\tpkg\t0.003s\n"},
+		{Action: ActionFail, Package: "pkg"},
+	} {
+		exec.add(event)
+	}
+	exec.end()
+
+	incomplete := exec.Incomplete()
+	assert.Equal(t, len(incomplete), 1)
+	assert.DeepEqual(t, incomplete[0].Incomplete, &Incomplete{Reason: "exit status 3"})
+}
+
+func TestNewIncomplete_OutputIsTruncated(t *testing.T) {
+	pkg := newPackage()
+	tc := TestCase{ID: 1, Test: "TestMany"}
+	pkg.addOutput(tc.ID, "=== RUN   TestMany\n")
+	for i := 0; i < 15; i++ {
+		pkg.addOutput(tc.ID, "line\n")
+	}
+	pkg.addOutput(tc.ID, "last\n")
+	pkg.addOutput(tc.ID, "panic: boom\n")
+	pkg.addOutput(tc.ID, "goroutine 8 [running]:\n")
+
+	incomplete := pkg.newIncomplete(tc)
+	assert.Equal(t, len(incomplete.Output), incompleteOutputLines)
+	assert.Equal(t, incomplete.Output[incompleteOutputLines-1], "last\n")
+}
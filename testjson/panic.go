@@ -0,0 +1,224 @@
+package testjson
+
+import (
+	"strings"
+)
+
+// Panic is a panic found in the output of a test, or a package.
+type Panic struct {
+	// Test which printed the panic. Test.ID is 0 when the panic was printed
+	// as output of the package, which is always the case for a Timeout.
+	Test TestCase
+	// Value of the panic, ex: runtime error: index out of range [3] with length 3
+	Value string
+	// Goroutine is the header of the goroutine that panicked,
+	// ex: goroutine 8 [running]
+	Goroutine string
+	// Frames is the stack of the goroutine that panicked, without the frames
+	// from the runtime and testing packages.
+	Frames []StackFrame
+	// Source is the first frame from the module under test, which is usually
+	// the line that caused the panic. Source is empty if no frame was found.
+	Source StackFrame
+	// Timeout is true when the panic was caused by a test running longer than
+	// the -timeout flag.
+	Timeout bool
+	// RunningTests are the tests that were running when a Timeout panic
+	// happened, including the elapsed time, ex: TestSlow (10m0s)
+	RunningTests []string
+}
+
+// Message returns a single line which describes the panic.
+func (p Panic) Message() string {
+	if p.Timeout {
+		return p.Value
+	}
+	return panicPrefix + p.Value
+}
+
+// panicParser finds a panic in the output of a package. The goroutine dump
+// that follows the stack of the goroutine that panicked is ignored. A line
+// that starts with "panic: " is only a panic once it is followed by a
+// goroutine header, so that a test which logs such a line has no panic.
+type panicParser struct {
+	// current is the panic being parsed, or nil.
+	current *Panic
+	// state is the part of the panic that is being parsed.
+	state panicParserState
+}
+
+type panicParserState int
+
+const (
+	panicStateValue panicParserState = iota
+	panicStateRunningTests
+	panicStateStack
+)
+
+const (
+	panicPrefix        = "panic: "
+	panicTimeoutPrefix = "test timed out after "
+)
+
+// add a line of output to the parser. A panic is returned once the stack of
+// the goroutine that panicked is complete.
+func (r *panicParser) add(tc TestCase, output string) *Panic {
+	line := strings.TrimRight(output, "\n")
+	// a panic without a goroutine header yet is replaced by a later panic
+	if strings.HasPrefix(line, panicPrefix) && (r.current == nil || r.current.Goroutine == "") {
+		value := strings.TrimPrefix(line, panicPrefix)
+		value = strings.TrimSuffix(value, " [recovered, repanicked]")
+		value = strings.TrimSuffix(value, " [recovered]")
+		r.current = &Panic{
+			Test:    tc,
+			Value:   value,
+			Timeout: strings.HasPrefix(value, panicTimeoutPrefix),
+		}
+		r.state = panicStateValue
+		return nil
+	}
+	if r.current == nil {
+		return nil
+	}
+
+	trimmed := strings.TrimSpace(line)
+	switch r.state {
+	case panicStateValue, panicStateRunningTests:
+		switch {
+		case trimmed == "running tests:":
+			r.state = panicStateRunningTests
+		case isGoroutineHeader(line):
+			r.current.Goroutine = strings.TrimSuffix(line, ":")
+			r.state = panicStateStack
+		case r.state == panicStateRunningTests && trimmed != "":
+			r.current.RunningTests = append(r.current.RunningTests, trimmed)
+		}
+		return nil
+	}
+
+	switch {
+	case strings.HasPrefix(line, "\t"):
+		if n := len(r.current.Frames); n > 0 && r.current.Frames[n-1].File == "" {
+			frame := &r.current.Frames[n-1]
+			frame.File, frame.Line = parseFrameLocation(line)
+		}
+		return nil
+	case trimmed == "" || !isStackFrameFunction(line):
+		return r.end()
+	}
+	r.current.Frames = append(r.current.Frames, StackFrame{Function: line})
+	return nil
+}
+
+// end returns the panic being parsed, if there is one, and its goroutine
+// header was found.
+func (r *panicParser) end() *Panic {
+	p := r.current
+	r.current = nil
+	if p == nil || p.Goroutine == "" {
+		return nil
+	}
+	if p.Timeout {
+		// the timeout is not caused by the test which received the output
+		p.Test = TestCase{Package: p.Test.Package, RunID: p.Test.RunID}
+	}
+	p.Frames = trimPanicFrames(p.Frames)
+	p.Source = moduleFrame(p.Test.Package, p.Frames)
+	return p
+}
+
+func isGoroutineHeader(line string) bool {
+	return strings.HasPrefix(line, "goroutine ") && strings.HasSuffix(line, "]:")
+}
+
+// isStackFrameFunction returns true if line is the first line of a stack
+// frame, ex: example.com/pkg.TestSomething(0xc000102ea0)
+func isStackFrameFunction(line string) bool {
+	if line == "" || line[0] == ' ' || line[0] == '\t' {
+		return false
+	}
+	return strings.HasSuffix(line, ")") || strings.HasPrefix(line, "created by ")
+}
+
+// trimPanicFrames removes the frames from the runtime and testing packages,
+// and the frame where the goroutine was created.
+func trimPanicFrames(frames []StackFrame) []StackFrame {
+	result := make([]StackFrame, 0, len(frames))
+	for _, frame := range frames {
+		switch {
+		case strings.HasPrefix(frame.Function, "created by "),
+			strings.HasPrefix(frame.Function, "panic("),
+			strings.HasPrefix(frame.Function, "runtime."),
+			strings.HasPrefix(frame.Function, "testing."):
+			continue
+		}
+		result = append(result, frame)
+	}
+	return result
+}
+
+// moduleFrame returns the first frame of a function from the module under
+// test. If pkg is not in the module, the first frame from pkg is returned.
+func moduleFrame(pkg string, frames []StackFrame) StackFrame {
+	prefix := pkg
+	if pkgPathPrefix != "" && strings.HasPrefix(pkg, pkgPathPrefix) {
+		prefix = pkgPathPrefix
+	}
+	for _, frame := range frames {
+		if strings.HasPrefix(frame.Function, prefix+".") ||
+			strings.HasPrefix(frame.Function, prefix+"/") {
+			return frame
+		}
+	}
+	return StackFrame{}
+}
+
+// addPanicOutput parses a panic from the output of tc.
+func (p *Package) addPanicOutput(tc TestCase, output string) {
+	if found := p.panicParser.add(tc, output); found != nil {
+		p.panics = append(p.panics, *found)
+	}
+}
+
+// endPanic adds a panic that is still being parsed when the package ends.
+func (p *Package) endPanic() {
+	if found := p.panicParser.end(); found != nil {
+		p.panics = append(p.panics, *found)
+	}
+}
+
+// Panics returns the panics found in the output of the package.
+func (p *Package) Panics() []Panic {
+	return p.panics
+}
+
+// Panics returns the panics found in the output of all packages.
+func (e *Execution) Panics() []Panic {
+	var result []Panic
+	for _, name := range e.Packages() {
+		result = append(result, e.packages[name].panics...)
+	}
+	return result
+}
+
+// trimTimeoutPanicOutput removes the goroutine dump which follows a test
+// timeout panic from lines. The panic value and the list of running tests are
+// kept.
+func trimTimeoutPanicOutput(lines []string) []string {
+	var inTimeout, inDump bool
+	result := make([]string, 0, len(lines))
+	for _, line := range lines {
+		switch {
+		case strings.HasPrefix(line, panicPrefix+panicTimeoutPrefix):
+			inTimeout = true
+		case inTimeout && isGoroutineHeader(strings.TrimRight(line, "\n")):
+			inTimeout, inDump = false, true
+		case inDump && (strings.HasPrefix(line, "FAIL") || strings.HasPrefix(line, "exit status")):
+			inDump = false
+		}
+		if !inDump {
+			result = append(result, line)
+		}
+	}
+	return result
+}
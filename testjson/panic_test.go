@@ -0,0 +1,109 @@
+package testjson
+
+import (
+	"bytes"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/golden"
+)
+
+func TestScanTestOutput_WithPanic(t *testing.T) {
+	exec, err := ScanTestOutput(ScanConfig{
+		Stdout: bytes.NewReader(golden.Get(t, "input/go-test-json-panic.out")),
+	})
+	assert.NilError(t, err)
+
+	panics := exec.Panics()
+	assert.Equal(t, len(panics), 1)
+	p := panics[0]
+	assert.Equal(t, p.Test.Test.Name(), "TestPanic")
+	assert.Equal(t, p.Value, "runtime error: invalid memory address or nil pointer dereference")
+	assert.Equal(t, p.Message(), "panic: runtime error: invalid memory address or nil pointer dereference")
+	assert.Equal(t, p.Goroutine, "goroutine 8 [running]")
+	assert.Assert(t, !p.Timeout)
+
+	expected := []StackFrame{
+		{Function: "example.com/pan.explode(...)", File: "/tmp/pan/pan_test.go", Line: 10},
+		{Function: "example.com/pan.TestPanic.func1(0x218bbc1946c8?)", File: "/tmp/pan/pan_test.go", Line: 19},
+	}
+	assert.DeepEqual(t, p.Frames, expected)
+	assert.DeepEqual(t, p.Source, expected[0])
+}
+
+func TestScanTestOutput_WithTimeoutPanic(t *testing.T) {
+	exec, err := ScanTestOutput(ScanConfig{
+		Stdout: bytes.NewReader(golden.Get(t, "input/go-test-json-timeout.out")),
+	})
+	assert.NilError(t, err)
+
+	panics := exec.Panics()
+	assert.Equal(t, len(panics), 1)
+	p := panics[0]
+	assert.Assert(t, p.Timeout)
+	assert.Equal(t, p.Test.ID, 0)
+	assert.Equal(t, p.Test.Package, "example.com/pan")
+	assert.Equal(t, p.Message(), "test timed out after 1s")
+	assert.DeepEqual(t, p.RunningTests, []string{"TestSlow (1s)", "TestSlow/inner (1s)"})
+}
+
+func TestPanicParser_EndOfOutput(t *testing.T) {
+	p := newPackage()
+	for _, line := range []string{
+		"panic: boom\n",
+		"\n",
+		"goroutine 1 [running]:\n",
+		"example.com/pkg.init.0()\n",
+		"\t/src/pkg/pkg.go:7 +0x25\n",
+	} {
+		p.addEvent(TestEvent{Action: ActionOutput, Package: "example.com/pkg", Output: line})
+	}
+	p.addEvent(TestEvent{Action: ActionFail, Package: "example.com/pkg"})
+
+	panics := p.Panics()
+	assert.Equal(t, len(panics), 1)
+	assert.Equal(t, panics[0].Value, "boom")
+	assert.DeepEqual(t, panics[0].Source,
+		StackFrame{Function: "example.com/pkg.init.0()", File: "/src/pkg/pkg.go", Line: 7})
+}
+
+func TestPanicParser_WithoutGoroutineHeader(t *testing.T) {
+	p := newPackage()
+	for _, line := range []string{
+		"=== RUN   TestLogs\n",
+		"panic: not really, only logged by the test\n",
+		"    logs_test.go:9: more output\n",
+		"--- PASS: TestLogs (0.00s)\n",
+	} {
+		p.addEvent(TestEvent{Action: ActionOutput, Package: "example.com/pkg", Test: "TestLogs", Output: line})
+	}
+	p.addEvent(TestEvent{Action: ActionPass, Package: "example.com/pkg"})
+	assert.Equal(t, len(p.Panics()), 0)
+}
+
+func TestTrimTimeoutPanicOutput(t *testing.T) {
+	lines := []string{
+		"=== RUN   TestSlow\n",
+		"panic: test timed out after 1s\n",
+		"\trunning tests:\n",
+		"\t\tTestSlow (1s)\n",
+		"\n",
+		"goroutine 8 [running]:\n",
+		"testing.(*M).startAlarm.func1()\n",
+		"\t/usr/lib/go/src/testing/testing.go:2959 +0x34a\n",
+		"\n",
+		"goroutine 1 [chan receive]:\n",
+		"main.main()\n",
+		"\t_testmain.go:52 +0x9b\n",
+		"FAIL\texample.com/pan\t1.004s\n",
+	}
+	expected := []string{
+		"=== RUN   TestSlow\n",
+		"panic: test timed out after 1s\n",
+		"\trunning tests:\n",
+		"\t\tTestSlow (1s)\n",
+		"\n",
+		"FAIL\texample.com/pan\t1.004s\n",
+	}
+	assert.DeepEqual(t, trimTimeoutPanicOutput(lines), expected)
+}
@@ -0,0 +1,136 @@
+package testjson
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// ErrFormatter is an optional interface implemented by an EventFormatter that
+// also formats the lines 'go test' writes to stderr, like build errors.
+type ErrFormatter interface {
+	FormatErr(text string) error
+}
+
+// quickfixFormat prints a line for each test failure in the format
+// 'file:line: TestName: message'. The format can be parsed by the default
+// errorformat of Vim and Neovim, and by Emacs compilation-mode.
+type quickfixFormat struct {
+	// mu protects out, because stderr lines are formatted concurrently with
+	// events.
+	mu  sync.Mutex
+	out *bufio.Writer
+}
+
+func newQuickfixFormat(out io.Writer) *quickfixFormat {
+	return &quickfixFormat{out: bufio.NewWriter(out)}
+}
+
+var (
+	// matches the file and line prefix added by testing.T.Log and friends
+	testLogLocation = regexp.MustCompile(`^\s+(\S+\.go):(\d+): ?(.*)$`)
+	// matches a frame in a goroutine stack trace
+	stackFrameLocation = regexp.MustCompile(`^\t(\S+_test\.go):(\d+)`)
+)
+
+func (f *quickfixFormat) Format(event TestEvent, exec *Execution) error {
+	if event.PackageEvent() || event.Action != ActionFail {
+		return nil
+	}
+	pkg := exec.Package(event.Package)
+	tc := pkg.LastFailedByName(event.Test)
+	dir := RelativePackagePath(event.Package)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, loc := range quickfixLocations(pkg.OutputLines(tc)) {
+		fmt.Fprintf(f.out, "%s:%s: %s: %s\n",
+			loc.path(dir), loc.line, event.Test, loc.message)
+	}
+	return f.out.Flush()
+}
+
+// FormatErr prints lines from stderr that already contain a file and line
+// number, like compiler errors.
+func (f *quickfixFormat) FormatErr(text string) error {
+	if _, ok := parseBuildErrorLine(text); !ok {
+		return nil
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.out.WriteString(text) // nolint: errcheck
+	f.out.WriteRune('\n')   // nolint: errcheck
+	return f.out.Flush()
+}
+
+type quickfixLocation struct {
+	file    string
+	line    string
+	message string
+	// inStack is true when the location was found in a stack trace, where the
+	// file is a full path instead of a base name.
+	inStack bool
+}
+
+// quickfixLocations returns a location for each line of output logged by a
+// failing test. If the output contains no such lines, and the test panicked,
+// the location of the first test file in the stack trace is returned.
+func quickfixLocations(lines []string) []quickfixLocation {
+	var result []quickfixLocation
+	for i, line := range lines {
+		match := testLogLocation.FindStringSubmatch(strings.TrimRight(line, "\n"))
+		if match == nil {
+			continue
+		}
+		loc := quickfixLocation{file: match[1], line: match[2], message: match[3]}
+		// multi-line messages start on the next line
+		if loc.message == "" && i+1 < len(lines) {
+			loc.message = strings.TrimSpace(lines[i+1])
+		}
+		result = append(result, loc)
+	}
+	if len(result) > 0 {
+		return result
+	}
+
+	var panicMsg string
+	for _, line := range lines {
+		if panicMsg == "" && strings.HasPrefix(line, "panic: ") {
+			panicMsg = strings.TrimSpace(line)
+			continue
+		}
+		if panicMsg == "" {
+			continue
+		}
+		if match := stackFrameLocation.FindStringSubmatch(line); match != nil {
+			return []quickfixLocation{
+				{file: match[1], line: match[2], message: panicMsg, inStack: true},
+			}
+		}
+	}
+	return nil
+}
+
+// path returns the path to the file relative to the working directory.
+func (l quickfixLocation) path(pkgDir string) string {
+	switch {
+	case filepath.IsAbs(l.file):
+		if cwd, err := os.Getwd(); err == nil {
+			if rel, err := filepath.Rel(cwd, l.file); err == nil {
+				return rel
+			}
+		}
+		return l.file
+	case l.inStack:
+		// binaries built with -trimpath use the package import path
+		return RelativePackagePath(l.file)
+	default:
+		// test output includes only the base name of the file
+		return filepath.Join(pkgDir, l.file)
+	}
+}
@@ -0,0 +1,110 @@
+package testjson
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestQuickfixFormat_Panic(t *testing.T) {
+	patchPkgPathPrefix(t, "example.com")
+	source := `{"Action":"run","Package":"example.com/pkg","Test":"TestPanics"}
+{"Action":"output","Package":"example.com/pkg","Test":"TestPanics","Output":"=== RUN   TestPanics\n"}
+{"Action":"output","Package":"example.com/pkg","Test":"TestPanics","Output":"--- FAIL: TestPanics (0.00s)\n"}
+{"Action":"output","Package":"example.com/pkg","Test":"TestPanics","Output":"panic: runtime error: index out of range [3] with length 2 [recovered]\n"}
+{"Action":"output","Package":"example.com/pkg","Test":"TestPanics","Output":"goroutine 7 [running]:\n"}
+{"Action":"output","Package":"example.com/pkg","Test":"TestPanics","Output":"testing.tRunner.func1.2({0x5173a0, 0xc000016198})\n"}
+{"Action":"output","Package":"example.com/pkg","Test":"TestPanics","Output":"\t/usr/local/go/src/testing/testing.go:1396 +0x24e\n"}
+{"Action":"output","Package":"example.com/pkg","Test":"TestPanics","Output":"example.com/pkg.TestPanics(0x0?)\n"}
+{"Action":"output","Package":"example.com/pkg","Test":"TestPanics","Output":"\tpkg/panics_test.go:9 +0x1d\n"}
+{"Action":"fail","Package":"example.com/pkg","Test":"TestPanics","Elapsed":0}
+{"Action":"output","Package":"example.com/pkg","Output":"FAIL\texample.com/pkg\t0.005s\n"}
+{"Action":"fail","Package":"example.com/pkg","Elapsed":0.005}
+`
+	out := new(bytes.Buffer)
+	_, err := ScanTestOutput(ScanConfig{
+		Stdout:  strings.NewReader(source),
+		Handler: &fakeHandler{formatter: newQuickfixFormat(out), err: new(bytes.Buffer)},
+	})
+	assert.NilError(t, err)
+
+	expected := "pkg/panics_test.go:9: TestPanics: " +
+		"panic: runtime error: index out of range [3] with length 2 [recovered]\n"
+	assert.Equal(t, out.String(), expected)
+}
+
+func TestQuickfixFormat_FormatErr(t *testing.T) {
+	out := new(bytes.Buffer)
+	f := newQuickfixFormat(out)
+
+	lines := []string{
+		"# gotest.tools/gotestsum/testjson/internal/broken",
+		"testjson/internal/broken/broken.go:5:21: undefined: somepackage",
+		"note: module requires Go 1.20",
+		"./main_test.go:12: unreachable code",
+	}
+	for _, line := range lines {
+		assert.NilError(t, f.FormatErr(line))
+	}
+
+	expected := `testjson/internal/broken/broken.go:5:21: undefined: somepackage
+./main_test.go:12: unreachable code
+`
+	assert.Equal(t, out.String(), expected)
+}
+
+// quickfixHandler sends stderr lines to the formatter, the same way the
+// handler in cmd does.
+type quickfixHandler struct {
+	formatter *quickfixFormat
+}
+
+func (h *quickfixHandler) Event(event TestEvent, exec *Execution) error {
+	return h.formatter.Format(event, exec)
+}
+
+func (h *quickfixHandler) Err(text string) error {
+	return h.formatter.FormatErr(text)
+}
+
+func TestQuickfixFormat_ConcurrentStdoutAndStderr(t *testing.T) {
+	const count = 50
+	stdout := new(strings.Builder)
+	stderr := new(strings.Builder)
+	for i := 0; i < count; i++ {
+		fmt.Fprintf(stdout, `{"Action":"run","Package":"example.com/pkg","Test":"TestOne%[1]d"}
+{"Action":"output","Package":"example.com/pkg","Test":"TestOne%[1]d","Output":"    one_test.go:%[1]d: failed\n"}
+{"Action":"fail","Package":"example.com/pkg","Test":"TestOne%[1]d"}
+`, i)
+		fmt.Fprintf(stderr, "./main_test.go:%d: unreachable code\n", i)
+	}
+
+	out := new(bytes.Buffer)
+	_, err := ScanTestOutput(ScanConfig{
+		Stdout:  strings.NewReader(stdout.String()),
+		Stderr:  strings.NewReader(stderr.String()),
+		Handler: &quickfixHandler{formatter: newQuickfixFormat(out)},
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, strings.Count(out.String(), "\n"), 2*count)
+}
+
+func TestQuickfixLocations_MultilineMessage(t *testing.T) {
+	lines := []string{
+		"=== RUN   TestOne\n",
+		"    one_test.go:12: \n",
+		"        assertion failed: 1 != 2\n",
+		"    one_test.go:14: second\n",
+		"--- FAIL: TestOne (0.00s)\n",
+	}
+	expected := []quickfixLocation{
+		{file: "one_test.go", line: "12", message: "assertion failed: 1 != 2"},
+		{file: "one_test.go", line: "14", message: "second"},
+	}
+	assert.DeepEqual(t, quickfixLocations(lines), expected, cmp.AllowUnexported(quickfixLocation{}))
+}
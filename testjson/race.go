@@ -0,0 +1,214 @@
+package testjson
+
+import (
+	"strconv"
+	"strings"
+)
+
+// DataRace is a report from the race detector, found in the output of tests
+// run with -race.
+type DataRace struct {
+	// Package where the race was detected.
+	Package string
+	// Tests which reported the race with the 'race detected during execution
+	// of test' line. An identical race reported by more than one test is
+	// stored once, with all the tests that reported it. Tests is empty if the
+	// race was not reported by a test.
+	Tests []TestCase
+	// Accesses are the stacks of the memory accesses that raced. The first is
+	// the access that detected the race, followed by the previous access.
+	Accesses []RaceStack
+	// Goroutines are the stacks where the goroutines from Accesses were
+	// created.
+	Goroutines []RaceStack
+	// Lines of the report, including the lines that start and end the report.
+	Lines []string
+}
+
+// RaceStack is a stack from a race report.
+type RaceStack struct {
+	// Header is the line before the stack, without the trailing colon,
+	// ex: Read at 0x00c000124008 by goroutine 8
+	Header string
+	Frames []StackFrame
+}
+
+// StackFrame is a single function call from a stack.
+type StackFrame struct {
+	// Function is the name of the function, ex: example.com/pkg.Func()
+	Function string
+	File     string
+	Line     int
+}
+
+const (
+	raceSeparator      = "=================="
+	raceWarning        = "WARNING: DATA RACE"
+	raceDetectedInTest = "race detected during execution of test"
+)
+
+// signature identifies identical races. It does not include addresses or
+// goroutine numbers, which are different each time a race is detected.
+func (r DataRace) signature() string {
+	var sb strings.Builder
+	for _, stack := range r.Accesses {
+		header := stack.Header
+		if i := strings.Index(header, " at "); i > 0 {
+			header = header[:i]
+		}
+		sb.WriteString(header)
+		sb.WriteByte('\n')
+		for _, frame := range stack.Frames {
+			sb.WriteString(frame.Function + " " + frame.File + ":" + strconv.Itoa(frame.Line) + "\n")
+		}
+	}
+	return sb.String()
+}
+
+// raceParser collects the lines of a race report from the output of a package.
+type raceParser struct {
+	// lines of the report. lines is empty when the parser is not in a report.
+	lines []string
+	// separator is true when the last line was a raceSeparator outside of a
+	// report.
+	separator bool
+}
+
+// add a line of output to the parser. The lines of a report are returned once
+// the report is complete.
+func (r *raceParser) add(output string) []string {
+	line := strings.TrimRight(output, "\n")
+	switch {
+	case len(r.lines) > 0 && line == raceSeparator:
+		lines := append(r.lines, line)
+		r.lines = nil
+		return lines
+	case len(r.lines) > 0:
+		r.lines = append(r.lines, line)
+	case r.separator && line == raceWarning:
+		r.lines = []string{raceSeparator, line}
+	}
+	r.separator = line == raceSeparator
+	return nil
+}
+
+// isRaceDetectedInTest returns true if output is the line printed by the
+// testing package when a race was detected while the test was running,
+// ex: testing.go:1465: race detected during execution of test
+func isRaceDetectedInTest(output string) bool {
+	output = strings.TrimSpace(output)
+	return strings.HasPrefix(output, "testing.go:") &&
+		strings.HasSuffix(output, ": "+raceDetectedInTest)
+}
+
+// parseDataRace parses the lines of a race report. Sections of the report
+// which are not a stack, like the location of a global variable, are only
+// included in Lines.
+func parseDataRace(pkg string, lines []string) DataRace {
+	race := DataRace{Package: pkg, Lines: lines}
+	var stack *RaceStack
+	var isGoroutine bool
+	endStack := func() {
+		if stack == nil {
+			return
+		}
+		if isGoroutine {
+			race.Goroutines = append(race.Goroutines, *stack)
+		} else {
+			race.Accesses = append(race.Accesses, *stack)
+		}
+		stack = nil
+	}
+
+	for _, line := range lines {
+		switch {
+		case line == "" || line == raceSeparator || line == raceWarning:
+			endStack()
+		case stack == nil && strings.HasSuffix(line, ":"):
+			header := strings.TrimSuffix(line, ":")
+			isGoroutine = strings.HasPrefix(header, "Goroutine ") &&
+				strings.HasSuffix(header, " created at")
+			if isGoroutine || strings.Contains(header, " at 0x") {
+				stack = &RaceStack{Header: header}
+			}
+		case stack == nil:
+		case strings.HasPrefix(line, "      "):
+			if n := len(stack.Frames); n > 0 {
+				stack.Frames[n-1].File, stack.Frames[n-1].Line = parseFrameLocation(line)
+			}
+		case strings.HasPrefix(line, "  "):
+			stack.Frames = append(stack.Frames, StackFrame{Function: strings.TrimSpace(line)})
+		}
+	}
+	endStack()
+	return race
+}
+
+// parseFrameLocation parses the file and line number from the second line of
+// a stack frame, ex: /path/to/file.go:12 +0x1d
+func parseFrameLocation(line string) (string, int) {
+	location := strings.TrimSpace(line)
+	if i := strings.LastIndex(location, " +0x"); i > 0 {
+		location = location[:i]
+	}
+	i := strings.LastIndex(location, ":")
+	if i < 0 {
+		return location, 0
+	}
+	n, err := strconv.Atoi(location[i+1:])
+	if err != nil {
+		return location, 0
+	}
+	return location[:i], n
+}
+
+// addRaceOutput parses race reports from the output of tc. Reports are
+// attributed to the test which prints the 'race detected during execution of
+// test' line, which may not be the test that received the report output.
+func (p *Package) addRaceOutput(tc TestCase, output string) {
+	if lines := p.raceParser.add(output); lines != nil {
+		p.pendingRaces = append(p.pendingRaces, parseDataRace(tc.Package, lines))
+		return
+	}
+	if tc.ID != 0 && isRaceDetectedInTest(output) {
+		p.attributeRaces(tc)
+	}
+}
+
+// attributeRaces adds all the pending race reports to the races of the
+// package. The reports are attributed to tc, unless tc is the package.
+func (p *Package) attributeRaces(tc TestCase) {
+	for _, race := range p.pendingRaces {
+		if tc.ID != 0 {
+			race.Tests = []TestCase{tc}
+		}
+		p.addRace(race)
+	}
+	p.pendingRaces = nil
+}
+
+func (p *Package) addRace(race DataRace) {
+	sig := race.signature()
+	for i, existing := range p.races {
+		if existing.signature() == sig {
+			p.races[i].Tests = append(p.races[i].Tests, race.Tests...)
+			return
+		}
+	}
+	p.races = append(p.races, race)
+}
+
+// Races returns the data races detected in the package. Identical races
+// reported by more than one test are only included once.
+func (p *Package) Races() []DataRace {
+	return p.races
+}
+
+// Races returns the data races detected in all packages.
+func (e *Execution) Races() []DataRace {
+	var result []DataRace
+	for _, name := range e.Packages() {
+		result = append(result, e.packages[name].races...)
+	}
+	return result
+}
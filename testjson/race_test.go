@@ -0,0 +1,125 @@
+package testjson
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/golden"
+)
+
+func TestScanTestOutput_WithRaces(t *testing.T) {
+	exec, err := ScanTestOutput(ScanConfig{
+		Stdout: bytes.NewReader(golden.Get(t, "input/go-test-json-race.out")),
+	})
+	assert.NilError(t, err)
+
+	races := exec.Races()
+	assert.Equal(t, len(races), 1)
+	race := races[0]
+	assert.Equal(t, race.Package, "example.com/race")
+	assert.DeepEqual(t, testNames(race.Tests), []string{"TestRaceOne"})
+
+	assert.Equal(t, len(race.Accesses), 2)
+	assert.Assert(t, strings.HasPrefix(race.Accesses[0].Header, "Read at 0x"), race.Accesses[0].Header)
+	assert.Assert(t, strings.HasPrefix(race.Accesses[1].Header, "Previous write at 0x"), race.Accesses[1].Header)
+	assert.DeepEqual(t, race.Accesses[0].Frames[0], StackFrame{
+		Function: "example.com/race.inc()",
+		File:     "/tmp/race/race_test.go",
+		Line:     10,
+	})
+
+	assert.Equal(t, len(race.Goroutines), 2)
+	assert.Assert(t, strings.HasSuffix(race.Goroutines[0].Header, "created at"), race.Goroutines[0].Header)
+	assert.DeepEqual(t, race.Goroutines[0].Frames[0], StackFrame{
+		Function: "example.com/race.TestRaceOne()",
+		File:     "/tmp/race/race_test.go",
+		Line:     15,
+	})
+	assert.Equal(t, race.Lines[0], raceSeparator)
+	assert.Equal(t, race.Lines[len(race.Lines)-1], raceSeparator)
+}
+
+func TestScanTestOutput_WithRacesDeduplicated(t *testing.T) {
+	in := golden.Get(t, "input/go-test-json-race.out")
+	exec, err := ScanTestOutput(ScanConfig{Stdout: bytes.NewReader(in)})
+	assert.NilError(t, err)
+	exec, err = ScanTestOutput(ScanConfig{Stdout: bytes.NewReader(in), Execution: exec, RunID: 1})
+	assert.NilError(t, err)
+
+	races := exec.Races()
+	assert.Equal(t, len(races), 1)
+	assert.DeepEqual(t, testNames(races[0].Tests), []string{"TestRaceOne", "TestRaceOne"})
+	assert.Equal(t, races[0].Tests[1].RunID, 1)
+}
+
+func TestPackage_AddEvent_RaceAttributedToReportingTest(t *testing.T) {
+	p := newPackage()
+	add := func(action Action, test string, output string) {
+		event := TestEvent{Action: action, Package: "example.com/pkg", Test: test, Output: output}
+		if test == "" {
+			p.addEvent(event)
+			return
+		}
+		p.addTestEvent(event)
+	}
+
+	add(ActionRun, "TestRacy", "")
+	add(ActionRun, "TestOther", "")
+	// the report is printed while TestOther is running
+	for _, line := range []string{
+		"==================\n",
+		"WARNING: DATA RACE\n",
+		"Write at 0x00c000012345 by goroutine 8:\n",
+		"  example.com/pkg.racy()\n",
+		"      /src/pkg/racy.go:12 +0x44\n",
+		"\n",
+		"Previous write at 0x00c000012345 by goroutine 7:\n",
+		"  example.com/pkg.racy()\n",
+		"      /src/pkg/racy.go:12 +0x44\n",
+		"==================\n",
+	} {
+		add(ActionOutput, "TestOther", line)
+	}
+	add(ActionPass, "TestOther", "")
+	assert.Equal(t, len(p.Races()), 0)
+
+	add(ActionOutput, "TestRacy", "    testing.go:1465: race detected during execution of test\n")
+	add(ActionFail, "TestRacy", "")
+	add(ActionFail, "", "")
+
+	races := p.Races()
+	assert.Equal(t, len(races), 1)
+	assert.DeepEqual(t, testNames(races[0].Tests), []string{"TestRacy"})
+	assert.Equal(t, len(races[0].Accesses), 2)
+	assert.Equal(t, races[0].Accesses[0].Header, "Write at 0x00c000012345 by goroutine 8")
+}
+
+func TestPackage_AddEvent_RaceOutsideOfTest(t *testing.T) {
+	p := newPackage()
+	for _, line := range []string{
+		"==================\n",
+		"WARNING: DATA RACE\n",
+		"Write at 0x00c000012345 by main goroutine:\n",
+		"  example.com/pkg.init()\n",
+		"      /src/pkg/racy.go:12 +0x44\n",
+		"==================\n",
+	} {
+		p.addEvent(TestEvent{Action: ActionOutput, Package: "example.com/pkg", Output: line})
+	}
+	p.addEvent(TestEvent{Action: ActionFail, Package: "example.com/pkg"})
+
+	races := p.Races()
+	assert.Equal(t, len(races), 1)
+	assert.Equal(t, len(races[0].Tests), 0)
+	assert.Equal(t, races[0].Accesses[0].Frames[0].Line, 12)
+}
+
+func testNames(tcs []TestCase) []string {
+	names := make([]string, 0, len(tcs))
+	for _, tc := range tcs {
+		names = append(names, tc.Test.Name())
+	}
+	return names
+}
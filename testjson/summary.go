@@ -21,7 +21,12 @@ const (
 	SummarizeFailed
 	SummarizeErrors
 	SummarizeOutput
-	SummarizeAll = SummarizeSkipped | SummarizeFailed | SummarizeErrors | SummarizeOutput
+	SummarizeRaces
+	SummarizePanics
+	SummarizeIncomplete
+	SummarizeClusters
+	SummarizeAll = SummarizeSkipped | SummarizeFailed | SummarizeErrors | SummarizeOutput |
+		SummarizeRaces | SummarizePanics | SummarizeIncomplete
 )
 
 var summaryValues = map[Summary]string{
@@ -29,6 +34,11 @@ var summaryValues = map[Summary]string{
 	SummarizeFailed:  "failed",
 	SummarizeErrors:  "errors",
 	SummarizeOutput:  "output",
+	SummarizeRaces:   "races",
+	SummarizePanics:  "panics",
+
+	SummarizeIncomplete: "incomplete",
+	SummarizeClusters:   "clusters",
 }
 
 var summaryFromValue = map[string]Summary{
@@ -37,7 +47,11 @@ var summaryFromValue = map[string]Summary{
 	"failed":  SummarizeFailed,
 	"errors":  SummarizeErrors,
 	"output":  SummarizeOutput,
-	"all":     SummarizeAll,
+	"races":   SummarizeRaces,
+	"panics":  SummarizePanics,
+
+	"incomplete": SummarizeIncomplete,
+	"all":        SummarizeAll,
 }
 
 func (s Summary) String() string {
@@ -68,25 +82,73 @@ func NewSummary(value string) (Summary, bool) {
 // PrintSummary of a test Execution. Prints a section for each summary type
 // followed by a DONE line to out.
 func PrintSummary(out io.Writer, execution *Execution, opts Summary) {
+	PrintSummaryWithOptions(out, execution, SummaryOptions{Sections: opts})
+}
+
+// SummaryAttrs filters and groups the skipped and failed tests in the summary
+// by the attributes set with testing.T.Attr.
+type SummaryAttrs struct {
+	// Filter the skipped and failed tests to those which have all of these
+	// attributes.
+	Filter []Attr
+	// GroupBy is the key of an attribute. When GroupBy is set the skipped
+	// and failed sections are printed once for each value of the attribute.
+	GroupBy string
+}
+
+// SummaryOptions are the options used by PrintSummaryWithOptions.
+type SummaryOptions struct {
+	// Sections of the summary to print.
+	Sections Summary
+	// Attrs filters and groups the skipped and failed tests. The counts on the
+	// DONE line include all tests.
+	Attrs SummaryAttrs
+	// ReproduceCommand returns the command that runs a failed test again.
+	// When ReproduceCommand is set, the command is printed after the output
+	// of each failed test.
+	ReproduceCommand func(TestCase) string
+}
+
+// PrintSummaryWithOptions is PrintSummary with all the options from
+// SummaryOptions.
+func PrintSummaryWithOptions(out io.Writer, execution *Execution, options SummaryOptions) {
+	opts, attrs := options.Sections, options.Attrs
 	execSummary := newExecSummary(execution, opts)
 	if opts.Includes(SummarizeSkipped) {
-		writeTestCaseSummary(out, execSummary, formatSkipped())
+		writeTestCaseSummary(out, execSummary, formatSkipped().withAttrs(attrs))
+	}
+	if opts.Includes(SummarizeClusters) {
+		writeClusterSummary(out, execution.FailureClusters())
 	}
 	if opts.Includes(SummarizeFailed) {
-		writeTestCaseSummary(out, execSummary, formatFailed())
+		failed := formatFailed().withAttrs(attrs)
+		failed.reproduce = options.ReproduceCommand
+		writeTestCaseSummary(out, execSummary, failed)
+	}
+	incomplete := execution.Incomplete()
+	if opts.Includes(SummarizeIncomplete) {
+		writeIncompleteSummary(out, incomplete, opts.Includes(SummarizeOutput), options.ReproduceCommand)
+	}
+	races := execution.Races()
+	if opts.Includes(SummarizeRaces) {
+		writeRaceSummary(out, races)
+	}
+	if opts.Includes(SummarizePanics) {
+		writePanicSummary(out, execution.Panics())
 	}
 
-	errors := execution.Errors()
 	if opts.Includes(SummarizeErrors) {
-		writeErrorSummary(out, errors)
+		writeErrorSummary(out, execution)
 	}
 
-	fmt.Fprintf(out, "\n%s %d tests%s%s%s in %s\n",
+	fmt.Fprintf(out, "\n%s %d tests%s%s%s%s%s in %s\n",
 		formatExecStatus(execution),
 		execution.Total(),
 		formatTestCount(len(execution.Skipped()), "skipped", ""),
-		formatTestCount(len(execution.Failed()), "failure", "s"),
-		formatTestCount(countErrors(errors), "error", "s"),
+		formatTestCount(len(execution.Failed())-len(incomplete), "failure", "s"),
+		formatTestCount(len(incomplete), "incomplete", ""),
+		formatTestCount(len(races), "data race", "s"),
+		formatTestCount(execution.ErrorCount(), "error", "s"),
 		FormatDurationAsSeconds(execution.Elapsed(), 3))
 }
 
@@ -120,15 +182,146 @@ func FormatDurationAsSeconds(d time.Duration, precision int) string {
 	return fmt.Sprintf("%.[2]*[1]fs", d.Seconds(), precision)
 }
 
-func writeErrorSummary(out io.Writer, errors []string) {
-	if len(errors) > 0 {
+func writeErrorSummary(out io.Writer, execution *Execution) {
+	if len(execution.BuildErrors()) > 0 || len(execution.OtherErrors()) > 0 {
 		fmt.Fprintln(out, color.MagentaString("\n=== Errors"))
 	}
-	for _, err := range errors {
+	for _, pkg := range execution.BuildFailedPackages() {
+		fmt.Fprintf(out, "=== %s: %s\n",
+			color.MagentaString("BUILD FAILED"), RelativePackagePath(pkg))
+		for _, err := range execution.PackageBuildErrors(pkg) {
+			fmt.Fprintln(out, err.String())
+		}
+	}
+	for _, err := range execution.OtherErrors() {
 		fmt.Fprintln(out, err)
 	}
 }
 
+// writeClusterSummary prints the number of failures, the names of the tests,
+// and the signature, of each group of failures with the same signature.
+func writeClusterSummary(out io.Writer, clusters []FailureCluster) {
+	if len(clusters) == 0 {
+		return
+	}
+	fmt.Fprintln(out, "\n=== "+color.RedString("Failure clusters"))
+	for idx, cluster := range clusters {
+		fmt.Fprintf(out, "=== %s: %d failures\n", color.RedString("CLUSTER"), cluster.Count)
+		for _, tc := range cluster.Tests {
+			fmt.Fprintf(out, "%s %s\n", RelativePackagePath(tc.Package), tc.Test)
+		}
+		fmt.Fprintln(out, "signature:")
+		for _, line := range strings.Split(cluster.Signature, "\n") {
+			fmt.Fprintln(out, "    "+line)
+		}
+		if idx+1 != len(clusters) {
+			fmt.Fprintln(out)
+		}
+	}
+}
+
+// writeIncompleteSummary prints the tests that were running when the test
+// binary exited, with the reason it exited, and the last lines of output from
+// each test.
+func writeIncompleteSummary(
+	out io.Writer,
+	incomplete []TestCase,
+	withOutput bool,
+	reproduce func(TestCase) string,
+) {
+	if len(incomplete) == 0 {
+		return
+	}
+	fmt.Fprintln(out, "\n=== "+color.RedString("Incomplete"))
+	for idx, tc := range incomplete {
+		fmt.Fprintf(out, "=== %s: %s %s%s\n",
+			color.RedString("INCOMPLETE"),
+			RelativePackagePath(tc.Package),
+			tc.Test,
+			formatRunID(tc.RunID))
+		fmt.Fprintln(out, tc.Incomplete.Reason)
+		if withOutput {
+			for _, line := range tc.Incomplete.Output {
+				fmt.Fprint(out, line)
+			}
+		}
+		if reproduce != nil {
+			fmt.Fprintln(out, "reproduce: "+reproduce(tc))
+		}
+		if !withOutput {
+			continue
+		}
+		if idx+1 != len(incomplete) {
+			fmt.Fprintln(out)
+		}
+	}
+}
+
+func writeRaceSummary(out io.Writer, races []DataRace) {
+	if len(races) == 0 {
+		return
+	}
+	fmt.Fprintln(out, "\n=== "+color.RedString("Races"))
+	for idx, race := range races {
+		names := make([]string, 0, len(race.Tests))
+		for _, tc := range race.Tests {
+			names = append(names, tc.Test.Name()+formatRunID(tc.RunID))
+		}
+		fmt.Fprintf(out, "=== %s: %s\n",
+			color.RedString("RACE"),
+			strings.TrimSpace(RelativePackagePath(race.Package)+" "+strings.Join(names, ", ")))
+		for _, line := range race.Lines {
+			if line == raceSeparator || line == raceWarning {
+				continue
+			}
+			fmt.Fprintln(out, line)
+		}
+		if idx+1 != len(races) {
+			fmt.Fprintln(out)
+		}
+	}
+}
+
+func writePanicSummary(out io.Writer, panics []Panic) {
+	if len(panics) == 0 {
+		return
+	}
+	fmt.Fprintln(out, "\n=== "+color.RedString("Panics"))
+	for idx, p := range panics {
+		prefix := "PANIC"
+		if p.Timeout {
+			prefix = "TIMEOUT"
+		}
+		fmt.Fprintf(out, "=== %s: %s%s\n",
+			color.RedString(prefix),
+			strings.TrimSpace(RelativePackagePath(p.Test.Package)+" "+p.Test.Test.Name()),
+			formatRunID(p.Test.RunID))
+		fmt.Fprintln(out, p.Message())
+
+		if p.Timeout {
+			if len(p.RunningTests) > 0 {
+				fmt.Fprintln(out, "running tests:")
+			}
+			for _, name := range p.RunningTests {
+				fmt.Fprintln(out, "\t"+name)
+			}
+		} else {
+			if p.Source.File != "" {
+				fmt.Fprintf(out, "at %s:%d\n", p.Source.File, p.Source.Line)
+			}
+			if p.Goroutine != "" {
+				fmt.Fprintln(out, p.Goroutine+":")
+			}
+			for _, frame := range p.Frames {
+				fmt.Fprintf(out, "%s\n\t%s:%d\n", frame.Function, frame.File, frame.Line)
+			}
+		}
+		if idx+1 != len(panics) {
+			fmt.Fprintln(out)
+		}
+	}
+}
+
 // countErrors in stderr lines. Build errors may include multiple lines where
 // subsequent lines are indented.
 // FIXME: Panics will include multiple lines, and are still overcounted.
@@ -165,11 +358,23 @@ func newExecSummary(execution *Execution, opts Summary) executionSummary {
 }
 
 func writeTestCaseSummary(out io.Writer, execution executionSummary, conf testCaseFormatConfig) {
-	testCases := conf.getter(execution)
+	testCases := filterByAttrs(conf.getter(execution), conf.attrs.Filter)
 	if len(testCases) == 0 {
 		return
 	}
-	fmt.Fprintln(out, "\n=== "+conf.header)
+	if conf.attrs.GroupBy == "" {
+		fmt.Fprintln(out, "\n=== "+conf.header)
+		writeTestCases(out, execution, conf, testCases)
+		return
+	}
+	for _, group := range groupByAttr(testCases, conf.attrs.GroupBy) {
+		fmt.Fprintf(out, "\n=== %s (%s)\n", conf.header, group.name)
+		writeTestCases(out, execution, conf, group.testCases)
+	}
+}
+
+func writeTestCases(out io.Writer, execution executionSummary, conf testCaseFormatConfig, testCases []TestCase) {
+	_, isNoOutput := execution.(*noOutputSummary)
 	for idx, tc := range testCases {
 		fmt.Fprintf(out, "=== %s: %s %s%s (%s)\n",
 			conf.prefix,
@@ -177,13 +382,16 @@ func writeTestCaseSummary(out io.Writer, execution executionSummary, conf testCa
 			tc.Test,
 			formatRunID(tc.RunID),
 			FormatDurationAsSeconds(tc.Elapsed, 2))
-		for _, line := range execution.OutputLines(tc) {
+		for _, line := range trimTimeoutPanicOutput(execution.OutputLines(tc)) {
 			if isFramingLine(line) || conf.filter(tc.Test.Name(), line) {
 				continue
 			}
 			fmt.Fprint(out, line)
 		}
-		if _, isNoOutput := execution.(*noOutputSummary); !isNoOutput && idx+1 != len(testCases) {
+		if conf.reproduce != nil {
+			fmt.Fprintln(out, "reproduce: "+conf.reproduce(tc))
+		}
+		if !isNoOutput && idx+1 != len(testCases) {
 			fmt.Fprintln(out)
 		}
 	}
@@ -192,8 +400,16 @@ func writeTestCaseSummary(out io.Writer, execution executionSummary, conf testCa
 type testCaseFormatConfig struct {
 	header string
 	prefix string
-	filter func(testName string, line string) bool
-	getter func(executionSummary) []TestCase
+	attrs  SummaryAttrs
+	// reproduce returns the command to run the test again, or is nil.
+	reproduce func(TestCase) string
+	filter    func(testName string, line string) bool
+	getter    func(executionSummary) []TestCase
+}
+
+func (c testCaseFormatConfig) withAttrs(attrs SummaryAttrs) testCaseFormatConfig {
+	c.attrs = attrs
+	return c
 }
 
 func formatFailed() testCaseFormatConfig {
@@ -205,7 +421,8 @@ func formatFailed() testCaseFormatConfig {
 			return strings.HasPrefix(line, "--- FAIL: "+testName+" ")
 		},
 		getter: func(execution executionSummary) []TestCase {
-			return execution.Failed()
+			// incomplete tests are printed in their own section
+			return withoutIncomplete(execution.Failed())
 		},
 	}
 }
@@ -226,6 +443,7 @@ func formatSkipped() testCaseFormatConfig {
 
 func isFramingLine(line string) bool {
 	return strings.HasPrefix(line, "=== RUN   Test") ||
+		strings.HasPrefix(line, "=== ATTR  Test") ||
 		strings.HasPrefix(line, "=== PAUSE Test") ||
 		strings.HasPrefix(line, "=== CONT  Test")
 }
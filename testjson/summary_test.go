@@ -9,6 +9,7 @@ import (
 
 	"gotest.tools/gotestsum/internal/text"
 	"gotest.tools/v3/assert"
+	"gotest.tools/v3/assert/cmp"
 	"gotest.tools/v3/golden"
 )
 
@@ -26,7 +27,7 @@ func TestSummary_String(t *testing.T) {
 		{
 			name:     "all",
 			summary:  SummarizeAll,
-			expected: "skipped,failed,errors,output",
+			expected: "skipped,failed,errors,output,races,panics,incomplete",
 		},
 		{
 			name:     "one value",
@@ -142,10 +143,8 @@ Some stdout/stderr here
 				},
 			},
 		},
-		errors: []string{
-			"pkg/file.go:99:12: missing ',' before newline",
-		},
 	}
+	exec.addError("pkg/file.go:99:12: missing ',' before newline")
 	timeNow = func() time.Time {
 		return start.Add(34123111 * time.Microsecond)
 	}
@@ -278,6 +277,36 @@ func TestPrintSummary(t *testing.T) {
 			config:      scanConfigFromGolden("input/go-test-json-with-parallel-fails.out"),
 			expectedOut: "summary/parallel-failures",
 		},
+		{
+			name:        "with data races",
+			config:      scanConfigFromGolden("input/go-test-json-race.out"),
+			expectedOut: "summary/with-data-races",
+		},
+		{
+			name: "with build failures",
+			config: func(t *testing.T) ScanConfig {
+				return ScanConfig{
+					Stdout: bytes.NewReader(golden.Get(t, "input/go-test-json-build-failed.out")),
+					Stderr: bytes.NewReader(golden.Get(t, "input/go-test-json-build-failed.err")),
+				}
+			},
+			expectedOut: "summary/with-build-failures",
+		},
+		{
+			name:        "with build events",
+			config:      scanConfigFromGolden("input/go-test-json-build-events.out"),
+			expectedOut: "summary/with-build-failures",
+		},
+		{
+			name:        "with panic",
+			config:      scanConfigFromGolden("input/go-test-json-panic.out"),
+			expectedOut: "summary/with-panic",
+		},
+		{
+			name:        "with test timeout",
+			config:      scanConfigFromGolden("input/go-test-json-timeout.out"),
+			expectedOut: "summary/with-test-timeout",
+		},
 		{
 			name:        "missing skip message",
 			config:      scanConfigFromGolden("input/go-test-json-missing-skip-msg.out"),
@@ -336,3 +365,47 @@ func TestSummary_TestTimeoutPanicRace(t *testing.T) {
 	actual := text.ProcessLines(t, out, text.OpRemoveSummaryLineElapsedTime)
 	golden.Assert(t, actual, "summary/test-timeout-panic-race")
 }
+
+func TestPrintSummaryWithOptions_ReproduceCommand(t *testing.T) {
+	reproduce := func(tc TestCase) string {
+		return "go test -run=" + tc.Test.Name() + " " + tc.Package
+	}
+
+	t.Run("failed", func(t *testing.T) {
+		exec, err := ScanTestOutput(scanConfigFromGolden("input/go-test-json.out")(t))
+		assert.NilError(t, err)
+
+		out := new(bytes.Buffer)
+		PrintSummaryWithOptions(out, exec, SummaryOptions{
+			Sections:         SummarizeFailed,
+			ReproduceCommand: reproduce,
+		})
+		assert.Equal(t, strings.Count(out.String(), "\nreproduce: "), len(exec.Failed()))
+		assert.Assert(t, cmp.Contains(out.String(), "reproduce: go test -run=TestFailed "+
+			"gotest.tools/gotestsum/testjson/internal/withfails\n"))
+	})
+
+	t.Run("incomplete", func(t *testing.T) {
+		exec, err := ScanTestOutput(scanConfigFromGolden("input/go-test-json-missing-test-fail.out")(t))
+		assert.NilError(t, err)
+
+		out := new(bytes.Buffer)
+		PrintSummaryWithOptions(out, exec, SummaryOptions{
+			Sections:         SummarizeIncomplete,
+			ReproduceCommand: reproduce,
+		})
+		assert.Assert(t, cmp.Contains(out.String(),
+			"reproduce: go test -run=TestWaitOn_WithCompare gotest.tools/v3/poll\n"))
+	})
+}
+
+func TestPackage_ShuffleSeed(t *testing.T) {
+	exec, err := ScanTestOutput(scanConfigFromGolden("input/go-test-json-with-shuffle.out")(t))
+	assert.NilError(t, err)
+	pkg := exec.Package("gotest.tools/gotestsum/testjson/internal/parallelfails")
+	assert.Equal(t, pkg.ShuffleSeed(), "123456")
+
+	exec, err = ScanTestOutput(scanConfigFromGolden("input/go-test-json.out")(t))
+	assert.NilError(t, err)
+	assert.Equal(t, exec.Package("gotest.tools/gotestsum/testjson/internal/good").ShuffleSeed(), "")
+}
@@ -0,0 +1,145 @@
+package testjson
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// TemplateEvent is the data used to execute the template of a template
+// formatter. The fields of TestEvent can be used directly in the template,
+// ex: {{.Action}} {{.Package}} {{.Test}}.
+type TemplateEvent struct {
+	TestEvent
+	// Execution of all the events received so far.
+	Execution *Execution
+}
+
+// Output returns the output of the test from a pass, fail, or skip event.
+// Output returns an empty string for any other event.
+func (e TemplateEvent) Output() string {
+	if e.PackageEvent() || !e.Action.IsTerminal() || e.Execution == nil {
+		return ""
+	}
+	pkg := e.Execution.Package(e.Package)
+	if pkg == nil {
+		return ""
+	}
+
+	var tcs []TestCase
+	switch e.Action {
+	case ActionFail:
+		tcs = pkg.Failed
+	case ActionPass:
+		tcs = pkg.Passed
+	case ActionSkip:
+		tcs = pkg.Skipped
+	}
+	for i := len(tcs) - 1; i >= 0; i-- {
+		if tcs[i].Test.Name() == e.Test {
+			return strings.Join(pkg.OutputLines(tcs[i]), "")
+		}
+	}
+	return ""
+}
+
+// TemplateFilePrefix is the prefix of a template which is read from a file.
+// The rest of the value is the path to the file, ex: @summary.tmpl.
+const TemplateFilePrefix = "@"
+
+// ParseTemplate parses a text/template used to format events, or to print the
+// summary. If text starts with TemplateFilePrefix, the rest of text is the
+// path to a file which contains the template.
+//
+// The template has access to the following functions in addition to the
+// text/template builtins:
+//
+//	red, green, yellow, blue, magenta, cyan, white, bold - print text in color
+//	colorAction ACTION TEXT - print text in the color used for the action
+//	upper, lower - change the case of text
+//	relativePackagePath PKG - the package path relative to the module root
+//	testName PKG TEST - the relative package path joined to the test name
+//	seconds DURATION - format a time.Duration as seconds, ex: 1.234s
+//	elapsed SECONDS - format the Elapsed field of an event, ex: (1.23s)
+//	runID ID - format a RunID from a rerun, ex: (re-run 1)
+func ParseTemplate(text string) (*template.Template, error) {
+	name := "inline"
+	if path := strings.TrimPrefix(text, TemplateFilePrefix); path != text {
+		raw, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read template: %w", err)
+		}
+		name, text = path, string(raw)
+	}
+	tmpl, err := template.New(name).Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template: %w", err)
+	}
+	return tmpl, nil
+}
+
+var templateFuncs = withColorFuncs(template.FuncMap{
+	"upper":               strings.ToUpper,
+	"lower":               strings.ToLower,
+	"relativePackagePath": RelativePackagePath,
+	"testName": func(pkg string, test string) string {
+		return joinPkgToTestName(RelativePackagePath(pkg), test)
+	},
+	"seconds": func(d time.Duration) string {
+		return FormatDurationAsSeconds(d, 3)
+	},
+	"elapsed": func(seconds float64) string {
+		return TestEvent{Elapsed: seconds}.ElapsedFormatted()
+	},
+	"runID": formatRunID,
+}, ColorAuto)
+
+// withColorFuncs adds the functions which print text in color to funcs, using
+// the color setting from mode.
+func withColorFuncs(funcs template.FuncMap, mode ColorMode) template.FuncMap {
+	colorFunc := func(attr color.Attribute) func(text string) string {
+		return func(text string) string {
+			return newColor(mode, attr).Sprint(text)
+		}
+	}
+	funcs["red"] = colorFunc(color.FgRed)
+	funcs["green"] = colorFunc(color.FgGreen)
+	funcs["yellow"] = colorFunc(color.FgYellow)
+	funcs["blue"] = colorFunc(color.FgBlue)
+	funcs["magenta"] = colorFunc(color.FgMagenta)
+	funcs["cyan"] = colorFunc(color.FgCyan)
+	funcs["white"] = colorFunc(color.FgWhite)
+	funcs["bold"] = colorFunc(color.Bold)
+	funcs["colorAction"] = func(action Action, text string) string {
+		return colorEvent(mode, TestEvent{Action: action})("%s", text)
+	}
+	return funcs
+}
+
+// NewTemplateFormatter returns a formatter which executes tmpl with a
+// TemplateEvent for each event, and writes the result to out. The color
+// functions in tmpl use the color setting from opts.
+func NewTemplateFormatter(out io.Writer, tmpl *template.Template, opts FormatOptions) EventFormatter {
+	if opts.Color != ColorAuto {
+		tmpl = tmpl.Funcs(withColorFuncs(template.FuncMap{}, opts.Color))
+	}
+	buf := bufio.NewWriter(out)
+	return eventFormatterFunc(func(event TestEvent, exec *Execution) error {
+		if err := tmpl.Execute(buf, TemplateEvent{TestEvent: event, Execution: exec}); err != nil {
+			return err
+		}
+		return buf.Flush()
+	})
+}
+
+// PrintSummaryTemplate prints the summary of a test Execution by executing
+// tmpl with the Execution.
+func PrintSummaryTemplate(out io.Writer, execution *Execution, tmpl *template.Template) error {
+	return tmpl.Execute(out, execution)
+}
@@ -0,0 +1,67 @@
+package testjson
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+	"gotest.tools/v3/golden"
+)
+
+const testTemplate = `{{- if and (not .PackageEvent) (eq .Action "fail") -}}
+{{ .Output }}{{ colorAction .Action (upper (print .Action)) }} {{ testName .Package .Test }}{{ runID .RunID }} {{ elapsed .Elapsed }}
+{{ else if and .PackageEvent .Action.IsTerminal -}}
+{{ colorAction .Action (print .Action) }} {{ relativePackagePath .Package }} ({{ .Execution.Total }} tests so far)
+{{ end -}}`
+
+const testSummaryTemplate = `{{ len .Failed }} failed, {{ len .Skipped }} skipped, {{ .Total }} total
+{{ range .Errors }}error: {{ . }}
+{{ end -}}`
+
+func TestTemplateFormat(t *testing.T) {
+	tmpl, err := ParseTemplate(testTemplate)
+	assert.NilError(t, err)
+
+	out := new(bytes.Buffer)
+	shim := newFakeHandler(NewTemplateFormatter(out, tmpl, FormatOptions{}), "input/go-test-json")
+	exec, err := ScanTestOutput(shim.Config(t))
+	assert.NilError(t, err)
+	golden.Assert(t, out.String(), "format/template.out")
+
+	summaryTmpl, err := ParseTemplate(testSummaryTemplate)
+	assert.NilError(t, err)
+	out.Reset()
+	assert.NilError(t, PrintSummaryTemplate(out, exec, summaryTmpl))
+	golden.Assert(t, out.String(), "summary/template.out")
+}
+
+func TestNewEventFormatter_Template(t *testing.T) {
+	dir := fs.NewDir(t, t.Name(), fs.WithFile("format.tmpl", "{{.Action}} {{.Test}}\n"))
+
+	out := new(bytes.Buffer)
+	f := NewEventFormatter(out, TemplateFormatPrefix+TemplateFilePrefix+dir.Join("format.tmpl"), FormatOptions{})
+	assert.Assert(t, f != nil)
+	assert.NilError(t, f.Format(TestEvent{Action: ActionPass, Test: "TestOne"}, nil))
+	assert.Equal(t, out.String(), "pass TestOne\n")
+
+	f = NewEventFormatter(out, TemplateFormatPrefix+"{{.Action", FormatOptions{})
+	assert.Assert(t, f == nil)
+}
+
+func TestParseTemplate_Errors(t *testing.T) {
+	_, err := ParseTemplate("{{ .Action ")
+	assert.ErrorContains(t, err, "failed to parse template: ")
+
+	_, err = ParseTemplate("@does-not-exist.tmpl")
+	assert.ErrorContains(t, err, "failed to read template: ")
+}
+
+func TestParseTemplate_TextWithoutAction(t *testing.T) {
+	tmpl, err := ParseTemplate("tests finished\n")
+	assert.NilError(t, err)
+	out := new(strings.Builder)
+	assert.NilError(t, tmpl.Execute(out, nil))
+	assert.Equal(t, out.String(), "tests finished\n")
+}